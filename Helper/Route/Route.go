@@ -1,7 +1,31 @@
 package Route
 
+import "time"
+
 var PostURL string = "/AddTask"
 var GetURL string = "/GetTask"
 var EditURL string = "/EditTask"
 var DeleteURL string = "/DeleteTask"
 var ListPaginationURL string = "/ListTask"
+var ProblemsURL string = "/problems"
+var OpenAPIURL string = "/openapi.json"
+var DocsURL string = "/docs"
+var MetricsURL string = "/debug/vars"
+
+var TasksURL string = "/v1/tasks"
+var TaskURL string = TasksURL + "/:id"
+var TaskSearchURL string = TasksURL + "/search"
+var TasksBulkURL string = TasksURL + "/bulk"
+var TaskTransitionsURL string = TaskURL + "/transitions"
+var TaskHistoryURL string = TaskURL + "/history"
+var TaskRevertURL string = TaskURL + "/revert"
+var WorkflowURL string = "/v1/workflow"
+
+var TrashURL string = "/v1/trash"
+var TrashTaskURL string = TrashURL + "/:id"
+var TrashRestoreURL string = TrashTaskURL + "/restore"
+
+// LegacyDeprecatedAt and LegacySunsetAt are announced on the verb named
+// routes above through the Deprecation and Sunset headers.
+var LegacyDeprecatedAt time.Time = time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+var LegacySunsetAt time.Time = time.Date(2027, time.April, 18, 0, 0, 0, 0, time.UTC)
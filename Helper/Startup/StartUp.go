@@ -1,5 +1,40 @@
 package Startup
 
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
 var DebugMode int = 1
 var QAMode int = 2
 var PRODMode int = 3
+
+// ModeEnv is the environment variable that picks the mode the server runs
+// in, by the names in ModeNames. Unset, the server runs in DebugMode.
+var ModeEnv string = "TASKMANAGER_MODE"
+
+var ModeNames = map[int]string{
+	DebugMode: "debug",
+	QAMode:    "qa",
+	PRODMode:  "prod",
+}
+
+// ParseMode returns the mode ModeNames calls Name, ignoring case.
+func ParseMode(Name string) (int, error) {
+	for mode, name := range ModeNames {
+		if strings.EqualFold(name, strings.TrimSpace(Name)) {
+			return mode, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown mode %q, expected one of debug, qa or prod", Name)
+}
+
+// ModeFromEnv returns the mode ModeEnv names, DebugMode when it is unset.
+func ModeFromEnv() (int, error) {
+	name, ok := os.LookupEnv(ModeEnv)
+	if !ok || len(name) <= 0 {
+		return DebugMode, nil
+	}
+	return ParseMode(name)
+}
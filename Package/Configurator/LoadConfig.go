@@ -5,90 +5,245 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"log"
+	"flag"
+	"fmt"
+	"log/slog"
+	"slices"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/mattn/go-sqlite3"
 	"github.com/spf13/viper"
 )
 
 type ConfiguratorInterface interface {
-	LoadConfig()
-	LoadDBInstance()
+	LoadConfig(Mode int, Flags *flag.FlagSet) error
+	LoadDBInstance() error
 }
 
 type configParser struct {
-	DbDriver     string `mapstructure="DBDRIVER"`
-	DbConnString string `mapstructure="DBCONNSTRING"`
-	Address      string `mapstructure="ADDRESS"`
+	DbDriver               string        `mapstructure:"DBDRIVER"`
+	DbConnString           string        `mapstructure:"DBCONNSTRING"`
+	Address                string        `mapstructure:"ADDRESS"`
+	PageTokenSecret        string        `mapstructure:"PAGETOKENSECRET"`
+	TrashRetentionDays     int           `mapstructure:"TRASHRETENTIONDAYS"`
+	WorkflowInitial        string        `mapstructure:"WORKFLOWINITIAL"`
+	WorkflowTransitions    string        `mapstructure:"WORKFLOWTRANSITIONS"`
+	WorkflowTerminal       string        `mapstructure:"WORKFLOWTERMINAL"`
+	IdempotencyWindowHours int           `mapstructure:"IDEMPOTENCYWINDOWHOURS"`
+	SecretKeyFile          string        `mapstructure:"SECRETKEYFILE"`
+	DbMaxOpenConns         int           `mapstructure:"DBMAXOPENCONNS"`
+	DbMaxIdleConns         int           `mapstructure:"DBMAXIDLECONNS"`
+	DbConnMaxLifetime      time.Duration `mapstructure:"DBCONNMAXLIFETIME"`
+	DbConnMaxIdleTime      time.Duration `mapstructure:"DBCONNMAXIDLETIME"`
+	DbStartupTimeout       time.Duration `mapstructure:"DBSTARTUPTIMEOUT"`
+	DbRotateTimeout        time.Duration `mapstructure:"DBROTATETIMEOUT"`
+	LogLevel               string        `mapstructure:"LOGLEVEL"`
 }
 
 type ConfiguratorStruct struct {
+	// ConfigDir holds app.env and the app.<mode>.env files.
+	ConfigDir string
+	// NoDatabase leaves the database settings out of validation, for
+	// servers that keep their tasks in memory.
+	NoDatabase   bool
 	DbDriver     string
 	DbConnString string
 	SqlDBConn    *sql.DB
-	DbCtx        context.Context
-	DbCancelFunc context.CancelFunc
-	Address      string
+	// DbMaxOpenConns, DbMaxIdleConns, DbConnMaxLifetime and
+	// DbConnMaxIdleTime limit the pool of SqlDBConn as the database/sql
+	// setters of the same names do. SQLite is held to one open connection.
+	DbMaxOpenConns    int
+	DbMaxIdleConns    int
+	DbConnMaxLifetime time.Duration
+	DbConnMaxIdleTime time.Duration
+	// DbStartupTimeout is how long LoadDBInstance keeps pinging a database
+	// that does not answer yet.
+	DbStartupTimeout time.Duration
+	// DbRotateTimeout is how long Reload tries new database credentials.
+	DbRotateTimeout time.Duration
+	// LogLevel is the least severe level of the log lines written through
+	// log/slog, see slog.SetLogLoggerLevel.
+	LogLevel slog.Level
+	Address  string
+	// PageTokenSecret signs list page tokens. Left empty, tokens are signed
+	// with a random key and stop working when the server restarts.
+	PageTokenSecret string
+	// TrashRetentionDays is how long deleted tasks stay restorable before
+	// they are purged for good. Zero keeps them forever.
+	TrashRetentionDays int
+	// WorkflowInitial, WorkflowTransitions and WorkflowTerminal describe the
+	// Workflow_Status state machine, for example "todo", "todo>done,done>todo"
+	// and "". All empty keeps the default workflow.
+	WorkflowInitial     string
+	WorkflowTransitions string
+	WorkflowTerminal    string
+	// IdempotencyWindowHours is how long the response of a request sent with
+	// an Idempotency-Key is replayed to its retries. Zero keeps the default
+	// of a day.
+	IdempotencyWindowHours int
+	// SecretKeyFile holds the key that decrypts enc: values of DbConnString
+	// and PageTokenSecret, which are loaded already resolved.
+	SecretKeyFile string
+	// values holds every setting as it was configured, for Reload to compare.
+	values map[string]string
+	// connector opens the connections of SqlDBConn, RotateDBConnString
+	// changes the credentials it uses.
+	connector *rotatingConnector
 }
 
+var SupportedDrivers = []string{"mysql", "sqlite3"}
+
 func NewConfigurator() *ConfiguratorStruct {
-	return &ConfiguratorStruct{}
+	return &ConfiguratorStruct{
+		ConfigDir:        ".",
+		DbMaxIdleConns:   DefaultDBMaxIdleConns,
+		DbStartupTimeout: DefaultDBStartupTimeout,
+		DbRotateTimeout:  DefaultDBRotateTimeout,
+	}
 }
 
-func (Conf *ConfiguratorStruct) LoadConfig(Mode int) {
-
-	switch Mode {
-	case Startup.DebugMode:
-
-		var configParser configParser
+// EnvPrefix prefixes the environment variables that override a setting,
+// TASKMANAGER_DBCONNSTRING for DbConnString.
+const EnvPrefix string = "TASKMANAGER"
+
+// LoadConfig reads the settings of Mode from these layers, each one
+// overriding the ones before it:
+//
+//  1. the defaults in settings
+//  2. app.env in ConfigDir
+//  3. app.<mode>.env in ConfigDir, app.qa.env for Startup.QAMode
+//  4. EnvPrefix_<KEY> environment variables, TASKMANAGER_ADDRESS for Address
+//  5. the flags RegisterFlags added to Flags that were set, when Flags is
+//     not nil
+//
+// Neither file has to exist. The result is checked against settings and
+// every problem found is returned at once in a *ConfigError.
+func (Conf *ConfiguratorStruct) LoadConfig(Mode int, Flags *flag.FlagSet) error {
+
+	var configParser configParser
+
+	modeName, ok := Startup.ModeNames[Mode]
+	if !ok {
+		return fmt.Errorf("unknown mode %d", Mode)
+	}
 
-		viper.AddConfigPath(".")
-		viper.SetConfigName("app")
-		viper.SetConfigType("env")
+	layers := viper.New()
+	layers.AddConfigPath(Conf.ConfigDir)
+	layers.SetConfigType("env")
+	layers.SetEnvPrefix(EnvPrefix)
 
-		//viper.AutomaticEnv()
+	for _, item := range settings {
+		if item.Default != nil {
+			layers.SetDefault(item.Key, item.Default)
+		}
 
-		err := viper.ReadInConfig()
+		err := layers.BindEnv(item.Key)
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
+	}
 
-		err = viper.Unmarshal(&configParser)
+	layers.SetConfigName("app")
+	err := layers.ReadInConfig()
+	if err != nil && !errors.As(err, &viper.ConfigFileNotFoundError{}) {
+		return err
+	}
 
-		if err != nil {
-			log.Fatal(err)
+	layers.SetConfigName("app." + modeName)
+	err = layers.MergeInConfig()
+	if err != nil && !errors.As(err, &viper.ConfigFileNotFoundError{}) {
+		return err
+	}
+
+	if Flags != nil {
+		flagKeys := map[string]string{}
+		for _, item := range settings {
+			flagKeys[item.Flag] = item.Key
 		}
 
-		Conf.DbDriver = configParser.DbDriver
-		Conf.DbConnString = configParser.DbConnString
-		Conf.Address = configParser.Address
+		Flags.Visit(func(Flag *flag.Flag) {
+			if key, ok := flagKeys[Flag.Name]; ok {
+				layers.Set(key, Flag.Value.String())
+			}
+		})
+	}
 
-	case Startup.QAMode:
+	// Reload compares settings by what is configured, a reference to a
+	// secret that changed is still the same setting.
+	values := map[string]string{}
+	for _, item := range settings {
+		values[item.Key] = layers.GetString(item.Key)
+	}
 
-	case Startup.QAMode:
+	err = validate(layers, modeName, Conf.NoDatabase).OrNil()
 
+	if err != nil {
+		return err
 	}
+
+	err = layers.Unmarshal(&configParser)
+
+	if err != nil {
+		return err
+	}
+
+	Conf.DbDriver = configParser.DbDriver
+	Conf.DbConnString = configParser.DbConnString
+	Conf.Address = configParser.Address
+	Conf.PageTokenSecret = configParser.PageTokenSecret
+	Conf.TrashRetentionDays = configParser.TrashRetentionDays
+	Conf.WorkflowInitial = configParser.WorkflowInitial
+	Conf.WorkflowTransitions = configParser.WorkflowTransitions
+	Conf.WorkflowTerminal = configParser.WorkflowTerminal
+	Conf.IdempotencyWindowHours = configParser.IdempotencyWindowHours
+	Conf.SecretKeyFile = configParser.SecretKeyFile
+	Conf.DbMaxOpenConns = configParser.DbMaxOpenConns
+	Conf.DbMaxIdleConns = configParser.DbMaxIdleConns
+	Conf.DbConnMaxLifetime = configParser.DbConnMaxLifetime
+	Conf.DbConnMaxIdleTime = configParser.DbConnMaxIdleTime
+	Conf.DbStartupTimeout = configParser.DbStartupTimeout
+	Conf.DbRotateTimeout = configParser.DbRotateTimeout
+	Conf.LogLevel, _ = parseLogLevel(configParser.LogLevel)
+	Conf.values = values
+
+	return nil
 }
 
+// LoadDBInstance opens the pool of SqlDBConn with the pool settings and
+// waits for the database to answer a ping, for up to DbStartupTimeout.
 func (Conf *ConfiguratorStruct) LoadDBInstance() error {
 	if len(Conf.DbConnString) <= 0 {
 		return errors.New("Configuration is not Loaded Properly. Please run LoadConfig() before executing this function")
 	}
 
-	db, err := sql.Open(Conf.DbDriver, Conf.DbConnString)
+	if !slices.Contains(SupportedDrivers, Conf.DbDriver) {
+		return fmt.Errorf("Unsupported DbDriver %q. Supported drivers are %v", Conf.DbDriver, SupportedDrivers)
+	}
+
+	connector, err := newRotatingConnector(Conf.DbDriver, Conf.DbConnString)
 
 	if err != nil {
 		return err
 	}
 
-	Conf.SqlDBConn = db
+	db := sql.OpenDB(connector)
+
+	Conf.applyPoolLimits(db)
 
-	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Millisecond*2)
+	ctx, cancelFunc := context.WithTimeout(context.Background(), Conf.DbStartupTimeout)
+	defer cancelFunc()
 
-	Conf.DbCtx = ctx
+	err = pingWithRetry(ctx, db, pingBaseDelay, pingMaxDelay)
 
-	Conf.DbCancelFunc = cancelFunc
+	if err != nil {
+		db.Close()
+		return fmt.Errorf("database did not answer within %v: %w", Conf.DbStartupTimeout, err)
+	}
+
+	Conf.SqlDBConn = db
+	Conf.connector = connector
+	pool.Store(db)
 
 	return nil
 }
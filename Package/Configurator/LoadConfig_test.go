@@ -0,0 +1,401 @@
+package Configurator
+
+import (
+	"TaskManager/Helper/Startup"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"expvar"
+	"flag"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/suite"
+)
+
+type ConfiguratorSuiteStruct struct {
+	suite.Suite
+	Config *ConfiguratorStruct
+}
+
+func (Suite *ConfiguratorSuiteStruct) SetupTest() {
+	Suite.Config = NewConfigurator()
+	Suite.Config.ConfigDir = Suite.T().TempDir()
+}
+
+func (Suite *ConfiguratorSuiteStruct) WriteConfig(Name string, Content string) {
+	err := os.WriteFile(filepath.Join(Suite.Config.ConfigDir, Name), []byte(Content), 0o600)
+	Suite.Require().NoError(err)
+}
+
+func (Suite *ConfiguratorSuiteStruct) TestDefaultsWithoutFiles() {
+	Suite.Config.NoDatabase = true
+	Suite.Require().NoError(Suite.Config.LoadConfig(Startup.PRODMode, nil))
+
+	Suite.Equal("mysql", Suite.Config.DbDriver)
+	Suite.Equal("localhost:8080", Suite.Config.Address)
+	Suite.Empty(Suite.Config.DbConnString)
+}
+
+func (Suite *ConfiguratorSuiteStruct) TestLayersOverrideInOrder() {
+	Suite.WriteConfig("app.env", "DBDRIVER=sqlite3\nDBCONNSTRING=base.db\nADDRESS=:1000\nTRASHRETENTIONDAYS=7\nWORKFLOWINITIAL=todo\n")
+	Suite.WriteConfig("app.qa.env", "DBCONNSTRING=qa.db\nADDRESS=:2000\nTRASHRETENTIONDAYS=14\n")
+	Suite.WriteConfig("app.prod.env", "DBCONNSTRING=prod.db\n")
+
+	Suite.T().Setenv(EnvPrefix+"_ADDRESS", ":3000")
+	Suite.T().Setenv(EnvPrefix+"_TRASHRETENTIONDAYS", "21")
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	Suite.Config.RegisterFlags(flags)
+	Suite.Require().NoError(flags.Parse([]string{"-trash-retention-days", "28"}))
+
+	Suite.Require().NoError(Suite.Config.LoadConfig(Startup.QAMode, flags))
+
+	Suite.Equal("sqlite3", Suite.Config.DbDriver, "app.env")
+	Suite.Equal("todo", Suite.Config.WorkflowInitial, "app.env")
+	Suite.Equal("qa.db", Suite.Config.DbConnString, "app.qa.env over app.env")
+	Suite.Equal(":3000", Suite.Config.Address, "environment over files")
+	Suite.Equal(28, Suite.Config.TrashRetentionDays, "flags over environment")
+}
+
+func (Suite *ConfiguratorSuiteStruct) TestUnknownMode() {
+	Suite.Error(Suite.Config.LoadConfig(0, nil))
+}
+
+func (Suite *ConfiguratorSuiteStruct) TestReportsEveryProblem() {
+	Suite.WriteConfig("app.env", "DBDRIVER=postgres\nADDRESS=localhost\nTRASHRETENTIONDAYS=-1\nIDEMPOTENCYWINDOWHOURS=1d\nLOGLEVEL=loud\nADRESS=:8080\n")
+
+	err := Suite.Config.LoadConfig(Startup.QAMode, nil)
+
+	var configErr *ConfigError
+	Suite.Require().ErrorAs(err, &configErr)
+	Suite.Equal("qa", configErr.Mode)
+
+	keys := []string{}
+	for _, problem := range configErr.Problems {
+		keys = append(keys, problem.Key)
+	}
+	Suite.ElementsMatch([]string{"DbDriver", "DbConnString", "Address", "TrashRetentionDays", "IdempotencyWindowHours", "LogLevel", "ADRESS"}, keys)
+
+	Suite.Contains(err.Error(), "TASKMANAGER_DBCONNSTRING")
+	Suite.Contains(err.Error(), "app.qa.env")
+	Suite.Empty(Suite.Config.DbDriver, "nothing is assigned from an invalid configuration")
+}
+
+func (Suite *ConfiguratorSuiteStruct) TestAddressConstraints() {
+	Suite.Config.NoDatabase = true
+
+	for address, valid := range map[string]bool{
+		":8080":           true,
+		"localhost:80":    true,
+		"[::1]:443":       true,
+		"localhost":       false,
+		"localhost:0":     false,
+		"localhost:http":  false,
+		"localhost:70000": false,
+	} {
+		Suite.T().Setenv(EnvPrefix+"_ADDRESS", address)
+		err := Suite.Config.LoadConfig(Startup.DebugMode, nil)
+		Suite.Equal(valid, err == nil, address)
+	}
+}
+
+func (Suite *ConfiguratorSuiteStruct) TestNoDatabaseSkipsDatabaseSettings() {
+	Suite.WriteConfig("app.env", "DBDRIVER=postgres\n")
+
+	Suite.Config.NoDatabase = true
+	Suite.NoError(Suite.Config.LoadConfig(Startup.DebugMode, nil))
+
+	Suite.Config.NoDatabase = false
+	Suite.Error(Suite.Config.LoadConfig(Startup.DebugMode, nil))
+}
+
+func (Suite *ConfiguratorSuiteStruct) TestReloadAppliesReloadableSettings() {
+	Suite.WriteConfig("app.env", "DBCONNSTRING=app.db\nTRASHRETENTIONDAYS=7\n")
+	Suite.Require().NoError(Suite.Config.LoadConfig(Startup.DebugMode, nil))
+
+	reloader := NewReloader(Suite.Config, Startup.DebugMode, nil)
+
+	calls := []ReloadableSettings{}
+	reloader.Subscribe(func(Previous ReloadableSettings, Current ReloadableSettings) {
+		calls = append(calls, Previous, Current)
+	})
+
+	Suite.Require().NoError(reloader.Reload())
+	Suite.Empty(calls, "subscribers only hear about changes")
+
+	Suite.WriteConfig("app.env", "DBCONNSTRING=app.db\nTRASHRETENTIONDAYS=14\nIDEMPOTENCYWINDOWHOURS=2\nLOGLEVEL=warn\nDBCONNMAXLIFETIME=30m\nDBROTATETIMEOUT=5s\n")
+	Suite.Require().NoError(reloader.Reload())
+
+	Suite.Equal([]ReloadableSettings{
+		{TrashRetentionDays: 7, LogLevel: slog.LevelInfo, DbRotateTimeout: DefaultDBRotateTimeout},
+		{TrashRetentionDays: 14, IdempotencyWindowHours: 2, LogLevel: slog.LevelWarn, DbConnMaxLifetime: 30 * time.Minute, DbRotateTimeout: 5 * time.Second},
+	}, calls)
+	Suite.Equal(14, Suite.Config.TrashRetentionDays)
+	Suite.Equal(slog.LevelWarn, Suite.Config.LogLevel)
+	Suite.Equal(30*time.Minute, Suite.Config.DbConnMaxLifetime)
+}
+
+func (Suite *ConfiguratorSuiteStruct) TestReloadRefusesRestartSettings() {
+	Suite.WriteConfig("app.env", "DBCONNSTRING=app.db\nTRASHRETENTIONDAYS=7\n")
+	Suite.Require().NoError(Suite.Config.LoadConfig(Startup.DebugMode, nil))
+
+	reloader := NewReloader(Suite.Config, Startup.DebugMode, nil)
+	reloader.Subscribe(func(Previous ReloadableSettings, Current ReloadableSettings) {
+		Suite.Fail("a refused reload must not reach subscribers")
+	})
+
+	Suite.WriteConfig("app.env", "DBCONNSTRING=other.db\nTRASHRETENTIONDAYS=14\n")
+
+	var restartErr *RestartRequiredError
+	Suite.Require().ErrorAs(reloader.Reload(), &restartErr)
+	Suite.Equal([]string{"DbConnString"}, restartErr.Keys)
+
+	Suite.WriteConfig("app.env", "DBCONNSTRING=app.db\nTRASHRETENTIONDAYS=-1\n")
+
+	var configErr *ConfigError
+	Suite.Require().ErrorAs(reloader.Reload(), &configErr)
+
+	Suite.Equal("app.db", Suite.Config.DbConnString)
+	Suite.Equal(7, Suite.Config.TrashRetentionDays)
+}
+
+func (Suite *ConfiguratorSuiteStruct) TestWatchReloadsOnWrite() {
+	Suite.WriteConfig("app.env", "DBCONNSTRING=app.db\n")
+	Suite.Require().NoError(Suite.Config.LoadConfig(Startup.DebugMode, nil))
+
+	reloader := NewReloader(Suite.Config, Startup.DebugMode, nil)
+
+	reloaded := make(chan ReloadableSettings, 10)
+	reloader.Subscribe(func(Previous ReloadableSettings, Current ReloadableSettings) {
+		reloaded <- Current
+	})
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	watching := make(chan error, 1)
+	go func() { watching <- reloader.Watch(ctx) }()
+
+	// The watcher has to be in place before the write it should see.
+	time.Sleep(100 * time.Millisecond)
+	Suite.WriteConfig("app.debug.env", "TRASHRETENTIONDAYS=3\n")
+
+	select {
+	case current := <-reloaded:
+		Suite.Equal(3, current.TrashRetentionDays)
+	case <-time.After(5 * time.Second):
+		Suite.Fail("no reload after app.debug.env was written")
+	}
+
+	cancelFunc()
+	Suite.NoError(<-watching)
+}
+
+func (Suite *ConfiguratorSuiteStruct) TestSecretReferences() {
+	keyFile := filepath.Join(Suite.Config.ConfigDir, "secret.key")
+	Suite.Require().NoError(os.WriteFile(keyFile, []byte(strings.Repeat("ab", 32)+"\n"), 0o600))
+
+	encrypted, err := EncryptSecret(keyFile, "page-secret")
+	Suite.Require().NoError(err)
+
+	secretFile := filepath.Join(Suite.Config.ConfigDir, "db")
+	Suite.Require().NoError(os.WriteFile(secretFile, []byte("user:password@/tasks\n"), 0o600))
+
+	Suite.WriteConfig("app.env", "DBCONNSTRING=secret+file://"+secretFile+"\nPAGETOKENSECRET="+encrypted+"\nSECRETKEYFILE="+keyFile+"\n")
+	Suite.Require().NoError(Suite.Config.LoadConfig(Startup.DebugMode, nil))
+
+	Suite.Equal("user:password@/tasks", Suite.Config.DbConnString)
+	Suite.Equal("page-secret", Suite.Config.PageTokenSecret)
+
+	Suite.T().Setenv("TASKS_DB", "from:env@/tasks")
+	Suite.T().Setenv(EnvPrefix+"_DBCONNSTRING", "env:TASKS_DB")
+	Suite.Require().NoError(Suite.Config.LoadConfig(Startup.DebugMode, nil))
+
+	Suite.Equal("from:env@/tasks", Suite.Config.DbConnString)
+
+	sqliteURI := "file:" + filepath.Join(Suite.Config.ConfigDir, "tasks.db") + "?mode=rwc&_busy_timeout=5000"
+	Suite.T().Setenv(EnvPrefix+"_DBCONNSTRING", sqliteURI)
+	Suite.Require().NoError(Suite.Config.LoadConfig(Startup.DebugMode, nil))
+
+	Suite.Equal(sqliteURI, Suite.Config.DbConnString, "a SQLite file: URI is a connection string, not a reference")
+}
+
+func (Suite *ConfiguratorSuiteStruct) TestSecretProblems() {
+	Suite.WriteConfig("app.env", "DBCONNSTRING=env:TASKS_DB_UNSET\nPAGETOKENSECRET=enc:AAAA\n")
+
+	err := Suite.Config.LoadConfig(Startup.DebugMode, nil)
+
+	var configErr *ConfigError
+	Suite.Require().ErrorAs(err, &configErr)
+	Suite.Len(configErr.Problems, 2)
+	Suite.Contains(err.Error(), "TASKS_DB_UNSET is not set")
+	Suite.Contains(err.Error(), "needs SecretKeyFile")
+
+	keyFile := filepath.Join(Suite.Config.ConfigDir, "secret.key")
+	Suite.Require().NoError(os.WriteFile(keyFile, []byte(strings.Repeat("cd", 32)), 0o600))
+	Suite.WriteConfig("app.env", "DBCONNSTRING=secret+file:///nonexistent/db\nPAGETOKENSECRET=enc:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA\nSECRETKEYFILE="+keyFile+"\n")
+
+	err = Suite.Config.LoadConfig(Startup.DebugMode, nil)
+
+	Suite.Require().ErrorAs(err, &configErr)
+	Suite.Len(configErr.Problems, 2)
+	Suite.Contains(err.Error(), "cannot read secret file: open /nonexistent/db")
+	Suite.Contains(err.Error(), "does not decrypt")
+}
+
+func (Suite *ConfiguratorSuiteStruct) TestReloadRotatesDBConnString() {
+	first := filepath.Join(Suite.Config.ConfigDir, "first.db")
+	second := filepath.Join(Suite.Config.ConfigDir, "second.db")
+
+	secretFile := filepath.Join(Suite.Config.ConfigDir, "db")
+	Suite.Require().NoError(os.WriteFile(secretFile, []byte(first), 0o600))
+
+	Suite.WriteConfig("app.env", "DBDRIVER=sqlite3\nDBCONNSTRING=secret+file://"+secretFile+"\n")
+	Suite.Require().NoError(Suite.Config.LoadConfig(Startup.DebugMode, nil))
+	Suite.Require().NoError(Suite.Config.LoadDBInstance())
+	defer Suite.Config.SqlDBConn.Close()
+
+	_, err := Suite.Config.SqlDBConn.Exec("CREATE TABLE Marker (ID INTEGER)")
+	Suite.Require().NoError(err)
+
+	reloader := NewReloader(Suite.Config, Startup.DebugMode, nil)
+
+	Suite.Require().NoError(os.WriteFile(secretFile, []byte("file:/nonexistent/dir/tasks.db?mode=rw"), 0o600))
+	Suite.Error(reloader.Reload(), "credentials that do not connect are not rotated to")
+	Suite.Equal(first, Suite.Config.DbConnString)
+
+	Suite.Require().NoError(os.WriteFile(secretFile, []byte(second), 0o600))
+	Suite.Require().NoError(reloader.Reload())
+	Suite.Equal(second, Suite.Config.DbConnString)
+
+	_, err = Suite.Config.SqlDBConn.Exec("SELECT * FROM Marker")
+	Suite.Error(err, "the pool connects to the rotated database")
+
+	_, err = os.Stat(second)
+	Suite.NoError(err)
+}
+
+func (Suite *ConfiguratorSuiteStruct) TestPoolSettings() {
+	Suite.WriteConfig("app.env", "DBDRIVER=sqlite3\nDBCONNSTRING="+filepath.Join(Suite.Config.ConfigDir, "pool.db")+"\nDBMAXOPENCONNS=8\nDBMAXIDLECONNS=4\nDBCONNMAXLIFETIME=30m\nDBCONNMAXIDLETIME=90s\n")
+	Suite.Require().NoError(Suite.Config.LoadConfig(Startup.DebugMode, nil))
+
+	Suite.Equal(8, Suite.Config.DbMaxOpenConns)
+	Suite.Equal(4, Suite.Config.DbMaxIdleConns)
+	Suite.Equal(30*time.Minute, Suite.Config.DbConnMaxLifetime)
+	Suite.Equal(90*time.Second, Suite.Config.DbConnMaxIdleTime)
+	Suite.Equal(DefaultDBStartupTimeout, Suite.Config.DbStartupTimeout)
+
+	Suite.Require().NoError(Suite.Config.LoadDBInstance())
+	defer Suite.Config.SqlDBConn.Close()
+
+	Suite.Equal(1, Suite.Config.SqlDBConn.Stats().MaxOpenConnections, "SQLite keeps one connection")
+	Suite.Contains(expvar.Get("database").String(), `"MaxOpenConnections":1`)
+
+	Suite.WriteConfig("app.env", "DBCONNSTRING=app.db\nDBMAXOPENCONNS=many\nDBCONNMAXLIFETIME=-1m\nDBSTARTUPTIMEOUT=0s\n")
+
+	var configErr *ConfigError
+	Suite.Require().ErrorAs(Suite.Config.LoadConfig(Startup.DebugMode, nil), &configErr)
+	Suite.Len(configErr.Problems, 3)
+}
+
+// flakyConnector fails to connect until it has been asked Failures times.
+type flakyConnector struct {
+	driver.Connector
+	Failures int
+	attempts int
+}
+
+func (Connector *flakyConnector) Connect(Ctx context.Context) (driver.Conn, error) {
+	Connector.attempts++
+	if Connector.attempts <= Connector.Failures {
+		return nil, errors.New("connection refused")
+	}
+	return Connector.Connector.Connect(Ctx)
+}
+
+func (Suite *ConfiguratorSuiteStruct) TestPingWithRetry() {
+	target, err := newRotatingConnector("sqlite3", filepath.Join(Suite.Config.ConfigDir, "ping.db"))
+	Suite.Require().NoError(err)
+
+	flaky := &flakyConnector{Connector: target, Failures: 2}
+	db := sql.OpenDB(flaky)
+	defer db.Close()
+
+	Suite.Require().NoError(pingWithRetry(context.Background(), db, time.Millisecond, 2*time.Millisecond))
+	Suite.Equal(3, flaky.attempts)
+
+	down := sql.OpenDB(&flakyConnector{Connector: target, Failures: 1 << 30})
+	defer down.Close()
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelFunc()
+
+	Suite.ErrorContains(pingWithRetry(ctx, down, time.Millisecond, 10*time.Millisecond), "connection refused")
+}
+
+func (Suite *ConfiguratorSuiteStruct) TestSessionDSNPinsUTC() {
+	dsn, err := sessionDSN("mysql", "user:password@tcp(db:3306)/tasks?time_zone=%27Europe%2FBerlin%27&loc=Local")
+	Suite.Require().NoError(err)
+
+	config, err := mysql.ParseDSN(dsn)
+	Suite.Require().NoError(err)
+	Suite.Equal("'+00:00'", config.Params["time_zone"], "now() must fill the timestamps in UTC")
+	Suite.Equal(time.UTC, config.Loc)
+	Suite.Equal("password", config.Passwd)
+
+	dsn, err = sessionDSN("sqlite3", "file:tasks.db?mode=rwc")
+	Suite.Require().NoError(err)
+	Suite.Equal("file:tasks.db?mode=rwc", dsn)
+}
+
+// TestMySQLSessionIsUTC needs a MySQL server, TASKMANAGER_TEST_MYSQL names
+// its DSN. The session zone the DSN asks for is overridden.
+func (Suite *ConfiguratorSuiteStruct) TestMySQLSessionIsUTC() {
+	dsn, ok := os.LookupEnv(EnvPrefix + "_TEST_MYSQL")
+	if !ok {
+		Suite.T().Skip(EnvPrefix + "_TEST_MYSQL is not set")
+	}
+
+	config, err := mysql.ParseDSN(dsn)
+	Suite.Require().NoError(err)
+	if config.Params == nil {
+		config.Params = map[string]string{}
+	}
+	config.Params["time_zone"] = "'+05:00'"
+
+	Suite.Config.DbDriver = "mysql"
+	Suite.Config.DbConnString = config.FormatDSN()
+	Suite.Require().NoError(Suite.Config.LoadDBInstance())
+	defer Suite.Config.SqlDBConn.Close()
+
+	var offset int64
+	Suite.Require().NoError(Suite.Config.SqlDBConn.QueryRow("SELECT TIMESTAMPDIFF(SECOND, UTC_TIMESTAMP(), now())").Scan(&offset))
+	Suite.Zero(offset)
+}
+
+func (Suite *ConfiguratorSuiteStruct) TestModeFromEnv() {
+	Suite.T().Setenv(Startup.ModeEnv, "")
+	mode, err := Startup.ModeFromEnv()
+	Suite.Require().NoError(err)
+	Suite.Equal(Startup.DebugMode, mode)
+
+	Suite.T().Setenv(Startup.ModeEnv, "PROD")
+	mode, err = Startup.ModeFromEnv()
+	Suite.Require().NoError(err)
+	Suite.Equal(Startup.PRODMode, mode)
+
+	Suite.T().Setenv(Startup.ModeEnv, "staging")
+	_, err = Startup.ModeFromEnv()
+	Suite.Error(err)
+}
+
+func TestConfiguratorSuite(Testor *testing.T) {
+	suite.Run(Testor, new(ConfiguratorSuiteStruct))
+}
@@ -0,0 +1,104 @@
+package Configurator
+
+import (
+	"context"
+	"database/sql"
+	"expvar"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// The pool settings a ConfiguratorStruct from NewConfigurator starts with.
+const (
+	DefaultDBMaxIdleConns   int           = 2
+	DefaultDBStartupTimeout time.Duration = 30 * time.Second
+	DefaultDBRotateTimeout  time.Duration = 10 * time.Second
+)
+
+// How long LoadDBInstance waits between pings while the database comes up.
+// The delay doubles after every failed ping up to pingMaxDelay.
+const (
+	pingBaseDelay time.Duration = 100 * time.Millisecond
+	pingMaxDelay  time.Duration = 2 * time.Second
+)
+
+// pool is the pool LoadDBInstance opened last. Its sql.DBStats are
+// published through expvar as "database", null before there is a pool.
+var pool atomic.Pointer[sql.DB]
+
+func init() {
+	expvar.Publish("database", expvar.Func(func() any {
+		db := pool.Load()
+		if db == nil {
+			return nil
+		}
+		return db.Stats()
+	}))
+}
+
+// sessionDSN pins the session time zone of MySQL connections to UTC. The
+// schema fills its timestamps with now(), which follows the session zone,
+// while the Model binds and reads every time as UTC. SQLite keeps
+// CURRENT_TIMESTAMP in UTC already.
+func sessionDSN(DriverName string, DSN string) (string, error) {
+	if DriverName != "mysql" {
+		return DSN, nil
+	}
+
+	config, err := mysql.ParseDSN(DSN)
+	if err != nil {
+		return "", err
+	}
+
+	if config.Params == nil {
+		config.Params = map[string]string{}
+	}
+	config.Params["time_zone"] = "'+00:00'"
+	config.Loc = time.UTC
+
+	return config.FormatDSN(), nil
+}
+
+// applyPoolLimits sets the limits of the pool settings on DB.
+func (Conf *ConfiguratorStruct) applyPoolLimits(DB *sql.DB) {
+	DB.SetMaxOpenConns(Conf.DbMaxOpenConns)
+	DB.SetMaxIdleConns(Conf.DbMaxIdleConns)
+	DB.SetConnMaxLifetime(Conf.DbConnMaxLifetime)
+	DB.SetConnMaxIdleTime(Conf.DbConnMaxIdleTime)
+
+	if Conf.DbDriver == "sqlite3" {
+		// SQLite allows a single writer per file, funnel every transaction through one connection.
+		DB.SetMaxOpenConns(1)
+	}
+}
+
+// pingWithRetry pings DB until it answers or Ctx is done, waiting longer
+// after every failure, starting at BaseDelay and never more than MaxDelay.
+// It returns the last ping error when it gives up.
+func pingWithRetry(Ctx context.Context, DB *sql.DB, BaseDelay time.Duration, MaxDelay time.Duration) error {
+	delay := BaseDelay
+
+	for attempt := 1; ; attempt++ {
+		err := DB.PingContext(Ctx)
+
+		if err == nil {
+			return nil
+		}
+
+		slog.Warn("database not reachable yet", "attempt", attempt, "error", err)
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-Ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		delay = min(delay*2, MaxDelay)
+	}
+}
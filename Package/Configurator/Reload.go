@@ -0,0 +1,228 @@
+package Configurator
+
+import (
+	"TaskManager/Helper/Startup"
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ReloadableSettings are the settings a running server picks up without a
+// restart. Every other setting is marked as needing one. Reload applies the
+// pool timeouts to SqlDBConn itself, the subscribers apply the rest.
+type ReloadableSettings struct {
+	TrashRetentionDays     int
+	IdempotencyWindowHours int
+	LogLevel               slog.Level
+	DbConnMaxLifetime      time.Duration
+	DbConnMaxIdleTime      time.Duration
+	DbRotateTimeout        time.Duration
+}
+
+// Reloadable returns the current value of the settings in ReloadableSettings.
+func (Conf *ConfiguratorStruct) Reloadable() ReloadableSettings {
+	return ReloadableSettings{
+		TrashRetentionDays:     Conf.TrashRetentionDays,
+		IdempotencyWindowHours: Conf.IdempotencyWindowHours,
+		LogLevel:               Conf.LogLevel,
+		DbConnMaxLifetime:      Conf.DbConnMaxLifetime,
+		DbConnMaxIdleTime:      Conf.DbConnMaxIdleTime,
+		DbRotateTimeout:        Conf.DbRotateTimeout,
+	}
+}
+
+func (Conf *ConfiguratorStruct) setReloadable(Settings ReloadableSettings) {
+	Conf.TrashRetentionDays = Settings.TrashRetentionDays
+	Conf.IdempotencyWindowHours = Settings.IdempotencyWindowHours
+	Conf.LogLevel = Settings.LogLevel
+	Conf.DbConnMaxLifetime = Settings.DbConnMaxLifetime
+	Conf.DbConnMaxIdleTime = Settings.DbConnMaxIdleTime
+	Conf.DbRotateTimeout = Settings.DbRotateTimeout
+}
+
+// ReloadSubscriber is called with the reloadable settings before and after
+// a reload that changed them.
+type ReloadSubscriber func(Previous ReloadableSettings, Current ReloadableSettings)
+
+// RestartRequiredError refuses a reload that changes settings the server
+// only reads when it starts. Nothing of such a reload is applied.
+type RestartRequiredError struct {
+	Keys []string
+}
+
+func (Err *RestartRequiredError) Error() string {
+	return fmt.Sprintf("%s changed and only take effect after a restart, reload refused", strings.Join(Err.Keys, ", "))
+}
+
+// ReloaderStruct loads the configuration of Config again and hands the
+// reloadable settings that changed to its subscribers.
+type ReloaderStruct struct {
+	Config *ConfiguratorStruct
+	Mode   int
+	Flags  *flag.FlagSet
+
+	mutex       sync.Mutex
+	subscribers []ReloadSubscriber
+}
+
+// NewReloader reloads Config, already loaded with LoadConfig(Mode, Flags),
+// from the same layers.
+func NewReloader(Config *ConfiguratorStruct, Mode int, Flags *flag.FlagSet) *ReloaderStruct {
+	return &ReloaderStruct{Config: Config, Mode: Mode, Flags: Flags}
+}
+
+// Subscribe calls Subscriber after every reload that changes a reloadable
+// setting. Subscribers are called one at a time, in the order they
+// subscribed.
+func (Reloader *ReloaderStruct) Subscribe(Subscriber ReloadSubscriber) {
+	Reloader.mutex.Lock()
+	defer Reloader.mutex.Unlock()
+
+	Reloader.subscribers = append(Reloader.subscribers, Subscriber)
+}
+
+// Reload loads and validates the configuration again. An invalid
+// configuration is returned as a *ConfigError, one that changes a setting
+// needing a restart as a *RestartRequiredError, and neither is applied.
+// When the secret DbConnString refers to changed, the pool is moved to it
+// with RotateDBConnString, trying for up to the reloaded DbRotateTimeout.
+// Then the reloadable settings are copied into Config, the pool takes the
+// new timeouts and the subscribers are told when they changed.
+func (Reloader *ReloaderStruct) Reload() error {
+	Reloader.mutex.Lock()
+	defer Reloader.mutex.Unlock()
+
+	next := &ConfiguratorStruct{ConfigDir: Reloader.Config.ConfigDir, NoDatabase: Reloader.Config.NoDatabase}
+
+	err := next.LoadConfig(Reloader.Mode, Reloader.Flags)
+
+	if err != nil {
+		return err
+	}
+
+	changed := []string{}
+	for _, item := range settings {
+		if !item.Reloadable && next.values[item.Key] != Reloader.Config.values[item.Key] {
+			changed = append(changed, item.Key)
+		}
+	}
+
+	// The references are the same but what they point to changed.
+	if next.PageTokenSecret != Reloader.Config.PageTokenSecret {
+		changed = append(changed, "PageTokenSecret")
+	}
+
+	if len(changed) > 0 {
+		return &RestartRequiredError{Keys: changed}
+	}
+
+	if next.DbConnString != Reloader.Config.DbConnString {
+		if Reloader.Config.SqlDBConn == nil {
+			Reloader.Config.DbConnString = next.DbConnString
+		} else {
+			ctx, cancelFunc := context.WithTimeout(context.Background(), next.DbRotateTimeout)
+			err = Reloader.Config.RotateDBConnString(ctx, next.DbConnString)
+			cancelFunc()
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	previous := Reloader.Config.Reloadable()
+	current := next.Reloadable()
+
+	Reloader.Config.setReloadable(current)
+	Reloader.Config.values = next.values
+
+	if previous == current {
+		return nil
+	}
+
+	if Reloader.Config.SqlDBConn != nil {
+		Reloader.Config.applyPoolLimits(Reloader.Config.SqlDBConn)
+	}
+
+	for _, subscriber := range Reloader.subscribers {
+		subscriber(previous, current)
+	}
+
+	return nil
+}
+
+// Watch reloads when app.env or app.<mode>.env in ConfigDir is written,
+// created or replaced, and when the process receives SIGHUP, until Ctx is
+// done. Failed reloads are logged and leave the running settings as they
+// were. Secret references are not watched, send SIGHUP after rotating one.
+func (Reloader *ReloaderStruct) Watch(Ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+
+	if err != nil {
+		return err
+	}
+
+	defer watcher.Close()
+
+	// Editors often save by replacing the file, watching the directory sees
+	// the new file where watching the old one would not.
+	err = watcher.Add(Reloader.Config.ConfigDir)
+
+	if err != nil {
+		return err
+	}
+
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	defer signal.Stop(hangups)
+
+	files := map[string]bool{"app.env": true}
+	if modeName, ok := Startup.ModeNames[Reloader.Mode]; ok {
+		files["app."+modeName+".env"] = true
+	}
+
+	for {
+		select {
+		case <-Ctx.Done():
+			return nil
+
+		case <-hangups:
+			Reloader.reloadAndLog("SIGHUP")
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if files[filepath.Base(event.Name)] && event.Has(fsnotify.Write|fsnotify.Create) {
+				Reloader.reloadAndLog(filepath.Base(event.Name) + " changed")
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			slog.Warn("watching the configuration failed", "error", err)
+		}
+	}
+}
+
+func (Reloader *ReloaderStruct) reloadAndLog(Cause string) {
+	err := Reloader.Reload()
+
+	if err != nil {
+		slog.Warn("configuration not reloaded", "cause", Cause, "error", err)
+		return
+	}
+
+	slog.Info("configuration reloaded", "cause", Cause)
+}
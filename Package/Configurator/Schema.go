@@ -0,0 +1,218 @@
+package Configurator
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// setting is one configuration key, the flag that overrides it, the value
+// it has when no layer sets it and the rules its value must follow.
+type setting struct {
+	Key      string
+	Flag     string
+	Usage    string
+	Default  any
+	Required bool
+	// Database marks the settings only a server backed by a database needs.
+	Database bool
+	// Secret settings may hold a reference to their value, see resolveSecret.
+	Secret bool
+	// Reloadable settings can change while the server runs, see Reload.
+	Reloadable bool
+	// Check describes what is wrong with a value that is set, or returns "".
+	Check func(Value string) string
+}
+
+var settings = []setting{
+	{Key: "DbDriver", Flag: "db-driver", Usage: "database driver, one of mysql or sqlite3", Default: "mysql", Database: true, Check: checkDriver},
+	{Key: "DbConnString", Flag: "db-conn-string", Usage: "database connection string, or a secret+file://, env: or enc: reference to it", Required: true, Database: true, Secret: true},
+	{Key: "DbMaxOpenConns", Flag: "db-max-open-conns", Usage: "most open database connections, 0 for no limit", Default: 0, Database: true, Check: checkWholeNumber},
+	{Key: "DbMaxIdleConns", Flag: "db-max-idle-conns", Usage: "most idle database connections kept open, 0 keeps none", Default: DefaultDBMaxIdleConns, Database: true, Check: checkWholeNumber},
+	{Key: "DbConnMaxLifetime", Flag: "db-conn-max-lifetime", Usage: "longest a database connection is reused, as 30m, 0 for no limit", Default: "0s", Database: true, Reloadable: true, Check: checkDuration},
+	{Key: "DbConnMaxIdleTime", Flag: "db-conn-max-idle-time", Usage: "longest a database connection stays idle, as 5m, 0 for no limit", Default: "0s", Database: true, Reloadable: true, Check: checkDuration},
+	{Key: "DbStartupTimeout", Flag: "db-startup-timeout", Usage: "how long startup waits for the database to answer, as 30s", Default: DefaultDBStartupTimeout.String(), Database: true, Check: checkPositiveDuration},
+	{Key: "DbRotateTimeout", Flag: "db-rotate-timeout", Usage: "how long a reload tries new database credentials, as 10s", Default: DefaultDBRotateTimeout.String(), Database: true, Reloadable: true, Check: checkPositiveDuration},
+	{Key: "Address", Flag: "address", Usage: "listen address", Default: "localhost:8080", Required: true, Check: checkAddress},
+	{Key: "PageTokenSecret", Flag: "page-token-secret", Usage: "secret that signs list page tokens, or a secret+file://, env: or enc: reference to it", Secret: true},
+	{Key: "SecretKeyFile", Flag: "secret-key-file", Usage: "file holding the hex encoded AES-256 key of enc: values"},
+	{Key: "TrashRetentionDays", Flag: "trash-retention-days", Usage: "days deleted tasks stay restorable, 0 keeps them forever", Default: 0, Reloadable: true, Check: checkWholeNumber},
+	{Key: "WorkflowInitial", Flag: "workflow-initial", Usage: "workflow status of new tasks"},
+	{Key: "WorkflowTransitions", Flag: "workflow-transitions", Usage: "allowed workflow transitions, as from>to,from>to"},
+	{Key: "WorkflowTerminal", Flag: "workflow-terminal", Usage: "workflow statuses tasks cannot leave"},
+	{Key: "IdempotencyWindowHours", Flag: "idempotency-window-hours", Usage: "hours responses to Idempotency-Key requests are replayed", Default: 0, Reloadable: true, Check: checkWholeNumber},
+	{Key: "LogLevel", Flag: "log-level", Usage: "least severe log lines written, one of debug, info, warn or error", Default: "info", Reloadable: true, Check: checkLogLevel},
+}
+
+// RegisterFlags adds a flag for every setting to Flags. Only the flags set
+// on the command line override the other layers.
+func (Conf *ConfiguratorStruct) RegisterFlags(Flags *flag.FlagSet) {
+	for _, item := range settings {
+		Flags.String(item.Flag, "", item.Usage)
+	}
+}
+
+func checkDriver(Value string) string {
+	if !slices.Contains(SupportedDrivers, Value) {
+		return fmt.Sprintf("unsupported driver %q, use one of %s", Value, strings.Join(SupportedDrivers, ", "))
+	}
+	return ""
+}
+
+func checkAddress(Value string) string {
+	_, port, err := net.SplitHostPort(Value)
+	if err != nil {
+		return fmt.Sprintf("%q is not a host:port address", Value)
+	}
+
+	number, err := strconv.Atoi(port)
+	if err != nil || number < 1 || number > 65535 {
+		return fmt.Sprintf("port %q of %q must be a number from 1 to 65535", port, Value)
+	}
+	return ""
+}
+
+func checkLogLevel(Value string) string {
+	if _, err := parseLogLevel(Value); err != nil {
+		return fmt.Sprintf("%q must be one of debug, info, warn or error", Value)
+	}
+	return ""
+}
+
+// parseLogLevel reads the level of a LogLevel setting.
+func parseLogLevel(Value string) (slog.Level, error) {
+	var level slog.Level
+	if !slices.Contains([]string{"debug", "info", "warn", "error"}, strings.ToLower(Value)) {
+		return level, fmt.Errorf("unknown log level %q", Value)
+	}
+	return level, level.UnmarshalText([]byte(Value))
+}
+
+func checkWholeNumber(Value string) string {
+	number, err := strconv.Atoi(Value)
+	if err != nil || number < 0 {
+		return fmt.Sprintf("%q must be a whole number of 0 or more", Value)
+	}
+	return ""
+}
+
+func checkDuration(Value string) string {
+	duration, err := time.ParseDuration(Value)
+	if err != nil || duration < 0 {
+		return fmt.Sprintf("%q must be a duration of 0 or more, as 90s or 5m", Value)
+	}
+	return ""
+}
+
+func checkPositiveDuration(Value string) string {
+	duration, err := time.ParseDuration(Value)
+	if err != nil || duration <= 0 {
+		return fmt.Sprintf("%q must be a duration of more than 0, as 90s or 5m", Value)
+	}
+	return ""
+}
+
+// ConfigProblem is one thing wrong with the configuration.
+type ConfigProblem struct {
+	Key     string
+	Message string
+}
+
+// ConfigError lists everything wrong with the configuration of a mode, so
+// that it can all be fixed before the next try.
+type ConfigError struct {
+	Mode     string
+	Problems []ConfigProblem
+}
+
+func (Err *ConfigError) Add(Key string, Message string) {
+	Err.Problems = append(Err.Problems, ConfigProblem{Key: Key, Message: Message})
+}
+
+// OrNil returns Err when it holds problems, and nil otherwise.
+func (Err *ConfigError) OrNil() error {
+	if len(Err.Problems) <= 0 {
+		return nil
+	}
+	return Err
+}
+
+func (Err *ConfigError) Error() string {
+	var message strings.Builder
+
+	fmt.Fprintf(&message, "invalid %s configuration, %d problem(s):", Err.Mode, len(Err.Problems))
+	for _, problem := range Err.Problems {
+		fmt.Fprintf(&message, "\n  %s: %s", problem.Key, problem.Message)
+	}
+
+	return message.String()
+}
+
+// validate checks every setting of Layers and every key they hold that is
+// not a setting, and collects all the problems found. NoDatabase skips the
+// settings marked Database. The references in Secret settings are replaced
+// with the values they resolve to.
+func validate(Layers *viper.Viper, ModeName string, NoDatabase bool) *ConfigError {
+	problems := &ConfigError{Mode: ModeName}
+
+	known := map[string]bool{}
+
+	for _, item := range settings {
+		known[strings.ToLower(item.Key)] = true
+
+		if item.Database && NoDatabase {
+			continue
+		}
+
+		value := strings.TrimSpace(Layers.GetString(item.Key))
+
+		if len(value) <= 0 {
+			if item.Required {
+				problems.Add(item.Key, fmt.Sprintf(
+					"is required, set %s in app.env or app.%s.env, %s_%s in the environment or -%s",
+					strings.ToUpper(item.Key), ModeName, EnvPrefix, strings.ToUpper(item.Key), item.Flag))
+			}
+			continue
+		}
+
+		if item.Check != nil {
+			if message := item.Check(value); len(message) > 0 {
+				problems.Add(item.Key, message)
+			}
+		}
+	}
+
+	keyFile := Layers.GetString("SecretKeyFile")
+
+	for _, item := range settings {
+		value := Layers.GetString(item.Key)
+
+		if !item.Secret || len(value) <= 0 || (item.Database && NoDatabase) {
+			continue
+		}
+
+		resolved, err := resolveSecret(value, keyFile)
+
+		if err != nil {
+			problems.Add(item.Key, err.Error())
+			continue
+		}
+
+		Layers.Set(item.Key, resolved)
+	}
+
+	for _, key := range Layers.AllKeys() {
+		if !known[key] {
+			problems.Add(strings.ToUpper(key), "is not a known setting")
+		}
+	}
+
+	return problems
+}
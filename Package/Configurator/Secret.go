@@ -0,0 +1,239 @@
+package Configurator
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// A setting marked Secret may hold a reference to its value instead of the
+// value itself, so that app.env can be checked in without credentials:
+//
+//	secret+file:///run/secrets/db   the content of the file, without the
+//	                                trailing newline
+//	env:DB_CONN_STRING              the value of the environment variable
+//	enc:<base64>                    the value encrypted by EncryptSecret with
+//	                                the key in SecretKeyFile
+//
+// Any other value is used as it is. Files take the secret+ scheme because a
+// SQLite connection string may itself be a file: URI.
+const (
+	secretFilePrefix      string = "secret+file://"
+	secretEnvPrefix       string = "env:"
+	secretEncryptedPrefix string = "enc:"
+)
+
+// secretKeySize is the size of the AES-256 key SecretKeyFile holds, hex
+// encoded. `openssl rand -hex 32` makes one.
+const secretKeySize int = 32
+
+// resolveSecret returns the value Reference points to. KeyFile is only read
+// for encrypted values. Errors name the reference, never the value.
+func resolveSecret(Reference string, KeyFile string) (string, error) {
+	switch {
+	case strings.HasPrefix(Reference, secretFilePrefix):
+		path := strings.TrimPrefix(Reference, secretFilePrefix)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("cannot read secret file: %w", err)
+		}
+		return strings.TrimRight(string(content), "\r\n"), nil
+
+	case strings.HasPrefix(Reference, secretEnvPrefix):
+		name := strings.TrimPrefix(Reference, secretEnvPrefix)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return value, nil
+
+	case strings.HasPrefix(Reference, secretEncryptedPrefix):
+		key, err := readSecretKey(KeyFile)
+		if err != nil {
+			return "", err
+		}
+		return decryptSecret(key, strings.TrimPrefix(Reference, secretEncryptedPrefix))
+	}
+
+	return Reference, nil
+}
+
+// readSecretKey reads the hex encoded key in Path.
+func readSecretKey(Path string) ([]byte, error) {
+	if len(Path) <= 0 {
+		return nil, errors.New("encrypted value needs SecretKeyFile")
+	}
+
+	content, err := os.ReadFile(Path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read secret key file: %w", err)
+	}
+
+	key, err := hex.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil || len(key) != secretKeySize {
+		return nil, fmt.Errorf("secret key file %s must hold %d hex encoded bytes", Path, secretKeySize)
+	}
+
+	return key, nil
+}
+
+func secretCipher(Key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(Key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptSecret encrypts Plain with the key in KeyFile into a value a
+// Secret setting accepts.
+func EncryptSecret(KeyFile string, Plain string) (string, error) {
+	key, err := readSecretKey(KeyFile)
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := secretCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(Plain), nil)
+
+	return secretEncryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func decryptSecret(Key []byte, Encoded string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(Encoded)
+	if err != nil {
+		return "", errors.New("encrypted value is not base64")
+	}
+
+	aead, err := secretCipher(Key)
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("encrypted value is too short")
+	}
+
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("encrypted value does not decrypt with the key in SecretKeyFile")
+	}
+
+	return string(plain), nil
+}
+
+// rotatingConnector opens connections with a DSN that can change while the
+// pool is in use. Connections already open keep the DSN they were opened
+// with. The DSN it holds is the one sessionDSN returned.
+type rotatingConnector struct {
+	driver driver.Driver
+	mutex  sync.RWMutex
+	dsn    string
+}
+
+func newRotatingConnector(DriverName string, DSN string) (*rotatingConnector, error) {
+	dsn, err := sessionDSN(DriverName, DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	// sql.Open only looks the driver up, it does not connect.
+	db, err := sql.Open(DriverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	return &rotatingConnector{driver: db.Driver(), dsn: dsn}, nil
+}
+
+func (Connector *rotatingConnector) current() string {
+	Connector.mutex.RLock()
+	defer Connector.mutex.RUnlock()
+
+	return Connector.dsn
+}
+
+func (Connector *rotatingConnector) rotate(DSN string) {
+	Connector.mutex.Lock()
+	defer Connector.mutex.Unlock()
+
+	Connector.dsn = DSN
+}
+
+func (Connector *rotatingConnector) Connect(Ctx context.Context) (driver.Conn, error) {
+	return connect(Ctx, Connector.driver, Connector.current())
+}
+
+func (Connector *rotatingConnector) Driver() driver.Driver {
+	return Connector.driver
+}
+
+func connect(Ctx context.Context, Driver driver.Driver, DSN string) (driver.Conn, error) {
+	if withContext, ok := Driver.(driver.DriverContext); ok {
+		connector, err := withContext.OpenConnector(DSN)
+		if err != nil {
+			return nil, err
+		}
+		return connector.Connect(Ctx)
+	}
+	return Driver.Open(DSN)
+}
+
+// RotateDBConnString switches the pool of LoadDBInstance to DSN, the
+// connection string with a new password, without closing it. A connection
+// is opened with DSN first and nothing changes when that fails. Then new
+// connections use DSN and the idle ones are closed, while the ones serving
+// a request finish with the credentials they were opened with.
+func (Conf *ConfiguratorStruct) RotateDBConnString(Ctx context.Context, DSN string) error {
+	if Conf.connector == nil || Conf.SqlDBConn == nil {
+		return errors.New("Database is not Loaded. Please run LoadDBInstance() before rotating its credentials")
+	}
+
+	dsn, err := sessionDSN(Conf.DbDriver, DSN)
+	if err != nil {
+		return err
+	}
+
+	conn, err := connect(Ctx, Conf.connector.driver, dsn)
+	if err != nil {
+		return fmt.Errorf("new database credentials do not connect: %w", err)
+	}
+
+	if pinger, ok := conn.(driver.Pinger); ok {
+		err = pinger.Ping(Ctx)
+	}
+	conn.Close()
+
+	if err != nil {
+		return fmt.Errorf("new database credentials do not connect: %w", err)
+	}
+
+	Conf.connector.rotate(dsn)
+	Conf.DbConnString = DSN
+
+	Conf.SqlDBConn.SetMaxIdleConns(0)
+	Conf.SqlDBConn.SetMaxIdleConns(Conf.DbMaxIdleConns)
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package Controller
+
+import (
+	"TaskManager/Package/Model"
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+const RequestIDHeader string = "X-Request-ID"
+const ActorHeader string = "X-Actor"
+
+// AnonymousActor is recorded for requests that do not name their actor.
+const AnonymousActor string = "anonymous"
+
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+var actorPattern = regexp.MustCompile(`^[^\x00-\x1f\x7f]{1,255}$`)
+
+// Audit names the actor and the request behind the changes a request makes,
+// so the task history can record them. A well formed X-Request-ID is kept,
+// otherwise one is generated, and it is echoed on the response either way.
+func Audit() gin.HandlerFunc {
+	return func(GinCtx *gin.Context) {
+		requestID := GinCtx.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+
+		actor := GinCtx.GetHeader(ActorHeader)
+		if !actorPattern.MatchString(actor) {
+			actor = AnonymousActor
+		}
+
+		GinCtx.Header(RequestIDHeader, requestID)
+		GinCtx.Request = GinCtx.Request.WithContext(Model.WithAuditor(GinCtx.Request.Context(), Model.Auditor{
+			Actor:      actor,
+			Request_ID: requestID,
+		}))
+		GinCtx.Next()
+	}
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
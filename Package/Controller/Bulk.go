@@ -0,0 +1,142 @@
+package Controller
+
+import (
+	"TaskManager/Package/Model"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func bulkMode(Mode string) string {
+	if len(Mode) <= 0 {
+		return Model.BulkAtomic
+	}
+	return Mode
+}
+
+// bulkStatus reads Task_Status like toTaskStoreRequest does. A value that
+// is not a boolean is recorded in Rejected under Index, so the Model fails
+// that item alone.
+func bulkStatus(Status string, Index int, Rejected map[int]*Model.ValidationError) bool {
+	status, err := strconv.ParseBool(Status)
+	if err != nil {
+		Rejected[Index] = statusViolation().(*Model.ValidationError)
+	}
+	return status
+}
+
+// BulkCreateTasks adds up to Model.MaxBulkItems tasks at once.
+func (Ctr *ControllerStruct) BulkCreateTasks(GinCtx *gin.Context) {
+	var req BulkCreateStruct
+
+	err := GinCtx.ShouldBindJSON(&req)
+	if err != nil {
+		RespondError(GinCtx, bindingError(err))
+		return
+	}
+
+	dbPayload := Model.BulkCreateStore{Mode: bulkMode(req.Mode), Rejected: map[int]*Model.ValidationError{}}
+
+	for index, task := range req.Tasks {
+		dbPayload.Tasks = append(dbPayload.Tasks, Model.TaskStoreRequest{
+			Title:            task.Title,
+			Task_Description: task.Task_Description,
+			Task_Status:      bulkStatus(task.Task_Status, index, dbPayload.Rejected),
+		})
+	}
+
+	resl, err := Ctr.Model.BulkCreate(GinCtx.Request.Context(), dbPayload)
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	respondBulk(GinCtx, resl, http.StatusCreated)
+}
+
+// BulkUpdateTasks replaces up to Model.MaxBulkItems tasks at once.
+func (Ctr *ControllerStruct) BulkUpdateTasks(GinCtx *gin.Context) {
+	var req BulkUpdateStruct
+
+	err := GinCtx.ShouldBindJSON(&req)
+	if err != nil {
+		RespondError(GinCtx, bindingError(err))
+		return
+	}
+
+	dbPayload := Model.BulkUpdateStore{Mode: bulkMode(req.Mode), Rejected: map[int]*Model.ValidationError{}}
+
+	for index, task := range req.Tasks {
+		dbPayload.Tasks = append(dbPayload.Tasks, Model.UpdateTaskStoreRequest{
+			ID:      task.ID,
+			Version: task.Version,
+			Task: Model.TaskStoreRequest{
+				Title:            task.Title,
+				Task_Description: task.Task_Description,
+				Task_Status:      bulkStatus(task.Task_Status, index, dbPayload.Rejected),
+			},
+		})
+	}
+
+	resl, err := Ctr.Model.BulkUpdate(GinCtx.Request.Context(), dbPayload)
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	respondBulk(GinCtx, resl, http.StatusOK)
+}
+
+// BulkDeleteTasks moves up to Model.MaxBulkItems tasks to the trash at once.
+func (Ctr *ControllerStruct) BulkDeleteTasks(GinCtx *gin.Context) {
+	var req BulkDeleteStruct
+
+	err := GinCtx.ShouldBindJSON(&req)
+	if err != nil {
+		RespondError(GinCtx, bindingError(err))
+		return
+	}
+
+	dbPayload := Model.BulkDeleteStore{Mode: bulkMode(req.Mode)}
+
+	for _, task := range req.Tasks {
+		dbPayload.Tasks = append(dbPayload.Tasks, Model.DeleteTaskStoreRequest{
+			ID:      task.ID,
+			Version: task.Version,
+		})
+	}
+
+	resl, err := Ctr.Model.BulkDelete(GinCtx.Request.Context(), dbPayload)
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	respondBulk(GinCtx, resl, http.StatusOK)
+}
+
+// respondBulk renders one BulkItemResponse per item, Applied items with
+// Status. The response is 200 when every item was applied and 207 otherwise.
+func respondBulk(GinCtx *gin.Context, Result Model.BulkResult, Status int) {
+	resp := BulkResponse{Items: []BulkItemResponse{}, Applied: Result.Applied}
+
+	for index, item := range Result.Items {
+		if item.Err != nil {
+			problem := NewProblem(item.Err, GinCtx.Request.URL.Path)
+			resp.Items = append(resp.Items, BulkItemResponse{Index: index, Status: problem.Status, Problem: &problem})
+			resp.Failed++
+			continue
+		}
+
+		task := item.Task
+		resp.Items = append(resp.Items, BulkItemResponse{Index: index, Status: Status, Task: &task})
+	}
+
+	if resp.Failed > 0 {
+		GinCtx.JSON(http.StatusMultiStatus, resp)
+		return
+	}
+
+	GinCtx.JSON(http.StatusOK, resp)
+}
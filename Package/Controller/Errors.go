@@ -0,0 +1,293 @@
+package Controller
+
+import (
+	"TaskManager/Helper/Route"
+	"TaskManager/Package/Model"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+const ProblemContentType string = "application/problem+json"
+
+// ProblemType documents one entry of the problem type registry served at
+// Route.ProblemsURL. Type is the URI carried by every Problem of that kind.
+type ProblemType struct {
+	Type        string `json:"type"`
+	Title       string `json:"title"`
+	Status      int    `json:"status"`
+	Description string `json:"description"`
+}
+
+// InvalidParam is the RFC 7807 "invalid-params" extension member.
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// Problem is an RFC 7807 problem details document.
+type Problem struct {
+	Type          string         `json:"type"`
+	Title         string         `json:"title"`
+	Status        int            `json:"status"`
+	Detail        string         `json:"detail,omitempty"`
+	Instance      string         `json:"instance,omitempty"`
+	InvalidParams []InvalidParam `json:"invalid-params,omitempty"`
+}
+
+func problemType(Slug string, Title string, Status int, Description string) ProblemType {
+	return ProblemType{
+		Type:        Route.ProblemsURL + "/" + Slug,
+		Title:       Title,
+		Status:      Status,
+		Description: Description,
+	}
+}
+
+var (
+	ProblemMalformedRequest = problemType("malformed-request", "Malformed Request", http.StatusBadRequest,
+		"The request body or query could not be parsed.")
+	ProblemValidation = problemType("validation-error", "Validation Failed", http.StatusUnprocessableEntity,
+		"The request was readable but one or more fields are invalid; see invalid-params.")
+	ProblemPayloadTooLarge = problemType("payload-too-large", "Payload Too Large", http.StatusRequestEntityTooLarge,
+		"The request body is larger than the server reads for this request.")
+	ProblemUnsupportedMediaType = problemType("unsupported-media-type", "Unsupported Media Type", http.StatusUnsupportedMediaType,
+		"The request body is in a format this route does not accept; see the Accept-Patch header.")
+	ProblemNotFound = problemType("not-found", "Resource Not Found", http.StatusNotFound,
+		"The requested resource does not exist or has been deleted.")
+	ProblemConflict = problemType("conflict", "Conflict", http.StatusConflict,
+		"The request conflicts with the current state of the resource. Retrying may succeed.")
+	ProblemInvalidTransition = problemType("invalid-transition", "Invalid Transition", http.StatusConflict,
+		"The workflow does not allow moving the task from its current status to the requested one; see the workflow.")
+	ProblemBulkAborted = problemType("bulk-aborted", "Bulk Item Not Applied", http.StatusConflict,
+		"The item was valid but another item of the all-or-nothing bulk request failed, so nothing was written.")
+	ProblemIdempotencyKeyReused = problemType("idempotency-key-reused", "Idempotency Key Reused", http.StatusUnprocessableEntity,
+		"The Idempotency-Key was already used for a request with another method, path, If-Match or body. Use a new key for a new request.")
+	ProblemIdempotencyKeyInUse = problemType("idempotency-key-in-use", "Idempotency Key In Use", http.StatusConflict,
+		"The first request with this Idempotency-Key is still being processed. Retry after the delay in Retry-After to get its response.")
+	ProblemPreconditionFailed = problemType("precondition-failed", "Precondition Failed", http.StatusPreconditionFailed,
+		"The If-Match header does not name the current version of the resource. Read it again and retry with the new ETag.")
+	ProblemUnavailable = problemType("unavailable", "Service Unavailable", http.StatusServiceUnavailable,
+		"The storage backend is unreachable or overloaded. Retry after the delay in Retry-After.")
+	ProblemInternal = problemType("internal-error", "Internal Server Error", http.StatusInternalServerError,
+		"An unexpected error occurred on the server.")
+)
+
+// ProblemTypes is the registry of every problem type this API can return.
+var ProblemTypes = []ProblemType{
+	ProblemMalformedRequest,
+	ProblemValidation,
+	ProblemPayloadTooLarge,
+	ProblemUnsupportedMediaType,
+	ProblemNotFound,
+	ProblemConflict,
+	ProblemInvalidTransition,
+	ProblemBulkAborted,
+	ProblemIdempotencyKeyReused,
+	ProblemIdempotencyKeyInUse,
+	ProblemPreconditionFailed,
+	ProblemUnavailable,
+	ProblemInternal,
+}
+
+// RequestError is a request the server could not read at all, such as a
+// malformed JSON body.
+type RequestError struct {
+	Err error
+}
+
+func (Err *RequestError) Error() string {
+	return "Malformed Request: " + Err.Err.Error()
+}
+
+func (Err *RequestError) Unwrap() error {
+	return Err.Err
+}
+
+// MediaTypeError is a request body whose Content-Type the route cannot read.
+type MediaTypeError struct {
+	ContentType string
+	Supported   []string
+}
+
+func (Err *MediaTypeError) Error() string {
+	return fmt.Sprintf("Unsupported Media Type: %q, expected one of [%s]", Err.ContentType, strings.Join(Err.Supported, " "))
+}
+
+// ProblemTypeForError maps the Model error taxonomy onto the problem registry.
+func ProblemTypeForError(Err error) ProblemType {
+	var tooLargeErr *http.MaxBytesError
+	var requestErr *RequestError
+	var mediaTypeErr *MediaTypeError
+	var preconditionErr *PreconditionError
+	var versionErr *Model.VersionConflictError
+	var validationErr *Model.ValidationError
+	var notFoundErr *Model.NotFoundError
+	var conflictErr *Model.ConflictError
+	var transitionErr *Model.TransitionError
+	var bulkAbortedErr *Model.BulkAbortedError
+	var keyReusedErr *Model.IdempotencyKeyReusedError
+	var keyInUseErr *Model.IdempotencyKeyInUseError
+	var unavailableErr *Model.UnavailableError
+
+	switch {
+	case errors.As(Err, &tooLargeErr):
+		return ProblemPayloadTooLarge
+	case errors.As(Err, &requestErr):
+		return ProblemMalformedRequest
+	case errors.As(Err, &mediaTypeErr):
+		return ProblemUnsupportedMediaType
+	case errors.As(Err, &validationErr):
+		return ProblemValidation
+	case errors.As(Err, &notFoundErr):
+		return ProblemNotFound
+	case errors.As(Err, &preconditionErr), errors.As(Err, &versionErr):
+		return ProblemPreconditionFailed
+	case errors.As(Err, &transitionErr):
+		return ProblemInvalidTransition
+	case errors.As(Err, &bulkAbortedErr):
+		return ProblemBulkAborted
+	case errors.As(Err, &keyReusedErr):
+		return ProblemIdempotencyKeyReused
+	case errors.As(Err, &keyInUseErr):
+		return ProblemIdempotencyKeyInUse
+	case errors.As(Err, &conflictErr):
+		return ProblemConflict
+	case errors.As(Err, &unavailableErr),
+		errors.Is(Err, context.DeadlineExceeded),
+		errors.Is(Err, context.Canceled):
+		return ProblemUnavailable
+	}
+
+	return ProblemInternal
+}
+
+// StatusForError is the HTTP status of the problem type Err maps to.
+func StatusForError(Err error) int {
+	return ProblemTypeForError(Err).Status
+}
+
+// NewProblem builds the problem document for Err raised while serving Instance.
+func NewProblem(Err error, Instance string) Problem {
+	kind := ProblemTypeForError(Err)
+
+	problem := Problem{
+		Type:     kind.Type,
+		Title:    kind.Title,
+		Status:   kind.Status,
+		Detail:   Err.Error(),
+		Instance: Instance,
+	}
+
+	if kind.Status == http.StatusInternalServerError {
+		problem.Detail = kind.Description
+	}
+
+	var validationErr *Model.ValidationError
+	if errors.As(Err, &validationErr) {
+		for _, violation := range validationErr.Violations {
+			problem.InvalidParams = append(problem.InvalidParams, InvalidParam{
+				Name:   violation.Field,
+				Reason: violation.Message,
+			})
+		}
+	}
+
+	return problem
+}
+
+// RespondError renders Err as application/problem+json and aborts the request.
+func RespondError(GinCtx *gin.Context, Err error) {
+	problem := NewProblem(Err, GinCtx.Request.URL.Path)
+
+	if problem.Status == http.StatusInternalServerError {
+		GinCtx.Error(Err)
+	}
+
+	if problem.Status == http.StatusServiceUnavailable {
+		GinCtx.Header("Retry-After", "1")
+	}
+
+	GinCtx.Header("Content-Type", ProblemContentType)
+	GinCtx.AbortWithStatusJSON(problem.Status, problem)
+}
+
+// bindingError converts binding failures into a Model.ValidationError when
+// the body was readable but broke a binding rule, and a RequestError otherwise.
+func bindingError(Err error) error {
+	var fieldErrors validator.ValidationErrors
+	if errors.As(Err, &fieldErrors) {
+		violations := &Model.ValidationError{}
+		for _, fieldErr := range fieldErrors {
+			violations.Add(fieldErr.Field(), fieldMessage(fieldErr))
+		}
+		return violations
+	}
+
+	return &RequestError{Err: Err}
+}
+
+func fieldMessage(FieldErr validator.FieldError) string {
+	switch FieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "oneof":
+		return fmt.Sprintf("must be one of [%s]", FieldErr.Param())
+	case "min":
+		return fmt.Sprintf("must be at least %s", FieldErr.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s", FieldErr.Param())
+	}
+	return fmt.Sprintf("failed the %q rule", FieldErr.Tag())
+}
+
+// ListProblems serves the problem type registry.
+func (Ctr *ControllerStruct) ListProblems(GinCtx *gin.Context) {
+	GinCtx.JSON(http.StatusOK, ProblemTypes)
+}
+
+// GetProblem serves the documentation of a single problem type, which makes
+// every Problem.Type URI dereferenceable.
+func (Ctr *ControllerStruct) GetProblem(GinCtx *gin.Context) {
+	var path ProblemPathStruct
+
+	err := GinCtx.ShouldBindUri(&path)
+	if err != nil {
+		RespondError(GinCtx, bindingError(err))
+		return
+	}
+
+	target := Route.ProblemsURL + "/" + path.Slug
+
+	for _, kind := range ProblemTypes {
+		if kind.Type == target {
+			GinCtx.JSON(http.StatusOK, kind)
+			return
+		}
+	}
+
+	RespondError(GinCtx, &Model.NotFoundError{Resource: "Problem Type"})
+}
+
+func (Ctr *ControllerStruct) NoRoute(GinCtx *gin.Context) {
+	RespondError(GinCtx, &Model.NotFoundError{Resource: "Route"})
+}
+
+func (Ctr *ControllerStruct) NoMethod(GinCtx *gin.Context) {
+	GinCtx.Header("Content-Type", ProblemContentType)
+	GinCtx.AbortWithStatusJSON(http.StatusMethodNotAllowed, Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(http.StatusMethodNotAllowed),
+		Status:   http.StatusMethodNotAllowed,
+		Instance: GinCtx.Request.URL.Path,
+	})
+}
+
+func (Ctr *ControllerStruct) Recovery(GinCtx *gin.Context, Recovered any) {
+	RespondError(GinCtx, fmt.Errorf("panic: %v", Recovered))
+}
@@ -0,0 +1,183 @@
+package Controller
+
+import (
+	"TaskManager/Package/Model"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+const IdempotencyKeyHeader string = "Idempotency-Key"
+
+// IdempotentReplayedHeader marks a response replayed from the store instead
+// of produced by running the request again.
+const IdempotentReplayedHeader string = "Idempotent-Replayed"
+
+// MaxIdempotentBodyBytes is the largest body a request with an
+// Idempotency-Key may carry, since the body is read whole to hash it.
+const MaxIdempotentBodyBytes int64 = 1 << 20
+
+var idempotencyKeyPattern = regexp.MustCompile(`^[\x21-\x7e]{1,255}$`)
+
+var idempotentMethods = map[string]bool{
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// Idempotency runs a mutating request that carries an Idempotency-Key once.
+// Its response is stored under the key and replayed to every retry with the
+// same actor, method, path, If-Match and body. A retry that differs is
+// rejected, as is one that arrives while the first request is still
+// running, so one actor never gets the response stored for another. Server
+// errors are not stored, so retrying them runs the request again.
+func Idempotency(Store Model.ModelInterface) gin.HandlerFunc {
+	return func(GinCtx *gin.Context) {
+		key := GinCtx.GetHeader(IdempotencyKeyHeader)
+		if len(key) <= 0 || !idempotentMethods[GinCtx.Request.Method] {
+			GinCtx.Next()
+			return
+		}
+
+		if !idempotencyKeyPattern.MatchString(key) {
+			violations := &Model.ValidationError{}
+			violations.Add(IdempotencyKeyHeader, "must be 1 to 255 visible ASCII characters")
+			RespondError(GinCtx, violations)
+			return
+		}
+
+		hash, err := requestHash(GinCtx)
+		if err != nil {
+			RespondError(GinCtx, &RequestError{Err: err})
+			return
+		}
+
+		reservation := Model.IdempotencyKeyStore{Key: key, Request_Hash: hash}
+
+		record, err := Store.ReserveIdempotencyKey(GinCtx.Request.Context(), reservation)
+
+		var inUseErr *Model.IdempotencyKeyInUseError
+		if errors.As(err, &inUseErr) {
+			GinCtx.Header("Retry-After", "1")
+		}
+
+		if err != nil {
+			RespondError(GinCtx, err)
+			return
+		}
+
+		if !record.Reserved {
+			replay(GinCtx, record)
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: GinCtx.Writer}
+		GinCtx.Writer = writer
+
+		// The response is already written, storing it must not depend on the
+		// client still listening.
+		ctx := context.WithoutCancel(GinCtx.Request.Context())
+
+		// A handler that panics never gets to the code after Next, the
+		// recovery middleware answers for it. Release the key on the way out
+		// so that a retry runs the request again instead of waiting out
+		// Model.IdempotencyLockTimeout.
+		handled := false
+		defer func() {
+			if !handled {
+				if err := Store.ReleaseIdempotencyKey(ctx, reservation); err != nil {
+					GinCtx.Error(err)
+				}
+			}
+		}()
+
+		GinCtx.Next()
+		handled = true
+
+		if writer.Status() >= http.StatusInternalServerError {
+			err = Store.ReleaseIdempotencyKey(ctx, reservation)
+		} else {
+			headers := writer.Header().Clone()
+			headers.Del(RequestIDHeader)
+
+			err = Store.CompleteIdempotencyKey(ctx, Model.IdempotencyRecord{
+				Key:          key,
+				Request_Hash: hash,
+				Status:       writer.Status(),
+				Headers:      headers,
+				Body:         writer.body.Bytes(),
+			})
+		}
+
+		if err != nil {
+			GinCtx.Error(err)
+		}
+	}
+}
+
+// requestHash digests what makes two requests with the same key the same
+// request, and leaves the body readable for the handler. Bodies larger than
+// MaxIdempotentBodyBytes fail with an *http.MaxBytesError.
+func requestHash(GinCtx *gin.Context) (string, error) {
+	body := []byte{}
+
+	if GinCtx.Request.Body != nil {
+		var err error
+		body, err = io.ReadAll(http.MaxBytesReader(GinCtx.Writer, GinCtx.Request.Body, MaxIdempotentBodyBytes))
+		if err != nil {
+			return "", err
+		}
+		GinCtx.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	digest := sha256.New()
+	for _, part := range []string{
+		Model.AuditorFrom(GinCtx.Request.Context()).Actor,
+		GinCtx.Request.Method,
+		GinCtx.Request.URL.RequestURI(),
+		GinCtx.GetHeader("Content-Type"),
+		GinCtx.GetHeader("If-Match"),
+	} {
+		digest.Write([]byte(part))
+		digest.Write([]byte{0})
+	}
+	digest.Write(body)
+
+	return hex.EncodeToString(digest.Sum(nil)), nil
+}
+
+// replay writes the response stored in Record and aborts the request.
+func replay(GinCtx *gin.Context, Record Model.IdempotencyRecord) {
+	for name, values := range Record.Headers {
+		GinCtx.Writer.Header()[name] = values
+	}
+	GinCtx.Header(IdempotentReplayedHeader, "true")
+
+	GinCtx.Status(Record.Status)
+	_, _ = GinCtx.Writer.Write(Record.Body)
+	GinCtx.Abort()
+}
+
+// recordingWriter keeps a copy of the body it writes.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (Writer *recordingWriter) Write(Data []byte) (int, error) {
+	Writer.body.Write(Data)
+	return Writer.ResponseWriter.Write(Data)
+}
+
+func (Writer *recordingWriter) WriteString(Data string) (int, error) {
+	Writer.body.WriteString(Data)
+	return Writer.ResponseWriter.WriteString(Data)
+}
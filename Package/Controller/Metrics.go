@@ -0,0 +1,30 @@
+package Controller
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PublishedMetrics are the expvar variables Route.MetricsURL serves. The
+// ones expvar publishes on its own are left out: cmdline holds the command
+// line, database credentials included, and memstats says nothing about the
+// tasks.
+var PublishedMetrics = []string{"transactions", "database"}
+
+// Metrics writes PublishedMetrics the way expvar.Handler writes every
+// variable. A variable nothing published yet is null.
+func Metrics(GinCtx *gin.Context) {
+	metrics := map[string]json.RawMessage{}
+
+	for _, name := range PublishedMetrics {
+		metrics[name] = json.RawMessage("null")
+		if variable := expvar.Get(name); variable != nil {
+			metrics[name] = json.RawMessage(variable.String())
+		}
+	}
+
+	GinCtx.JSON(http.StatusOK, metrics)
+}
@@ -0,0 +1,536 @@
+package Controller
+
+import (
+	"TaskManager/Helper/Route"
+	"TaskManager/Package/Model"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// PublishedOpenAPI is the committed contract served at Route.OpenAPIURL.
+// Regenerate it with `go test ./Package/Controller -run OpenAPI -update`.
+//
+//go:embed openapi.json
+var PublishedOpenAPI []byte
+
+// OperationSpec describes a route for the OpenAPI document. Body, Path and
+// Query and Header hold zero values of the structs the handler binds, Responses the
+// structs it renders per status code (nil for an empty body). Bodies adds
+// request bodies for media types other than application/json.
+type OperationSpec struct {
+	OperationID string
+	Summary     string
+	Tag         string
+	Body        any
+	Bodies      map[string]any
+	Path        any
+	Query       any
+	Header      any
+	Responses   map[int]any
+	Problems    []int
+	Headers     map[int][]string
+}
+
+type ProblemPathStruct struct {
+	Slug string `uri:"slug" binding:"required"`
+}
+
+var problemStatuses = []int{http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusServiceUnavailable, http.StatusInternalServerError}
+var problemStatusesByID = append([]int{http.StatusNotFound, http.StatusConflict}, problemStatuses...)
+var problemStatusesByVersion = append([]int{http.StatusPreconditionFailed}, problemStatusesByID...)
+
+// Operations documents every route registered by NewController, keyed by
+// "METHOD /path" as gin reports it.
+var Operations = map[string]OperationSpec{
+	"POST " + Route.PostURL: {
+		OperationID: "AddTask", Summary: "Create a task", Tag: "legacy",
+		Body: AddTaskStruct{}, Responses: map[int]any{http.StatusOK: Model.TaskStoreResponse{}}, Problems: problemStatuses,
+	},
+	"GET " + Route.GetURL: {
+		OperationID: "GetTask", Summary: "Read a task", Tag: "legacy",
+		Body: GetTaskStruct{}, Header: IfNoneMatchHeaderStruct{}, Responses: map[int]any{http.StatusOK: Model.TaskStoreResponse{}, http.StatusNotModified: nil},
+		Problems: problemStatusesByID, Headers: map[int][]string{http.StatusOK: {"ETag"}, http.StatusNotModified: {"ETag"}},
+	},
+	"PUT " + Route.EditURL: {
+		OperationID: "EditTask", Summary: "Replace a task", Tag: "legacy",
+		Body: UpdateTaskStruct{}, Header: IfMatchHeaderStruct{}, Responses: map[int]any{http.StatusOK: Model.TaskStoreResponse{}},
+		Problems: problemStatusesByVersion, Headers: map[int][]string{http.StatusOK: {"ETag"}},
+	},
+	"DELETE " + Route.DeleteURL: {
+		OperationID: "DeleteTask", Summary: "Soft delete a task", Tag: "legacy",
+		Body: DeleteTaskStruct{}, Header: IfMatchHeaderStruct{}, Responses: map[int]any{http.StatusOK: Model.DeleteTaskStoreResponse{}}, Problems: problemStatusesByVersion,
+	},
+	"GET " + Route.ListPaginationURL: {
+		OperationID: "ListTask", Summary: "List a page of tasks", Tag: "legacy",
+		Body: ListTaskStruct{}, Responses: map[int]any{http.StatusOK: []Model.TaskStoreResponse{}}, Problems: problemStatuses,
+	},
+	"GET " + Route.TasksURL: {
+		OperationID: "ListTasks", Summary: "List a page of tasks", Tag: "tasks",
+		Query: ListTaskQueryStruct{}, Responses: map[int]any{http.StatusOK: TaskListResponse{}}, Problems: problemStatuses,
+	},
+	"GET " + Route.TaskSearchURL: {
+		OperationID: "SearchTasks", Summary: "Search tasks by relevance", Tag: "tasks",
+		Query: SearchTaskQueryStruct{}, Responses: map[int]any{http.StatusOK: TaskSearchResponse{}}, Problems: problemStatuses,
+	},
+	"POST " + Route.TasksURL: {
+		OperationID: "CreateTask", Summary: "Create a task", Tag: "tasks",
+		Body: AddTaskStruct{}, Responses: map[int]any{http.StatusCreated: Model.TaskStoreResponse{}}, Problems: problemStatuses,
+		Headers: map[int][]string{http.StatusCreated: {"Location", "ETag"}},
+	},
+	"GET " + Route.TaskURL: {
+		OperationID: "ReadTask", Summary: "Read a task, now or as of a revision or time", Tag: "tasks",
+		Path: TaskPathStruct{}, Query: ReadTaskQueryStruct{}, Header: IfNoneMatchHeaderStruct{}, Responses: map[int]any{http.StatusOK: Model.TaskStoreResponse{}, http.StatusNotModified: nil},
+		Problems: problemStatusesByID, Headers: map[int][]string{http.StatusOK: {"ETag"}, http.StatusNotModified: {"ETag"}},
+	},
+	"PUT " + Route.TaskURL: {
+		OperationID: "ReplaceTask", Summary: "Replace a task", Tag: "tasks",
+		Path: TaskPathStruct{}, Body: AddTaskStruct{}, Header: IfMatchHeaderStruct{}, Responses: map[int]any{http.StatusOK: Model.TaskStoreResponse{}},
+		Problems: problemStatusesByVersion, Headers: map[int][]string{http.StatusOK: {"ETag"}},
+	},
+	"PATCH " + Route.TaskURL: {
+		OperationID: "PatchTask", Summary: "Update some fields of a task", Tag: "tasks",
+		Path: TaskPathStruct{}, Body: PatchTaskStruct{}, Header: IfMatchHeaderStruct{}, Responses: map[int]any{http.StatusOK: Model.TaskStoreResponse{}},
+		Bodies:   map[string]any{MergePatchContentType: map[string]any{}, JSONPatchContentType: Model.JSONPatch{}},
+		Problems: append([]int{http.StatusUnsupportedMediaType}, problemStatusesByVersion...),
+		Headers:  map[int][]string{http.StatusOK: {"Accept-Patch", "ETag"}},
+	},
+	"DELETE " + Route.TaskURL: {
+		OperationID: "RemoveTask", Summary: "Soft delete a task", Tag: "tasks",
+		Path: TaskPathStruct{}, Header: IfMatchHeaderStruct{}, Responses: map[int]any{http.StatusNoContent: nil}, Problems: problemStatusesByVersion,
+	},
+	"POST " + Route.TasksBulkURL: {
+		OperationID: "BulkCreateTasks", Summary: "Create many tasks", Tag: "bulk",
+		Body: BulkCreateStruct{}, Responses: map[int]any{http.StatusOK: BulkResponse{}, http.StatusMultiStatus: BulkResponse{}}, Problems: problemStatuses,
+	},
+	"PUT " + Route.TasksBulkURL: {
+		OperationID: "BulkUpdateTasks", Summary: "Replace many tasks", Tag: "bulk",
+		Body: BulkUpdateStruct{}, Responses: map[int]any{http.StatusOK: BulkResponse{}, http.StatusMultiStatus: BulkResponse{}}, Problems: problemStatuses,
+	},
+	"DELETE " + Route.TasksBulkURL: {
+		OperationID: "BulkDeleteTasks", Summary: "Delete many tasks", Tag: "bulk",
+		Body: BulkDeleteStruct{}, Responses: map[int]any{http.StatusOK: BulkResponse{}, http.StatusMultiStatus: BulkResponse{}}, Problems: problemStatuses,
+	},
+	"POST " + Route.TaskTransitionsURL: {
+		OperationID: "TransitionTask", Summary: "Move a task to another workflow status", Tag: "tasks",
+		Path: TaskPathStruct{}, Body: TransitionTaskStruct{}, Header: IfMatchHeaderStruct{}, Responses: map[int]any{http.StatusOK: Model.TaskStoreResponse{}},
+		Problems: problemStatusesByVersion, Headers: map[int][]string{http.StatusOK: {"ETag"}},
+	},
+	"POST " + Route.TaskRevertURL: {
+		OperationID: "RevertTask", Summary: "Restore an earlier revision of a task", Tag: "tasks",
+		Path: TaskPathStruct{}, Body: RevertTaskStruct{}, Header: IfMatchHeaderStruct{}, Responses: map[int]any{http.StatusOK: Model.TaskStoreResponse{}},
+		Problems: problemStatusesByVersion, Headers: map[int][]string{http.StatusOK: {"ETag"}},
+	},
+	"GET " + Route.TaskHistoryURL: {
+		OperationID: "ReadTaskHistory", Summary: "List the changes made to a task", Tag: "tasks",
+		Path: TaskPathStruct{}, Query: TaskHistoryQueryStruct{}, Responses: map[int]any{http.StatusOK: TaskHistoryResponse{}},
+		Problems: problemStatusesByID,
+	},
+	"GET " + Route.WorkflowURL: {
+		OperationID: "GetWorkflow", Summary: "Describe the task workflow", Tag: "tasks",
+		Responses: map[int]any{http.StatusOK: WorkflowResponse{}},
+	},
+	"GET " + Route.TrashURL: {
+		OperationID: "ListTrash", Summary: "List a page of deleted tasks", Tag: "trash",
+		Query: TrashQueryStruct{}, Responses: map[int]any{http.StatusOK: TaskListResponse{}}, Problems: problemStatuses,
+	},
+	"GET " + Route.TrashTaskURL: {
+		OperationID: "ReadTrashedTask", Summary: "Read a deleted task", Tag: "trash",
+		Path: TaskPathStruct{}, Header: IfNoneMatchHeaderStruct{}, Responses: map[int]any{http.StatusOK: Model.TaskStoreResponse{}, http.StatusNotModified: nil},
+		Problems: problemStatusesByID, Headers: map[int][]string{http.StatusOK: {"ETag"}, http.StatusNotModified: {"ETag"}},
+	},
+	"DELETE " + Route.TrashTaskURL: {
+		OperationID: "PurgeTask", Summary: "Permanently delete a deleted task", Tag: "trash",
+		Path: TaskPathStruct{}, Header: IfMatchHeaderStruct{}, Responses: map[int]any{http.StatusNoContent: nil}, Problems: problemStatusesByVersion,
+	},
+	"POST " + Route.TrashRestoreURL: {
+		OperationID: "RestoreTask", Summary: "Restore a deleted task", Tag: "trash",
+		Path: TaskPathStruct{}, Header: IfMatchHeaderStruct{}, Responses: map[int]any{http.StatusOK: Model.TaskStoreResponse{}},
+		Problems: problemStatusesByVersion, Headers: map[int][]string{http.StatusOK: {"Location", "ETag"}},
+	},
+	"GET " + Route.ProblemsURL: {
+		OperationID: "ListProblems", Summary: "List the problem types this API returns", Tag: "problems",
+		Responses: map[int]any{http.StatusOK: []ProblemType{}},
+	},
+	"GET " + Route.ProblemsURL + "/:slug": {
+		OperationID: "GetProblem", Summary: "Describe one problem type", Tag: "problems",
+		Path: ProblemPathStruct{}, Responses: map[int]any{http.StatusOK: ProblemType{}}, Problems: []int{http.StatusNotFound},
+	},
+}
+
+// undocumentedRoutes serve the documentation itself and the expvar metrics.
+var undocumentedRoutes = map[string]bool{
+	"GET " + Route.OpenAPIURL:             true,
+	"GET " + Route.DocsURL + "/*filepath": true,
+	"GET " + Route.MetricsURL:             true,
+}
+
+var pathParamPattern = regexp.MustCompile(`:([A-Za-z_]+)`)
+
+// BuildOpenAPI derives the OpenAPI 3.1 document from the registered Routes
+// and the binding structs in Operations. A route without an OperationSpec is
+// an error so new handlers cannot ship undocumented.
+func BuildOpenAPI(Routes gin.RoutesInfo) (map[string]any, error) {
+	builder := schemaBuilder{components: map[string]any{}}
+	paths := map[string]any{}
+
+	builder.schemaFor(reflect.TypeOf(Problem{}))
+
+	for _, route := range Routes {
+		key := route.Method + " " + route.Path
+		if undocumentedRoutes[key] {
+			continue
+		}
+
+		spec, ok := Operations[key]
+		if !ok {
+			return nil, fmt.Errorf("route %s has no OperationSpec", key)
+		}
+
+		openAPIPath := pathParamPattern.ReplaceAllString(route.Path, "{$1}")
+		item, _ := paths[openAPIPath].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[openAPIPath] = item
+		}
+
+		item[strings.ToLower(route.Method)] = builder.operation(route.Method, spec, spec.Tag == "legacy")
+	}
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":   "TaskManager API",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": builder.components,
+		},
+	}, nil
+}
+
+type schemaBuilder struct {
+	components map[string]any
+}
+
+// operation documents Spec served with Method. Every mutating operation
+// also takes an Idempotency-Key, see Idempotency.
+func (Builder *schemaBuilder) operation(Method string, Spec OperationSpec, Deprecated bool) map[string]any {
+	operation := map[string]any{
+		"operationId": Spec.OperationID,
+		"summary":     Spec.Summary,
+		"tags":        []string{Spec.Tag},
+	}
+
+	if Deprecated {
+		operation["deprecated"] = true
+	}
+
+	parameters := []any{}
+	parameters = append(parameters, Builder.parameters(Spec.Path, "uri", "path")...)
+	parameters = append(parameters, Builder.parameters(Spec.Query, "form", "query")...)
+	parameters = append(parameters, Builder.parameters(Spec.Header, "header", "header")...)
+	if idempotentMethods[Method] {
+		parameters = append(parameters, map[string]any{
+			"name":     IdempotencyKeyHeader,
+			"in":       "header",
+			"required": false,
+			"schema":   map[string]any{"type": "string", "minLength": 1, "maxLength": Model.MaxIdempotencyKeyLength},
+		})
+	}
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+
+	if Spec.Body != nil {
+		content := map[string]any{
+			"application/json": map[string]any{
+				"schema": Builder.schemaFor(reflect.TypeOf(Spec.Body)),
+			},
+		}
+		for mediaType, body := range Spec.Bodies {
+			content[mediaType] = map[string]any{
+				"schema": Builder.schemaFor(reflect.TypeOf(body)),
+			}
+		}
+		operation["requestBody"] = map[string]any{
+			"required": true,
+			"content":  content,
+		}
+	}
+
+	responses := map[string]any{}
+
+	for status, body := range Spec.Responses {
+		response := map[string]any{"description": http.StatusText(status)}
+		if body != nil {
+			response["content"] = map[string]any{
+				"application/json": map[string]any{
+					"schema": Builder.schemaFor(reflect.TypeOf(body)),
+				},
+			}
+		}
+		names := Spec.Headers[status]
+		if idempotentMethods[Method] {
+			names = append(slices.Clone(names), IdempotentReplayedHeader)
+		}
+		if len(names) > 0 {
+			headers := map[string]any{}
+			for _, name := range names {
+				headers[name] = map[string]any{"schema": map[string]any{"type": "string"}}
+			}
+			response["headers"] = headers
+		}
+		responses[strconv.Itoa(status)] = response
+	}
+
+	problems := Spec.Problems
+	if idempotentMethods[Method] {
+		problems = append(slices.Clone(problems), ProblemIdempotencyKeyInUse.Status, ProblemIdempotencyKeyReused.Status)
+	}
+
+	for _, status := range problems {
+		responses[strconv.Itoa(status)] = map[string]any{
+			"description": http.StatusText(status),
+			"content": map[string]any{
+				ProblemContentType: map[string]any{
+					"schema": map[string]any{"$ref": "#/components/schemas/Problem"},
+				},
+			},
+		}
+	}
+
+	operation["responses"] = responses
+
+	return operation
+}
+
+func (Builder *schemaBuilder) parameters(Struct any, TagName string, In string) []any {
+	parameters := []any{}
+	if Struct == nil {
+		return parameters
+	}
+
+	structType := reflect.TypeOf(Struct)
+	for index := 0; index < structType.NumField(); index++ {
+		field := structType.Field(index)
+		name := field.Tag.Get(TagName)
+		if len(name) <= 0 {
+			continue
+		}
+
+		rules := field.Tag.Get("binding")
+		parameters = append(parameters, map[string]any{
+			"name":     name,
+			"in":       In,
+			"required": In == "path" || hasRule(rules, "required"),
+			"schema":   Builder.fieldSchema(field.Type, rules),
+		})
+	}
+
+	return parameters
+}
+
+// schemaFor returns a JSON schema for Type, registering named structs as
+// components and referencing them.
+func (Builder *schemaBuilder) schemaFor(Type reflect.Type) map[string]any {
+	return Builder.fieldSchema(Type, "")
+}
+
+func (Builder *schemaBuilder) fieldSchema(Type reflect.Type, Rules string) map[string]any {
+	for Type.Kind() == reflect.Pointer {
+		Type = Type.Elem()
+	}
+
+	if Type == reflect.TypeOf(time.Time{}) {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	if Type == reflect.TypeOf(json.RawMessage{}) {
+		return map[string]any{}
+	}
+
+	schema := map[string]any{}
+
+	switch Type.Kind() {
+	case reflect.String:
+		schema["type"] = "string"
+		if value, ok := ruleValue(Rules, "min"); ok {
+			schema["minLength"] = value
+		}
+		if value, ok := ruleValue(Rules, "max"); ok {
+			schema["maxLength"] = value
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		schema["type"] = "integer"
+		if Type.Kind() == reflect.Int64 {
+			schema["format"] = "int64"
+		}
+		if value, ok := ruleValue(Rules, "min"); ok {
+			schema["minimum"] = value
+		}
+		if value, ok := ruleValue(Rules, "max"); ok {
+			schema["maximum"] = value
+		}
+	case reflect.Float32, reflect.Float64:
+		schema["type"] = "number"
+	case reflect.Bool:
+		schema["type"] = "boolean"
+	case reflect.Slice, reflect.Array:
+		schema["type"] = "array"
+		schema["items"] = Builder.fieldSchema(Type.Elem(), "")
+	case reflect.Map, reflect.Interface:
+		schema["type"] = "object"
+	case reflect.Struct:
+		Builder.component(Type)
+		return map[string]any{"$ref": "#/components/schemas/" + Type.Name()}
+	}
+
+	if options := ruleArg(Rules, "oneof"); len(options) > 0 {
+		schema["enum"] = strings.Fields(options)
+	}
+
+	return schema
+}
+
+func (Builder *schemaBuilder) component(Type reflect.Type) {
+	if _, ok := Builder.components[Type.Name()]; ok {
+		return
+	}
+
+	// Structs with binding rules are requests, only "required" fields are
+	// mandatory there. Every other struct is a response which always renders
+	// its fields unless they are omitempty.
+	isRequest := false
+	for index := 0; index < Type.NumField(); index++ {
+		if len(Type.Field(index).Tag.Get("binding")) > 0 {
+			isRequest = true
+		}
+	}
+
+	properties := map[string]any{}
+	required := []string{}
+	schema := map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+	}
+	Builder.components[Type.Name()] = schema
+
+	for index := 0; index < Type.NumField(); index++ {
+		field := Type.Field(index)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, omitEmpty := jsonName(field)
+		if name == "-" {
+			continue
+		}
+
+		rules := field.Tag.Get("binding")
+		properties[name] = Builder.fieldSchema(field.Type, rules)
+
+		if hasRule(rules, "required") || (!isRequest && !omitEmpty) {
+			required = append(required, name)
+		}
+	}
+
+	sort.Strings(required)
+	schema["properties"] = properties
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+}
+
+func jsonName(Field reflect.StructField) (string, bool) {
+	tag := Field.Tag.Get("json")
+	if len(tag) <= 0 {
+		return Field.Name, false
+	}
+
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	if len(name) <= 0 {
+		name = Field.Name
+	}
+
+	return name, strings.Contains(tag, ",omitempty")
+}
+
+func hasRule(Rules string, Name string) bool {
+	for _, rule := range strings.Split(Rules, ",") {
+		if rule == Name {
+			return true
+		}
+	}
+	return false
+}
+
+func ruleArg(Rules string, Name string) string {
+	for _, rule := range strings.Split(Rules, ",") {
+		if strings.HasPrefix(rule, Name+"=") {
+			return strings.TrimPrefix(rule, Name+"=")
+		}
+	}
+	return ""
+}
+
+func ruleValue(Rules string, Name string) (int64, bool) {
+	value, err := strconv.ParseInt(ruleArg(Rules, Name), 10, 64)
+	return value, err == nil
+}
+
+func (Ctr *ControllerStruct) OpenAPI(GinCtx *gin.Context) {
+	GinCtx.Data(http.StatusOK, "application/json", PublishedOpenAPI)
+}
+
+const swaggerInitializer string = `window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: "%s",
+    dom_id: "#swagger-ui",
+    deepLinking: true,
+    presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+    layout: "StandaloneLayout"
+  });
+};
+`
+
+// Docs serves the embedded Swagger UI pointed at Route.OpenAPIURL.
+func (Ctr *ControllerStruct) Docs(GinCtx *gin.Context) {
+	file := GinCtx.Param("filepath")
+
+	switch file {
+	case "", "/":
+		index, err := fs.ReadFile(swaggerFiles.FS, "index.html")
+		if err != nil {
+			RespondError(GinCtx, err)
+			return
+		}
+		GinCtx.Data(http.StatusOK, "text/html; charset=utf-8", index)
+		return
+	case "/swagger-initializer.js":
+		GinCtx.Data(http.StatusOK, "application/javascript", []byte(fmt.Sprintf(swaggerInitializer, Route.OpenAPIURL)))
+		return
+	}
+
+	if _, err := fs.Stat(swaggerFiles.FS, strings.TrimPrefix(file, "/")); err != nil {
+		Ctr.NoRoute(GinCtx)
+		return
+	}
+
+	GinCtx.FileFromFS(file, http.FS(swaggerFiles.FS))
+}
@@ -0,0 +1,165 @@
+package Controller
+
+import (
+	"TaskManager/Helper/Route"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var update = flag.Bool("update", false, "rewrite openapi.json from the registered routes")
+
+// TestOpenAPIMatchesPublished fails when a route, request struct or response
+// struct changed without regenerating the published openapi.json.
+func (Suite *ControllerSuiteStruct) TestOpenAPIMatchesPublished() {
+	spec, err := BuildOpenAPI(Suite.Controller.router.Routes())
+	Suite.Require().NoError(err)
+
+	generated, err := json.MarshalIndent(spec, "", "  ")
+	Suite.Require().NoError(err)
+	generated = append(generated, '\n')
+
+	if *update {
+		Suite.Require().NoError(os.WriteFile("openapi.json", generated, 0o644))
+		return
+	}
+
+	Suite.JSONEq(string(PublishedOpenAPI), string(generated),
+		"openapi.json is stale, run `make OpenAPI`")
+}
+
+func (Suite *ControllerSuiteStruct) TestOpenAPIIsServed() {
+	rec := Suite.Do(http.MethodGet, Route.OpenAPIURL, nil)
+	Suite.Require().Equal(http.StatusOK, rec.Code)
+	Suite.Equal(PublishedOpenAPI, rec.Body.Bytes())
+
+	rec = Suite.Do(http.MethodGet, Route.DocsURL+"/", nil)
+	Suite.Require().Equal(http.StatusOK, rec.Code)
+	Suite.Contains(rec.Body.String(), "swagger-ui")
+
+	rec = Suite.Do(http.MethodGet, Route.DocsURL+"/swagger-initializer.js", nil)
+	Suite.Require().Equal(http.StatusOK, rec.Code)
+	Suite.Contains(rec.Body.String(), Route.OpenAPIURL)
+}
+
+// TestResponsesMatchOpenAPI fails when a handler renders a shape that the
+// published document does not describe.
+func (Suite *ControllerSuiteStruct) TestResponsesMatchOpenAPI() {
+	var spec map[string]any
+	Suite.Require().NoError(json.Unmarshal(PublishedOpenAPI, &spec))
+
+	created := Suite.CreateTask("Spec")
+
+	Suite.MatchesSpec(spec, http.MethodGet, Route.TaskURL, Suite.Do(http.MethodGet, TaskLocation(created.ID), nil))
+	Suite.MatchesSpec(spec, http.MethodGet, Route.TasksURL, Suite.Do(http.MethodGet, Route.TasksURL, nil))
+	Suite.MatchesSpec(spec, http.MethodGet, Route.TaskSearchURL, Suite.Do(http.MethodGet, Route.TaskSearchURL+"?q=task", nil))
+	Suite.MatchesSpec(spec, http.MethodPost, Route.TasksURL, Suite.Do(http.MethodPost, Route.TasksURL, map[string]string{}))
+	Suite.MatchesSpec(spec, http.MethodGet, Route.TaskURL, Suite.Do(http.MethodGet, TaskLocation(created.ID+100), nil))
+	Suite.MatchesSpec(spec, http.MethodGet, Route.ListPaginationURL, Suite.Do(http.MethodGet, Route.ListPaginationURL, ListTaskStruct{Limit: 5, Page: 1}))
+	Suite.MatchesSpec(spec, http.MethodDelete, Route.DeleteURL, Suite.Do(http.MethodDelete, Route.DeleteURL, DeleteTaskStruct{ID: created.ID}))
+	Suite.MatchesSpec(spec, http.MethodGet, Route.ProblemsURL, Suite.Do(http.MethodGet, Route.ProblemsURL, nil))
+}
+
+func (Suite *ControllerSuiteStruct) MatchesSpec(Spec map[string]any, Method string, GinPath string, Rec *httptest.ResponseRecorder) {
+	openAPIPath := pathParamPattern.ReplaceAllString(GinPath, "{$1}")
+	operation := lookup(Spec, "paths", openAPIPath, strings.ToLower(Method))
+	Suite.Require().NotNil(operation, "%s %s is not documented", Method, openAPIPath)
+
+	response := lookup(operation, "responses", strconv.Itoa(Rec.Code))
+	Suite.Require().NotNil(response, "%s %s does not document status %d", Method, openAPIPath, Rec.Code)
+
+	mediaType, _, _ := mime.ParseMediaType(Rec.Header().Get("Content-Type"))
+	schema := lookup(response, "content", mediaType, "schema")
+	Suite.Require().NotNil(schema, "%s %s %d does not document %s", Method, openAPIPath, Rec.Code, mediaType)
+
+	var body any
+	Suite.Require().NoError(json.Unmarshal(Rec.Body.Bytes(), &body))
+
+	for _, problem := range validateSchema(Spec, schema.(map[string]any), body, "$") {
+		Suite.Fail(problem, "%s %s %d", Method, openAPIPath, Rec.Code)
+	}
+}
+
+func lookup(Node any, Keys ...string) any {
+	for _, key := range Keys {
+		object, ok := Node.(map[string]any)
+		if !ok {
+			return nil
+		}
+		Node = object[key]
+	}
+	return Node
+}
+
+// validateSchema checks Value against the subset of JSON schema BuildOpenAPI emits.
+func validateSchema(Spec map[string]any, Schema map[string]any, Value any, Where string) []string {
+	if ref, ok := Schema["$ref"].(string); ok {
+		resolved := lookup(Spec, strings.Split(strings.TrimPrefix(ref, "#/"), "/")...)
+		return validateSchema(Spec, resolved.(map[string]any), Value, Where)
+	}
+
+	problems := []string{}
+
+	switch Schema["type"] {
+	case "object":
+		object, ok := Value.(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected object, got %T", Where, Value)}
+		}
+		properties, _ := Schema["properties"].(map[string]any)
+		for _, name := range anyStrings(Schema["required"]) {
+			if _, ok := object[name]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: missing required property %q", Where, name))
+			}
+		}
+		for name, value := range object {
+			property, ok := properties[name].(map[string]any)
+			if !ok {
+				if Schema["additionalProperties"] == false {
+					problems = append(problems, fmt.Sprintf("%s: undocumented property %q", Where, name))
+				}
+				continue
+			}
+			problems = append(problems, validateSchema(Spec, property, value, Where+"."+name)...)
+		}
+	case "array":
+		items, ok := Value.([]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected array, got %T", Where, Value)}
+		}
+		for index, item := range items {
+			problems = append(problems, validateSchema(Spec, Schema["items"].(map[string]any), item, fmt.Sprintf("%s[%d]", Where, index))...)
+		}
+	case "string":
+		if _, ok := Value.(string); !ok {
+			problems = append(problems, fmt.Sprintf("%s: expected string, got %T", Where, Value))
+		}
+	case "integer", "number":
+		if _, ok := Value.(float64); !ok {
+			problems = append(problems, fmt.Sprintf("%s: expected number, got %T", Where, Value))
+		}
+	case "boolean":
+		if _, ok := Value.(bool); !ok {
+			problems = append(problems, fmt.Sprintf("%s: expected boolean, got %T", Where, Value))
+		}
+	}
+
+	return problems
+}
+
+func anyStrings(Value any) []string {
+	values := []string{}
+	list, _ := Value.([]any)
+	for _, item := range list {
+		if text, ok := item.(string); ok {
+			values = append(values, text)
+		}
+	}
+	return values
+}
@@ -0,0 +1,146 @@
+package Controller
+
+import (
+	"TaskManager/Package/Model"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type IfMatchHeaderStruct struct {
+	If_Match string `header:"If-Match"`
+}
+
+type IfNoneMatchHeaderStruct struct {
+	If_None_Match string `header:"If-None-Match"`
+}
+
+// PreconditionError is an If-Match header that names no current version of
+// the resource.
+type PreconditionError struct {
+	IfMatch string
+}
+
+func (Err *PreconditionError) Error() string {
+	return fmt.Sprintf("Precondition Failed: If-Match %s does not match the current version", Err.IfMatch)
+}
+
+// TaskETag is the strong entity tag of a task at Version.
+func TaskETag(Version int64) string {
+	return strconv.Quote(strconv.FormatInt(Version, 10))
+}
+
+func setETag(GinCtx *gin.Context, Task Model.TaskStoreResponse) {
+	GinCtx.Header("ETag", TaskETag(Task.Version))
+}
+
+// entityTags splits an If-Match or If-None-Match value into its entity
+// tags, with weak tags keeping their W/ prefix.
+func entityTags(Header string) []string {
+	tags := []string{}
+	for _, tag := range strings.Split(Header, ",") {
+		tag = strings.TrimSpace(tag)
+		if len(tag) > 0 {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// ifMatchVersion turns the If-Match header into the version the Model must
+// find stored, 0 when the write is unconditional. If-Match uses the strong
+// comparison of RFC 9110, so weak tags never match.
+func (Ctr *ControllerStruct) ifMatchVersion(GinCtx *gin.Context, ID int64) (int64, error) {
+	return ifMatchVersionOf(GinCtx, ID, Ctr.Model.GetTask)
+}
+
+// ifMatchTrashedVersion is ifMatchVersion for a task in the trash.
+func (Ctr *ControllerStruct) ifMatchTrashedVersion(GinCtx *gin.Context, ID int64) (int64, error) {
+	return ifMatchVersionOf(GinCtx, ID, Ctr.Model.GetTrashedTask)
+}
+
+// ifMatchVersionOf only calls Read, to learn the current version, when
+// If-Match lists several entity tags, or to learn there is one for *.
+func ifMatchVersionOf(GinCtx *gin.Context, ID int64, Read func(Ctx context.Context, Task Model.GetTask) (Model.TaskStoreResponse, error)) (int64, error) {
+	var header IfMatchHeaderStruct
+
+	err := GinCtx.ShouldBindHeader(&header)
+	if err != nil {
+		return 0, bindingError(err)
+	}
+
+	tags := entityTags(header.If_Match)
+	if len(tags) <= 0 {
+		return 0, nil
+	}
+
+	// If-Match: * holds for any current version, but there must be one.
+	if len(tags) == 1 && tags[0] == "*" {
+		_, err := Read(GinCtx.Request.Context(), Model.GetTask{ID: ID})
+
+		var notFoundErr *Model.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return 0, &PreconditionError{IfMatch: header.If_Match}
+		}
+
+		return 0, err
+	}
+
+	versions := []int64{}
+	for _, tag := range tags {
+		unquoted, err := strconv.Unquote(tag)
+		if err != nil {
+			continue
+		}
+		version, err := strconv.ParseInt(unquoted, 10, 64)
+		if err == nil && version > 0 {
+			versions = append(versions, version)
+		}
+	}
+
+	switch len(versions) {
+	case 0:
+		return 0, &PreconditionError{IfMatch: header.If_Match}
+	case 1:
+		return versions[0], nil
+	}
+
+	current, err := Read(GinCtx.Request.Context(), Model.GetTask{ID: ID})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, version := range versions {
+		if version == current.Version {
+			return version, nil
+		}
+	}
+
+	return 0, &PreconditionError{IfMatch: header.If_Match}
+}
+
+// notModified answers 304 when If-None-Match already names the current
+// version of Task, using the weak comparison of RFC 9110.
+func notModified(GinCtx *gin.Context, Task Model.TaskStoreResponse) bool {
+	var header IfNoneMatchHeaderStruct
+
+	if GinCtx.ShouldBindHeader(&header) != nil {
+		return false
+	}
+
+	current := TaskETag(Task.Version)
+	for _, tag := range entityTags(header.If_None_Match) {
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == current {
+			setETag(GinCtx, Task)
+			GinCtx.Status(http.StatusNotModified)
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,689 @@
+package Controller
+
+import (
+	"TaskManager/Helper/Route"
+	"TaskManager/Package/Model"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecated marks every response of a route group as deprecated in favour
+// of Successor, following RFC 9745 (Deprecation) and RFC 8594 (Sunset).
+func Deprecated(Successor string) gin.HandlerFunc {
+	deprecation := fmt.Sprintf("@%d", Route.LegacyDeprecatedAt.Unix())
+	sunset := Route.LegacySunsetAt.UTC().Format(http.TimeFormat)
+	link := fmt.Sprintf("<%s>; rel=\"successor-version\"", Successor)
+
+	return func(GinCtx *gin.Context) {
+		GinCtx.Header("Deprecation", deprecation)
+		GinCtx.Header("Sunset", sunset)
+		GinCtx.Header("Link", link)
+		GinCtx.Next()
+	}
+}
+
+func TaskLocation(ID int64) string {
+	return Route.TasksURL + "/" + strconv.FormatInt(ID, 10)
+}
+
+func toTaskStoreRequest(Req AddTaskStruct) (Model.TaskStoreRequest, error) {
+	status, err := strconv.ParseBool(Req.Task_Status)
+	if err != nil {
+		return Model.TaskStoreRequest{}, statusViolation()
+	}
+
+	return Model.TaskStoreRequest{
+		Title:            Req.Title,
+		Task_Description: Req.Task_Description,
+		Task_Status:      status,
+	}, nil
+}
+
+func (Ctr *ControllerStruct) CreateTask(GinCtx *gin.Context) {
+	var req AddTaskStruct
+
+	err := GinCtx.ShouldBindJSON(&req)
+	if err != nil {
+		RespondError(GinCtx, bindingError(err))
+		return
+	}
+
+	dbPayload, err := toTaskStoreRequest(req)
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	resl, err := Ctr.Model.AddTask(GinCtx.Request.Context(), dbPayload)
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	GinCtx.Header("Location", TaskLocation(resl.ID))
+	setETag(GinCtx, resl)
+	GinCtx.JSON(http.StatusCreated, resl)
+}
+
+// asOf reads an as_of value, a revision number or an RFC 3339 time, into Task.
+func asOf(Task *Model.GetTask, Field string, Value string) error {
+	if len(Value) <= 0 {
+		return nil
+	}
+
+	revision, err := strconv.ParseInt(Value, 10, 64)
+	if err == nil && revision > 0 {
+		Task.Revision = revision
+		return nil
+	}
+
+	Task.As_Of, err = time.Parse(time.RFC3339, Value)
+	if err != nil {
+		violations := &Model.ValidationError{}
+		violations.Add(Field, "must be a revision number or an RFC 3339 time")
+		return violations
+	}
+
+	return nil
+}
+
+// ReadTask returns the task, or with as_of the revision it had then. Past
+// revisions are not the current representation and carry no ETag.
+func (Ctr *ControllerStruct) ReadTask(GinCtx *gin.Context) {
+	var path TaskPathStruct
+	var query ReadTaskQueryStruct
+
+	err := GinCtx.ShouldBindUri(&path)
+	if err != nil {
+		RespondError(GinCtx, bindingError(err))
+		return
+	}
+
+	err = GinCtx.ShouldBindQuery(&query)
+	if err != nil {
+		RespondError(GinCtx, bindingError(err))
+		return
+	}
+
+	dbPayload := Model.GetTask{ID: path.ID}
+
+	err = asOf(&dbPayload, "as_of", query.As_Of)
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	resl, err := Ctr.Model.GetTask(GinCtx.Request.Context(), dbPayload)
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	if len(query.As_Of) > 0 {
+		GinCtx.JSON(http.StatusOK, resl)
+		return
+	}
+
+	if notModified(GinCtx, resl) {
+		return
+	}
+
+	setETag(GinCtx, resl)
+	GinCtx.JSON(http.StatusOK, resl)
+}
+
+func (Ctr *ControllerStruct) ReplaceTask(GinCtx *gin.Context) {
+	var path TaskPathStruct
+	var req AddTaskStruct
+
+	err := GinCtx.ShouldBindUri(&path)
+	if err != nil {
+		RespondError(GinCtx, bindingError(err))
+		return
+	}
+
+	err = GinCtx.ShouldBindJSON(&req)
+	if err != nil {
+		RespondError(GinCtx, bindingError(err))
+		return
+	}
+
+	updatedTask, err := toTaskStoreRequest(req)
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	version, err := Ctr.ifMatchVersion(GinCtx, path.ID)
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	resl, err := Ctr.Model.EditTask(GinCtx.Request.Context(), Model.UpdateTaskStoreRequest{
+		ID:      path.ID,
+		Version: version,
+		Task:    updatedTask,
+	})
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	setETag(GinCtx, resl)
+	GinCtx.JSON(http.StatusOK, resl)
+}
+
+const (
+	MergePatchContentType string = "application/merge-patch+json"
+	JSONPatchContentType  string = "application/json-patch+json"
+)
+
+// PatchContentTypes are the bodies PatchTask accepts, advertised in Accept-Patch.
+// Plain application/json keeps the original PatchTaskStruct body.
+var PatchContentTypes = []string{MergePatchContentType, JSONPatchContentType, "application/json"}
+
+// taskPatch reads the PATCH body as the Model.TaskPatch its Content-Type names.
+func taskPatch(GinCtx *gin.Context) (Model.TaskPatch, error) {
+	switch GinCtx.ContentType() {
+	case MergePatchContentType:
+		body, err := GinCtx.GetRawData()
+		if err != nil || !json.Valid(body) {
+			return nil, &RequestError{Err: errors.New("body is not a JSON Merge Patch document")}
+		}
+		return Model.MergePatch(body), nil
+	case JSONPatchContentType:
+		var patch Model.JSONPatch
+		err := json.NewDecoder(GinCtx.Request.Body).Decode(&patch)
+		if err != nil {
+			return nil, &RequestError{Err: err}
+		}
+		return patch, nil
+	case "application/json":
+		var req PatchTaskStruct
+		err := GinCtx.ShouldBindJSON(&req)
+		if err != nil {
+			return nil, bindingError(err)
+		}
+		return req.MergePatch()
+	}
+
+	return nil, &MediaTypeError{ContentType: GinCtx.ContentType(), Supported: PatchContentTypes}
+}
+
+// MergePatch turns the fields present in Req into a merge patch of the task.
+func (Req PatchTaskStruct) MergePatch() (Model.TaskPatch, error) {
+	task := map[string]any{}
+
+	if Req.Title != nil {
+		task["Title"] = *Req.Title
+	}
+
+	if Req.Task_Description != nil {
+		task["Task_Description"] = *Req.Task_Description
+	}
+
+	if Req.Task_Status != nil {
+		status, err := strconv.ParseBool(*Req.Task_Status)
+		if err != nil {
+			return nil, statusViolation()
+		}
+		task["Task_Status"] = status
+	}
+
+	patch, err := json.Marshal(map[string]any{"Task": task})
+	if err != nil {
+		return nil, err
+	}
+
+	return Model.MergePatch(patch), nil
+}
+
+// PatchTask applies a JSON Merge Patch (RFC 7386) or JSON Patch (RFC 6902)
+// to the task. The Model applies it inside the transaction that writes the
+// result, so only the touched fields change.
+func (Ctr *ControllerStruct) PatchTask(GinCtx *gin.Context) {
+	var path TaskPathStruct
+
+	GinCtx.Header("Accept-Patch", strings.Join(PatchContentTypes[:2], ", "))
+
+	err := GinCtx.ShouldBindUri(&path)
+	if err != nil {
+		RespondError(GinCtx, bindingError(err))
+		return
+	}
+
+	patch, err := taskPatch(GinCtx)
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	version, err := Ctr.ifMatchVersion(GinCtx, path.ID)
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	resl, err := Ctr.Model.PatchTask(GinCtx.Request.Context(), Model.PatchTaskStoreRequest{
+		ID:      path.ID,
+		Version: version,
+		Patch:   patch,
+	})
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	setETag(GinCtx, resl)
+	GinCtx.JSON(http.StatusOK, resl)
+}
+
+func (Ctr *ControllerStruct) RemoveTask(GinCtx *gin.Context) {
+	var path TaskPathStruct
+
+	err := GinCtx.ShouldBindUri(&path)
+	if err != nil {
+		RespondError(GinCtx, bindingError(err))
+		return
+	}
+
+	version, err := Ctr.ifMatchVersion(GinCtx, path.ID)
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	_, err = Ctr.Model.DeleteTask(GinCtx.Request.Context(), Model.DeleteTaskStoreRequest{
+		ID:      path.ID,
+		Version: version,
+	})
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	GinCtx.Status(http.StatusNoContent)
+}
+
+// RevertTask restores an earlier revision of the task, Workflow_Status
+// included, as a new revision.
+func (Ctr *ControllerStruct) RevertTask(GinCtx *gin.Context) {
+	var path TaskPathStruct
+	var req RevertTaskStruct
+
+	err := GinCtx.ShouldBindUri(&path)
+	if err != nil {
+		RespondError(GinCtx, bindingError(err))
+		return
+	}
+
+	err = GinCtx.ShouldBindJSON(&req)
+	if err != nil {
+		RespondError(GinCtx, bindingError(err))
+		return
+	}
+
+	version, err := Ctr.ifMatchVersion(GinCtx, path.ID)
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	resl, err := Ctr.Model.RevertTask(GinCtx.Request.Context(), Model.RevertTaskStoreRequest{
+		ID:       path.ID,
+		Version:  version,
+		Revision: req.Revision,
+	})
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	setETag(GinCtx, resl)
+	GinCtx.JSON(http.StatusOK, resl)
+}
+
+// TransitionTask moves the task along the workflow. Moves the workflow does
+// not allow from the current status fail with 409.
+func (Ctr *ControllerStruct) TransitionTask(GinCtx *gin.Context) {
+	var path TaskPathStruct
+	var req TransitionTaskStruct
+
+	err := GinCtx.ShouldBindUri(&path)
+	if err != nil {
+		RespondError(GinCtx, bindingError(err))
+		return
+	}
+
+	err = GinCtx.ShouldBindJSON(&req)
+	if err != nil {
+		RespondError(GinCtx, bindingError(err))
+		return
+	}
+
+	version, err := Ctr.ifMatchVersion(GinCtx, path.ID)
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	resl, err := Ctr.Model.TransitionTask(GinCtx.Request.Context(), Model.TransitionTaskStoreRequest{
+		ID:              path.ID,
+		Version:         version,
+		Workflow_Status: req.Workflow_Status,
+	})
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	setETag(GinCtx, resl)
+	GinCtx.JSON(http.StatusOK, resl)
+}
+
+func (Ctr *ControllerStruct) GetWorkflow(GinCtx *gin.Context) {
+	workflow := Ctr.Model.TaskWorkflow()
+
+	GinCtx.JSON(http.StatusOK, WorkflowResponse{
+		Initial:     workflow.Initial,
+		States:      workflow.States(),
+		Transitions: workflow.Transitions,
+		Terminal:    workflow.Terminal,
+	})
+}
+
+// ReadTaskHistory returns the timeline of a task, oldest change first. It
+// covers deleted and purged tasks too.
+func (Ctr *ControllerStruct) ReadTaskHistory(GinCtx *gin.Context) {
+	var path TaskPathStruct
+	var query TaskHistoryQueryStruct
+
+	err := GinCtx.ShouldBindUri(&path)
+	if err != nil {
+		RespondError(GinCtx, bindingError(err))
+		return
+	}
+
+	err = GinCtx.ShouldBindQuery(&query)
+	if err != nil {
+		RespondError(GinCtx, bindingError(err))
+		return
+	}
+
+	dbPayload := Model.TaskHistoryStore{
+		ID:    path.ID,
+		Limit: query.Limit,
+		Page:  query.Page,
+	}
+
+	if dbPayload.Limit < 1 {
+		dbPayload.Limit = 10
+	}
+
+	if dbPayload.Page < 1 {
+		dbPayload.Page = 1
+	}
+
+	page, err := Ctr.Model.TaskHistory(GinCtx.Request.Context(), dbPayload)
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	GinCtx.JSON(http.StatusOK, TaskHistoryResponse{
+		Items:       page.Items,
+		Page:        dbPayload.Page,
+		Limit:       dbPayload.Limit,
+		Total_Count: page.Total_Count,
+	})
+}
+
+// toTaskItem keeps the members of Task named in Fields, or all of them when
+// Fields is empty.
+func toTaskItem(Task Model.TaskStoreResponse, Fields []string) TaskItem {
+	selected := func(Field string) bool {
+		if len(Fields) <= 0 {
+			return true
+		}
+		for _, field := range Fields {
+			if field == Field {
+				return true
+			}
+		}
+		return false
+	}
+
+	item := TaskItem{ID: Task.ID}
+
+	if selected("Version") {
+		item.Version = &Task.Version
+	}
+
+	if selected("Workflow_Status") {
+		item.Workflow_Status = &Task.Workflow_Status
+	}
+
+	if selected("Title") {
+		item.Task.Title = &Task.Task.Title
+	}
+
+	if selected("Task_Description") {
+		item.Task.Task_Description = &Task.Task.Task_Description
+	}
+
+	if selected("Task_Status") {
+		item.Task.Task_Status = &Task.Task.Task_Status
+	}
+
+	return item
+}
+
+func (Ctr *ControllerStruct) ListTasks(GinCtx *gin.Context) {
+	var query ListTaskQueryStruct
+
+	err := GinCtx.ShouldBindQuery(&query)
+	if err != nil {
+		RespondError(GinCtx, bindingError(err))
+		return
+	}
+
+	dbPayload := Model.ListTaskStore{
+		Limit: query.Limit,
+		Page:  query.Page,
+		Filter: Model.ListTaskFilter{
+			Status:               query.Status,
+			Workflow_Status:      query.Workflow_Status,
+			Title_Contains:       query.Title_Contains,
+			Description_Contains: query.Description_Contains,
+			Created_After:        query.Created_After,
+			Created_Before:       query.Created_Before,
+			Edited_After:         query.Edited_After,
+			Edited_Before:        query.Edited_Before,
+		},
+		Sort:        Model.ParseSortKeys(query.Sort),
+		Fields:      Model.ParseFields(query.Fields),
+		Page_Token:  query.Page_Token,
+		Count_Total: query.Total_Count == nil || *query.Total_Count,
+	}
+
+	Ctr.respondPage(GinCtx, dbPayload)
+}
+
+// respondPage renders the page of tasks Task selects as a TaskListResponse.
+func (Ctr *ControllerStruct) respondPage(GinCtx *gin.Context, Task Model.ListTaskStore) {
+	if Task.Limit < 1 {
+		Task.Limit = 10
+	}
+
+	if Task.Page < 1 {
+		Task.Page = 1
+	}
+
+	page, err := Ctr.Model.PageTask(GinCtx.Request.Context(), Task)
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	items := []TaskItem{}
+	for _, task := range page.Items {
+		items = append(items, toTaskItem(task, Task.Fields))
+	}
+
+	GinCtx.JSON(http.StatusOK, TaskListResponse{
+		Items:           items,
+		Page:            Task.Page,
+		Limit:           Task.Limit,
+		Has_More:        page.Has_More,
+		Next_Page_Token: page.Next_Page_Token,
+		Total_Count:     page.Total_Count,
+	})
+}
+
+// SearchTasks ranks the active tasks by relevance to the q parameter and
+// returns snippets with the matches highlighted.
+func (Ctr *ControllerStruct) SearchTasks(GinCtx *gin.Context) {
+	var query SearchTaskQueryStruct
+
+	err := GinCtx.ShouldBindQuery(&query)
+	if err != nil {
+		RespondError(GinCtx, bindingError(err))
+		return
+	}
+
+	dbPayload := Model.SearchTaskStore{
+		Query: query.Q,
+		Limit: query.Limit,
+		Page:  query.Page,
+	}
+
+	if dbPayload.Limit < 1 {
+		dbPayload.Limit = 10
+	}
+
+	if dbPayload.Page < 1 {
+		dbPayload.Page = 1
+	}
+
+	page, err := Ctr.Model.SearchTask(GinCtx.Request.Context(), dbPayload)
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	GinCtx.JSON(http.StatusOK, TaskSearchResponse{
+		Items:       page.Items,
+		Page:        dbPayload.Page,
+		Limit:       dbPayload.Limit,
+		Total_Count: page.Total_Count,
+	})
+}
+
+// ListTrash lists the soft deleted tasks, which ListTasks hides by default.
+func (Ctr *ControllerStruct) ListTrash(GinCtx *gin.Context) {
+	var query TrashQueryStruct
+
+	err := GinCtx.ShouldBindQuery(&query)
+	if err != nil {
+		RespondError(GinCtx, bindingError(err))
+		return
+	}
+
+	Ctr.respondPage(GinCtx, Model.ListTaskStore{
+		Limit:       query.Limit,
+		Page:        query.Page,
+		Filter:      Model.ListTaskFilter{Status: Model.StatusDeleted},
+		Sort:        Model.ParseSortKeys(query.Sort),
+		Page_Token:  query.Page_Token,
+		Count_Total: query.Total_Count == nil || *query.Total_Count,
+	})
+}
+
+func (Ctr *ControllerStruct) ReadTrashedTask(GinCtx *gin.Context) {
+	var path TaskPathStruct
+
+	err := GinCtx.ShouldBindUri(&path)
+	if err != nil {
+		RespondError(GinCtx, bindingError(err))
+		return
+	}
+
+	resl, err := Ctr.Model.GetTrashedTask(GinCtx.Request.Context(), Model.GetTask{ID: path.ID})
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	if notModified(GinCtx, resl) {
+		return
+	}
+
+	setETag(GinCtx, resl)
+	GinCtx.JSON(http.StatusOK, resl)
+}
+
+// RestoreTask moves a task out of the trash, back to its Location.
+func (Ctr *ControllerStruct) RestoreTask(GinCtx *gin.Context) {
+	var path TaskPathStruct
+
+	err := GinCtx.ShouldBindUri(&path)
+	if err != nil {
+		RespondError(GinCtx, bindingError(err))
+		return
+	}
+
+	version, err := Ctr.ifMatchTrashedVersion(GinCtx, path.ID)
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	resl, err := Ctr.Model.RestoreTask(GinCtx.Request.Context(), Model.TrashTaskStoreRequest{
+		ID:      path.ID,
+		Version: version,
+	})
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	GinCtx.Header("Location", TaskLocation(resl.ID))
+	setETag(GinCtx, resl)
+	GinCtx.JSON(http.StatusOK, resl)
+}
+
+// PurgeTask permanently deletes a task from the trash.
+func (Ctr *ControllerStruct) PurgeTask(GinCtx *gin.Context) {
+	var path TaskPathStruct
+
+	err := GinCtx.ShouldBindUri(&path)
+	if err != nil {
+		RespondError(GinCtx, bindingError(err))
+		return
+	}
+
+	version, err := Ctr.ifMatchTrashedVersion(GinCtx, path.ID)
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	err = Ctr.Model.PurgeTask(GinCtx.Request.Context(), Model.TrashTaskStoreRequest{
+		ID:      path.ID,
+		Version: version,
+	})
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	GinCtx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,614 @@
+package Controller
+
+import (
+	"TaskManager/Helper/Route"
+	"TaskManager/Package/Model"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (Suite *ControllerSuiteStruct) CreateTask(Title string) Model.TaskStoreResponse {
+	rec := Suite.Do(http.MethodPost, Route.TasksURL, AddTaskStruct{
+		Title:            Title,
+		Task_Description: "Description",
+		Task_Status:      "true",
+	})
+	Suite.Require().Equal(http.StatusCreated, rec.Code, rec.Body.String())
+
+	var resp Model.TaskStoreResponse
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &resp))
+	Suite.Require().Equal(TaskLocation(resp.ID), rec.Header().Get("Location"))
+	return resp
+}
+
+func (Suite *ControllerSuiteStruct) TestResourceLifecycle() {
+	created := Suite.CreateTask("Hello")
+
+	rec := Suite.Do(http.MethodGet, TaskLocation(created.ID), nil)
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = Suite.Do(http.MethodPut, TaskLocation(created.ID), AddTaskStruct{
+		Title:            "Replaced",
+		Task_Description: "Replaced Description",
+		Task_Status:      "true",
+	})
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = Suite.Do(http.MethodPatch, TaskLocation(created.ID), map[string]string{"Title": "Patched"})
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp Model.TaskStoreResponse
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &resp))
+	Suite.Equal("Patched", resp.Task.Title)
+	Suite.Equal("Replaced Description", resp.Task.Task_Description)
+
+	rec = Suite.Do(http.MethodDelete, TaskLocation(created.ID), nil)
+	Suite.Require().Equal(http.StatusNoContent, rec.Code)
+
+	rec = Suite.Do(http.MethodGet, TaskLocation(created.ID), nil)
+	Suite.Equal(http.StatusNotFound, rec.Code)
+}
+
+func (Suite *ControllerSuiteStruct) TestListTasksWithQueryPaging() {
+	for _, title := range []string{"One", "Two", "Three"} {
+		Suite.CreateTask(title)
+	}
+
+	rec := Suite.Do(http.MethodGet, Route.TasksURL+"?limit=2&page=2", nil)
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp TaskListResponse
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &resp))
+	Suite.Equal(int64(2), resp.Page)
+	Suite.Require().Len(resp.Items, 1)
+	Suite.Equal("Three", *resp.Items[0].Task.Title)
+
+	rec = Suite.Do(http.MethodGet, Route.TasksURL+"?limit=abc", nil)
+	Suite.Equal(http.StatusBadRequest, rec.Code)
+}
+
+func (Suite *ControllerSuiteStruct) TestListTasksFiltersSortsAndSelects() {
+	for _, title := range []string{"Buy milk", "Buy 100% cotton", "Sell car"} {
+		Suite.CreateTask(title)
+	}
+	removed := Suite.CreateTask("Buy removed")
+	Suite.Require().Equal(http.StatusNoContent, Suite.Do(http.MethodDelete, TaskLocation(removed.ID), nil).Code)
+
+	rec := Suite.Do(http.MethodGet, Route.TasksURL+"?title_contains=buy&sort=-Title&fields=Title", nil)
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp TaskListResponse
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &resp))
+	Suite.Require().Len(resp.Items, 2, "Deleted tasks are only listed on request")
+	Suite.Equal("Buy milk", *resp.Items[0].Task.Title)
+	Suite.Equal("Buy 100% cotton", *resp.Items[1].Task.Title)
+	Suite.Nil(resp.Items[0].Version)
+	Suite.Nil(resp.Items[0].Task.Task_Description)
+
+	rec = Suite.Do(http.MethodGet, Route.TasksURL+"?title_contains=100%25", nil)
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &resp))
+	Suite.Require().Len(resp.Items, 1, "% must match literally")
+
+	rec = Suite.Do(http.MethodGet, Route.TasksURL+"?status=deleted", nil)
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &resp))
+	Suite.Require().Len(resp.Items, 1)
+	Suite.Equal(removed.ID, resp.Items[0].ID)
+
+	rec = Suite.Do(http.MethodGet, Route.TasksURL+"?sort=Owner&fields=Secret", nil)
+	Suite.Require().Equal(http.StatusUnprocessableEntity, rec.Code)
+
+	var problem Problem
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &problem))
+	Suite.Len(problem.InvalidParams, 2)
+
+	rec = Suite.Do(http.MethodGet, Route.TasksURL+"?created_after=yesterday", nil)
+	Suite.Equal(http.StatusBadRequest, rec.Code)
+}
+
+func (Suite *ControllerSuiteStruct) TestListTasksWithPageToken() {
+	for _, title := range []string{"Token_1", "Token_2", "Token_3"} {
+		Suite.CreateTask(title)
+	}
+
+	rec := Suite.Do(http.MethodGet, Route.TasksURL+"?title_contains=Token_&limit=2", nil)
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+
+	var first TaskListResponse
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &first))
+	Suite.True(first.Has_More)
+	Suite.Require().NotNil(first.Total_Count)
+	Suite.Equal(int64(3), *first.Total_Count)
+
+	rec = Suite.Do(http.MethodGet, Route.TasksURL+"?title_contains=Token_&limit=2&total_count=false&page_token="+first.Next_Page_Token, nil)
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+
+	var second TaskListResponse
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &second))
+	Suite.False(second.Has_More)
+	Suite.Nil(second.Total_Count)
+	Suite.Require().Len(second.Items, 1)
+	Suite.Equal("Token_3", *second.Items[0].Task.Title)
+
+	rec = Suite.Do(http.MethodGet, Route.TasksURL+"?page_token=forged", nil)
+	Suite.Equal(http.StatusUnprocessableEntity, rec.Code)
+}
+
+func (Suite *ControllerSuiteStruct) TestSearchTasks() {
+	created := Suite.CreateTask("Capybara grooming")
+	Suite.CreateTask("Capybaras bathing")
+
+	rec := Suite.Do(http.MethodGet, Route.TaskSearchURL+"?q=capybara", nil)
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp TaskSearchResponse
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &resp))
+	Suite.Equal(int64(1), resp.Total_Count)
+	Suite.Require().Len(resp.Items, 1)
+	Suite.Equal(created.ID, resp.Items[0].Task.ID)
+	Suite.Equal("<mark>Capybara</mark> grooming", resp.Items[0].Title_Snippet)
+
+	rec = Suite.Do(http.MethodGet, Route.TaskSearchURL+"?q=capy*&limit=1", nil)
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &resp))
+	Suite.Equal(int64(2), resp.Total_Count)
+	Suite.Len(resp.Items, 1)
+
+	rec = Suite.Do(http.MethodGet, Route.TaskSearchURL, nil)
+	Suite.Equal(http.StatusUnprocessableEntity, rec.Code)
+
+	rec = Suite.Do(http.MethodGet, Route.TaskSearchURL+"?q=%22%22", nil)
+	Suite.Equal(http.StatusUnprocessableEntity, rec.Code)
+}
+
+func (Suite *ControllerSuiteStruct) TestInvalidPathID() {
+	rec := Suite.Do(http.MethodGet, Route.TasksURL+"/0", nil)
+	Suite.Equal(http.StatusUnprocessableEntity, rec.Code)
+
+	rec = Suite.Do(http.MethodGet, Route.TasksURL+"/abc", nil)
+	Suite.Equal(http.StatusBadRequest, rec.Code)
+}
+
+func (Suite *ControllerSuiteStruct) TestLegacyRoutesAreDeprecated() {
+	Suite.AddTask("Legacy")
+
+	rec := Suite.Do(http.MethodGet, Route.ListPaginationURL, ListTaskStruct{Limit: 10, Page: 1})
+	Suite.Require().Equal(http.StatusOK, rec.Code)
+	Suite.NotEmpty(rec.Header().Get("Deprecation"))
+	Suite.Equal(Route.LegacySunsetAt.Format(http.TimeFormat), rec.Header().Get("Sunset"))
+	Suite.Contains(rec.Header().Get("Link"), Route.TasksURL)
+
+	rec = Suite.Do(http.MethodGet, Route.TasksURL, nil)
+	Suite.Empty(rec.Header().Get("Deprecation"))
+}
+
+func (Suite *ControllerSuiteStruct) DoRaw(Method string, URL string, ContentType string, Body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(Method, URL, strings.NewReader(Body))
+	req.Header.Set("Content-Type", ContentType)
+	rec := httptest.NewRecorder()
+	Suite.Controller.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (Suite *ControllerSuiteStruct) TestPatchDocuments() {
+	created := Suite.CreateTask("Patch")
+
+	rec := Suite.DoRaw(http.MethodPatch, TaskLocation(created.ID), MergePatchContentType, `{"Task": {"Title": "Merged"}}`)
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = Suite.DoRaw(http.MethodPatch, TaskLocation(created.ID), JSONPatchContentType,
+		`[{"op": "test", "path": "/Task/Title", "value": "Merged"}, {"op": "replace", "path": "/Task/Task_Description", "value": "Patched"}]`)
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp Model.TaskStoreResponse
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &resp))
+	Suite.Equal("Merged", resp.Task.Title)
+	Suite.Equal("Patched", resp.Task.Task_Description)
+
+	rec = Suite.DoRaw(http.MethodPatch, TaskLocation(created.ID), JSONPatchContentType, `[{"op": "test", "path": "/Task/Title", "value": "Stale"}]`)
+	Suite.Equal(http.StatusConflict, rec.Code, rec.Body.String())
+
+	rec = Suite.DoRaw(http.MethodPatch, TaskLocation(created.ID), JSONPatchContentType, `[{"op": "replace", "path": "/ID", "value": 99}]`)
+	Suite.Equal(http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+
+	rec = Suite.DoRaw(http.MethodPatch, TaskLocation(created.ID), MergePatchContentType, `{"Task":`)
+	Suite.Equal(http.StatusBadRequest, rec.Code, rec.Body.String())
+
+	rec = Suite.DoRaw(http.MethodPatch, TaskLocation(created.ID), "text/plain", `Title=Plain`)
+	Suite.Equal(http.StatusUnsupportedMediaType, rec.Code, rec.Body.String())
+	Suite.Contains(rec.Header().Get("Accept-Patch"), MergePatchContentType)
+
+	var problem Problem
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &problem))
+	Suite.Equal(ProblemUnsupportedMediaType.Type, problem.Type)
+}
+
+func (Suite *ControllerSuiteStruct) DoWithHeader(Method string, URL string, Header string, Value string, Body any) *httptest.ResponseRecorder {
+	payload, err := json.Marshal(Body)
+	Suite.Require().NoError(err)
+
+	req := httptest.NewRequest(Method, URL, strings.NewReader(string(payload)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(Header, Value)
+	rec := httptest.NewRecorder()
+	Suite.Controller.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (Suite *ControllerSuiteStruct) TestConditionalRequests() {
+	created := Suite.CreateTask("Conditional")
+
+	rec := Suite.Do(http.MethodGet, TaskLocation(created.ID), nil)
+	Suite.Require().Equal(http.StatusOK, rec.Code)
+	etag := rec.Header().Get("ETag")
+	Suite.Equal(TaskETag(created.Version), etag)
+
+	rec = Suite.DoWithHeader(http.MethodGet, TaskLocation(created.ID), "If-None-Match", "W/"+etag, nil)
+	Suite.Equal(http.StatusNotModified, rec.Code)
+	Suite.Empty(rec.Body.String())
+
+	replacement := AddTaskStruct{Title: "Replaced", Task_Description: "Description", Task_Status: "true"}
+
+	rec = Suite.DoWithHeader(http.MethodPut, TaskLocation(created.ID), "If-Match", etag, replacement)
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+	Suite.NotEqual(etag, rec.Header().Get("ETag"))
+
+	rec = Suite.DoWithHeader(http.MethodPut, TaskLocation(created.ID), "If-Match", etag, replacement)
+	Suite.Equal(http.StatusPreconditionFailed, rec.Code, "The second writer holding the old ETag must lose")
+
+	rec = Suite.DoWithHeader(http.MethodDelete, TaskLocation(created.ID), "If-Match", "W/"+etag, nil)
+	Suite.Equal(http.StatusPreconditionFailed, rec.Code, "Weak tags never match If-Match")
+
+	rec = Suite.DoWithHeader(http.MethodGet, TaskLocation(created.ID), "If-None-Match", etag, nil)
+	Suite.Equal(http.StatusOK, rec.Code)
+
+	rec = Suite.DoWithHeader(http.MethodDelete, TaskLocation(created.ID), "If-Match", etag+", "+rec.Header().Get("ETag"), nil)
+	Suite.Equal(http.StatusNoContent, rec.Code, rec.Body.String())
+}
+
+func (Suite *ControllerSuiteStruct) TestIfMatchAnyNeedsATask() {
+	created := Suite.CreateTask("Conditional_any")
+	replacement := AddTaskStruct{Title: "Replaced", Task_Description: "Description", Task_Status: "true"}
+
+	rec := Suite.DoWithHeader(http.MethodPut, TaskLocation(created.ID), "If-Match", "*", replacement)
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = Suite.Do(http.MethodDelete, TaskLocation(created.ID), nil)
+	Suite.Require().Equal(http.StatusNoContent, rec.Code, rec.Body.String())
+
+	for _, id := range []int64{created.ID, created.ID + 1000} {
+		rec = Suite.DoWithHeader(http.MethodPut, TaskLocation(id), "If-Match", "*", replacement)
+		Suite.Require().Equal(http.StatusPreconditionFailed, rec.Code, rec.Body.String())
+		var problem Problem
+		Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &problem))
+		Suite.Equal(ProblemPreconditionFailed.Type, problem.Type)
+	}
+
+	rec = Suite.DoWithHeader(http.MethodPost, Route.TrashURL+"/"+strconv.FormatInt(created.ID, 10)+"/restore", "If-Match", "*", nil)
+	Suite.Equal(http.StatusOK, rec.Code, "* matches the task in the trash: %s", rec.Body.String())
+}
+
+func (Suite *ControllerSuiteStruct) TestTrashLifecycle() {
+	kept := Suite.CreateTask("Trash_kept")
+	purged := Suite.CreateTask("Trash_purged")
+
+	trashURL := func(ID int64) string {
+		return Route.TrashURL + "/" + strconv.FormatInt(ID, 10)
+	}
+
+	rec := Suite.Do(http.MethodPost, trashURL(kept.ID)+"/restore", nil)
+	Suite.Equal(http.StatusNotFound, rec.Code, "Active tasks are not in the trash")
+
+	for _, task := range []Model.TaskStoreResponse{kept, purged} {
+		rec = Suite.Do(http.MethodDelete, TaskLocation(task.ID), nil)
+		Suite.Require().Equal(http.StatusNoContent, rec.Code, rec.Body.String())
+	}
+
+	rec = Suite.Do(http.MethodGet, Route.TrashURL, nil)
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+
+	var trash TaskListResponse
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &trash))
+	ids := []int64{}
+	for _, item := range trash.Items {
+		ids = append(ids, item.ID)
+	}
+	Suite.Contains(ids, kept.ID)
+	Suite.Contains(ids, purged.ID)
+
+	rec = Suite.Do(http.MethodGet, trashURL(kept.ID), nil)
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+	etag := rec.Header().Get("ETag")
+
+	rec = Suite.DoWithHeader(http.MethodPost, trashURL(kept.ID)+"/restore", "If-Match", TaskETag(kept.Version), nil)
+	Suite.Equal(http.StatusPreconditionFailed, rec.Code)
+
+	rec = Suite.DoWithHeader(http.MethodPost, trashURL(kept.ID)+"/restore", "If-Match", TaskETag(kept.Version)+", "+etag, nil)
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+	Suite.Equal(TaskLocation(kept.ID), rec.Header().Get("Location"))
+
+	rec = Suite.Do(http.MethodGet, TaskLocation(kept.ID), nil)
+	Suite.Equal(http.StatusOK, rec.Code)
+
+	rec = Suite.Do(http.MethodDelete, trashURL(purged.ID), nil)
+	Suite.Equal(http.StatusNoContent, rec.Code, rec.Body.String())
+
+	rec = Suite.Do(http.MethodGet, trashURL(purged.ID), nil)
+	Suite.Equal(http.StatusNotFound, rec.Code)
+}
+
+func (Suite *ControllerSuiteStruct) TestTaskTransitions() {
+	task := Suite.CreateTask("Workflow_task")
+	Suite.Equal(Model.DefaultWorkflow.Initial, task.Workflow_Status)
+
+	transitionURL := TaskLocation(task.ID) + "/transitions"
+
+	rec := Suite.DoWithHeader(http.MethodPost, transitionURL, "If-Match", TaskETag(task.Version), TransitionTaskStruct{Workflow_Status: "in_progress"})
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+
+	var moved Model.TaskStoreResponse
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &moved))
+	Suite.Equal("in_progress", moved.Workflow_Status)
+	Suite.Equal(TaskETag(moved.Version), rec.Header().Get("ETag"))
+
+	rec = Suite.DoWithHeader(http.MethodPost, transitionURL, "If-Match", TaskETag(task.Version), TransitionTaskStruct{Workflow_Status: "done"})
+	Suite.Equal(http.StatusPreconditionFailed, rec.Code)
+
+	rec = Suite.Do(http.MethodPost, transitionURL, TransitionTaskStruct{Workflow_Status: "done"})
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = Suite.Do(http.MethodPost, transitionURL, TransitionTaskStruct{Workflow_Status: "todo"})
+	Suite.Require().Equal(http.StatusConflict, rec.Code, rec.Body.String())
+
+	var problem Problem
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &problem))
+	Suite.Equal(ProblemInvalidTransition.Type, problem.Type)
+
+	rec = Suite.Do(http.MethodGet, Route.TasksURL+"?workflow_status=done", nil)
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+
+	var list TaskListResponse
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &list))
+	ids := []int64{}
+	for _, item := range list.Items {
+		ids = append(ids, item.ID)
+	}
+	Suite.Contains(ids, task.ID)
+
+	rec = Suite.Do(http.MethodGet, Route.WorkflowURL, nil)
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+
+	var workflow WorkflowResponse
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &workflow))
+	Suite.Equal(Model.DefaultWorkflow.Initial, workflow.Initial)
+	Suite.Contains(workflow.States, "cancelled")
+}
+
+func (Suite *ControllerSuiteStruct) TestTaskHistory() {
+	rec := Suite.DoWithHeader(http.MethodPost, Route.TasksURL, ActorHeader, "alice", AddTaskStruct{Title: "History_task", Task_Description: "Audited", Task_Status: "true"})
+	Suite.Require().Equal(http.StatusCreated, rec.Code, rec.Body.String())
+	Suite.NotEmpty(rec.Header().Get(RequestIDHeader))
+
+	var task Model.TaskStoreResponse
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &task))
+
+	rec = Suite.DoWithHeader(http.MethodDelete, TaskLocation(task.ID), RequestIDHeader, "delete-1", nil)
+	Suite.Require().Equal(http.StatusNoContent, rec.Code, rec.Body.String())
+	Suite.Equal("delete-1", rec.Header().Get(RequestIDHeader))
+
+	rec = Suite.Do(http.MethodGet, TaskLocation(task.ID)+"/history", nil)
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+
+	var history TaskHistoryResponse
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &history))
+	Suite.Require().Len(history.Items, 2)
+	Suite.Equal(int64(2), history.Total_Count)
+	Suite.Equal(Model.ActionCreated, history.Items[0].Action)
+	Suite.Equal("alice", history.Items[0].Actor)
+	Suite.Equal(Model.ActionDeleted, history.Items[1].Action)
+	Suite.Equal(AnonymousActor, history.Items[1].Actor)
+	Suite.Equal("delete-1", history.Items[1].Request_ID)
+	Suite.NotContains(history.Items[1].Changes, "Task_Status", "Deleting sets Deleted_At only")
+
+	rec = Suite.Do(http.MethodGet, TaskLocation(task.ID+1000)+"/history", nil)
+	Suite.Equal(http.StatusNotFound, rec.Code)
+}
+
+func (Suite *ControllerSuiteStruct) TestRevertTask() {
+	task := Suite.CreateTask("Revision_task")
+
+	rec := Suite.Do(http.MethodPut, TaskLocation(task.ID), AddTaskStruct{Title: "Oops", Task_Description: "Overwritten", Task_Status: "true"})
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = Suite.Do(http.MethodGet, TaskLocation(task.ID)+"?as_of=1", nil)
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+	Suite.Empty(rec.Header().Get("ETag"), "Past revisions carry no ETag")
+
+	var first Model.TaskStoreResponse
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &first))
+	Suite.Equal(task, first)
+
+	rec = Suite.Do(http.MethodGet, TaskLocation(task.ID)+"?as_of="+time.Now().Add(time.Minute).UTC().Format(time.RFC3339), nil)
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+	Suite.Contains(rec.Body.String(), "Overwritten")
+
+	rec = Suite.Do(http.MethodGet, TaskLocation(task.ID)+"?as_of=yesterday", nil)
+	Suite.Equal(http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+
+	rec = Suite.Do(http.MethodPost, TaskLocation(task.ID)+"/revert", RevertTaskStruct{Revision: 7})
+	Suite.Equal(http.StatusNotFound, rec.Code, rec.Body.String())
+
+	rec = Suite.DoWithHeader(http.MethodPost, TaskLocation(task.ID)+"/revert", "If-Match", TaskETag(task.Version), RevertTaskStruct{Revision: 1})
+	Suite.Equal(http.StatusPreconditionFailed, rec.Code, rec.Body.String())
+
+	rec = Suite.DoWithHeader(http.MethodPost, TaskLocation(task.ID)+"/revert", "If-Match", TaskETag(task.Version+1), RevertTaskStruct{Revision: 1})
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+
+	var reverted Model.TaskStoreResponse
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &reverted))
+	Suite.Equal(task.Task, reverted.Task)
+	Suite.Equal(task.Version+2, reverted.Version)
+	Suite.Equal(TaskETag(reverted.Version), rec.Header().Get("ETag"))
+}
+
+func (Suite *ControllerSuiteStruct) TestBulkTasks() {
+	rec := Suite.Do(http.MethodPost, Route.TasksBulkURL, BulkCreateStruct{Tasks: []AddTaskStruct{
+		{Title: "Bulk_one", Task_Description: "Bulk", Task_Status: "true"},
+		{Title: "Bulk_two", Task_Description: "Bulk", Task_Status: "true"},
+	}})
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+
+	var created BulkResponse
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &created))
+	Suite.Equal(2, created.Applied)
+	Suite.Require().Len(created.Items, 2)
+	Suite.Equal(http.StatusCreated, created.Items[0].Status)
+	first := *created.Items[0].Task
+
+	rec = Suite.Do(http.MethodPost, Route.TasksBulkURL, BulkCreateStruct{Tasks: []AddTaskStruct{
+		{Title: "Bulk_atomic", Task_Description: "Bulk", Task_Status: "true"},
+		{Title: "", Task_Description: "Bulk", Task_Status: "maybe"},
+	}})
+	Suite.Require().Equal(http.StatusMultiStatus, rec.Code, rec.Body.String())
+
+	var aborted BulkResponse
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &aborted))
+	Suite.Equal(0, aborted.Applied)
+	Suite.Equal(2, aborted.Failed)
+	Suite.Equal(ProblemBulkAborted.Type, aborted.Items[0].Problem.Type)
+	Suite.Equal(ProblemValidation.Type, aborted.Items[1].Problem.Type)
+	Suite.Len(aborted.Items[1].Problem.InvalidParams, 2)
+
+	rec = Suite.Do(http.MethodPut, Route.TasksBulkURL, BulkUpdateStruct{Mode: Model.BulkBestEffort, Tasks: []BulkUpdateItemStruct{
+		{ID: first.ID, Version: first.Version, Title: "Bulk_one_updated", Task_Description: "Bulk", Task_Status: "true"},
+		{ID: first.ID + 1000, Title: "Bulk_missing", Task_Description: "Bulk", Task_Status: "true"},
+	}})
+	Suite.Require().Equal(http.StatusMultiStatus, rec.Code, rec.Body.String())
+
+	var updated BulkResponse
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &updated))
+	Suite.Equal(1, updated.Applied)
+	Suite.Equal(http.StatusOK, updated.Items[0].Status)
+	Suite.Equal("Bulk_one_updated", updated.Items[0].Task.Task.Title)
+	Suite.Equal(http.StatusNotFound, updated.Items[1].Status)
+
+	rec = Suite.Do(http.MethodDelete, Route.TasksBulkURL, BulkDeleteStruct{Tasks: []BulkDeleteItemStruct{{ID: first.ID}, {ID: created.Items[1].Task.ID}}})
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = Suite.Do(http.MethodGet, TaskLocation(first.ID), nil)
+	Suite.Equal(http.StatusNotFound, rec.Code)
+
+	rec = Suite.Do(http.MethodDelete, Route.TasksBulkURL, BulkDeleteStruct{Mode: "some", Tasks: []BulkDeleteItemStruct{}})
+	Suite.Equal(http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+}
+
+func (Suite *ControllerSuiteStruct) TestIdempotencyKeyReleasedOnPanic() {
+	Suite.Controller.router.POST("/panics", func(GinCtx *gin.Context) {
+		panic("handler failed")
+	})
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		rec := Suite.DoWithHeader(http.MethodPost, "/panics", IdempotencyKeyHeader, "panic-1", nil)
+		Suite.Equal(http.StatusInternalServerError, rec.Code, "attempt %d must run the handler, not find the key in use", attempt)
+	}
+}
+
+func (Suite *ControllerSuiteStruct) TestIdempotencyKey() {
+	body := AddTaskStruct{Title: "Idempotent", Task_Description: "Retried", Task_Status: "true"}
+
+	first := Suite.DoWithHeader(http.MethodPost, Route.TasksURL, IdempotencyKeyHeader, "create-1", body)
+	Suite.Require().Equal(http.StatusCreated, first.Code, first.Body.String())
+	Suite.Empty(first.Header().Get(IdempotentReplayedHeader))
+
+	retry := Suite.DoWithHeader(http.MethodPost, Route.TasksURL, IdempotencyKeyHeader, "create-1", body)
+	Suite.Require().Equal(http.StatusCreated, retry.Code, retry.Body.String())
+	Suite.Equal("true", retry.Header().Get(IdempotentReplayedHeader))
+	Suite.Equal(first.Body.String(), retry.Body.String())
+	Suite.Equal(first.Header().Get("Location"), retry.Header().Get("Location"))
+	Suite.Equal(first.Header().Get("ETag"), retry.Header().Get("ETag"))
+
+	rec := Suite.Do(http.MethodGet, Route.TasksURL, nil)
+	Suite.Require().Equal(http.StatusOK, rec.Code)
+	var list TaskListResponse
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &list))
+	Suite.Len(list.Items, 1, "A retry does not create the task again")
+
+	body.Title = "Different"
+	rec = Suite.DoWithHeader(http.MethodPost, Route.TasksURL, IdempotencyKeyHeader, "create-1", body)
+	Suite.Require().Equal(http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+	var problem Problem
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &problem))
+	Suite.Equal(ProblemIdempotencyKeyReused.Type, problem.Type)
+
+	rec = Suite.DoWithHeader(http.MethodPost, Route.PostURL, IdempotencyKeyHeader, "create-1", body)
+	Suite.Equal(http.StatusUnprocessableEntity, rec.Code, "A key is bound to the route it was first used on")
+
+	rec = Suite.DoWithHeader(http.MethodPost, Route.TasksURL, IdempotencyKeyHeader, "bad key", body)
+	Suite.Require().Equal(http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &problem))
+	Suite.Equal(ProblemValidation.Type, problem.Type)
+
+	rec = Suite.DoWithHeader(http.MethodPost, Route.TasksURL, IdempotencyKeyHeader, "invalid-1", AddTaskStruct{})
+	Suite.Require().Equal(http.StatusUnprocessableEntity, rec.Code)
+	retry = Suite.DoWithHeader(http.MethodPost, Route.TasksURL, IdempotencyKeyHeader, "invalid-1", AddTaskStruct{})
+	Suite.Equal(rec.Code, retry.Code, "Client errors are replayed too")
+	Suite.Equal("true", retry.Header().Get(IdempotentReplayedHeader))
+
+	rec = Suite.DoWithHeader(http.MethodGet, Route.TasksURL, IdempotencyKeyHeader, "create-1", nil)
+	Suite.Equal(http.StatusOK, rec.Code, "Reads ignore the key")
+	Suite.Empty(rec.Header().Get(IdempotentReplayedHeader))
+}
+
+func (Suite *ControllerSuiteStruct) TestIdempotencyKeyIsScopedToActor() {
+	post := func(Actor string) *httptest.ResponseRecorder {
+		payload, err := json.Marshal(AddTaskStruct{Title: "Private", Task_Description: "Only for alice", Task_Status: "true"})
+		Suite.Require().NoError(err)
+
+		req := httptest.NewRequest(http.MethodPost, Route.TasksURL, strings.NewReader(string(payload)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(IdempotencyKeyHeader, "shared-1")
+		req.Header.Set(ActorHeader, Actor)
+		rec := httptest.NewRecorder()
+		Suite.Controller.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post("alice")
+	Suite.Require().Equal(http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = post("mallory")
+	Suite.Equal(http.StatusUnprocessableEntity, rec.Code, "Another actor must not get the stored response")
+	Suite.Empty(rec.Header().Get(IdempotentReplayedHeader))
+	Suite.NotContains(rec.Body.String(), "Only for alice")
+
+	rec = post("alice")
+	Suite.Equal(http.StatusCreated, rec.Code)
+	Suite.Equal("true", rec.Header().Get(IdempotentReplayedHeader))
+}
+
+func (Suite *ControllerSuiteStruct) TestIdempotentBodyIsLimited() {
+	body := AddTaskStruct{Title: "Large", Task_Description: strings.Repeat("x", int(MaxIdempotentBodyBytes)), Task_Status: "true"}
+
+	rec := Suite.DoWithHeader(http.MethodPost, Route.TasksURL, IdempotencyKeyHeader, "large-1", body)
+	Suite.Require().Equal(http.StatusRequestEntityTooLarge, rec.Code, rec.Body.String())
+	var problem Problem
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &problem))
+	Suite.Equal(ProblemPayloadTooLarge.Type, problem.Type)
+}
+
+func (Suite *ControllerSuiteStruct) TestMetricsAreServed() {
+	rec := Suite.Do(http.MethodGet, Route.MetricsURL, nil)
+	Suite.Require().Equal(http.StatusOK, rec.Code)
+
+	var metrics map[string]any
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &metrics))
+	Suite.Contains(metrics, "transactions")
+	Suite.Contains(metrics, "database")
+	Suite.NotContains(metrics, "cmdline", "The command line may hold the database password")
+	Suite.NotContains(metrics, "memstats")
+}
@@ -2,10 +2,8 @@ package Controller
 
 import (
 	"TaskManager/Package/Model"
-	"errors"
 	"net/http"
 	"strconv"
-	"sync"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,7 +13,7 @@ func (Ctr *ControllerStruct) AddData(GinCtx *gin.Context) {
 
 	err := GinCtx.ShouldBind(&req)
 	if err != nil {
-		GinCtx.JSON(http.StatusBadRequest, ErrorObjInitiator(err))
+		RespondError(GinCtx, bindingError(err))
 		return
 	}
 
@@ -26,40 +24,18 @@ func (Ctr *ControllerStruct) AddData(GinCtx *gin.Context) {
 	dbPayload.Task_Status, err = strconv.ParseBool(req.Task_Status)
 
 	if err != nil {
-		GinCtx.JSON(http.StatusBadRequest, ErrorObjInitiator(err))
+		RespondError(GinCtx, statusViolation())
 		return
 	}
 
-	errChannel := make(chan error, 1)
-	defer close(errChannel)
-	resChannel := make(chan Model.TaskStoreResponse, 1)
-	defer close(resChannel)
-	wg := sync.WaitGroup{}
-	//var IsexecutionFinished bool = false
+	resl, err := Ctr.Model.AddTask(GinCtx.Request.Context(), dbPayload)
 
-	wg.Add(1)
-
-	go Ctr.Model.AddTask(dbPayload, &wg, resChannel, errChannel)
-
-	go func() {
-
-		for err := range errChannel {
-			GinCtx.JSON(http.StatusBadRequest, ErrorObjInitiator(err))
-			break
-		}
-	}()
-
-	go func() {
-
-		for resl := range resChannel {
-			GinCtx.JSON(http.StatusOK, resl)
-			break
-		}
-	}()
-
-	wg.Wait()
-	return
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
 
+	GinCtx.JSON(http.StatusOK, resl)
 }
 
 func (Ctr *ControllerStruct) GetData(GinCtx *gin.Context) {
@@ -67,7 +43,7 @@ func (Ctr *ControllerStruct) GetData(GinCtx *gin.Context) {
 
 	err := GinCtx.ShouldBindBodyWithJSON(&req)
 	if err != nil {
-		GinCtx.JSON(http.StatusBadRequest, ErrorObjInitiator(err))
+		RespondError(GinCtx, bindingError(err))
 		return
 	}
 
@@ -75,39 +51,31 @@ func (Ctr *ControllerStruct) GetData(GinCtx *gin.Context) {
 
 	dbPayload.ID = req.ID
 
-	errChannel := make(chan error, 1)
-	defer close(errChannel)
-	resChannel := make(chan Model.TaskStoreResponse, 1)
-	defer close(resChannel)
-	wg := sync.WaitGroup{}
-	//var IsexecutionFinished bool = false
-
-	wg.Add(1)
-
-	go Ctr.Model.GetTask(dbPayload, &wg, resChannel, errChannel)
-
-	go func() {
-		for err := range errChannel {
-			GinCtx.JSON(http.StatusBadRequest, ErrorObjInitiator(err))
-			return
-		}
-	}()
-
-	go func() {
-
-		for resl := range resChannel {
-			if resl.ID >= 1 {
-				GinCtx.JSON(http.StatusOK, resl)
-			} else {
-				GinCtx.JSON(http.StatusNotFound, ErrorObjInitiator(errors.New("Data Not Found")))
-			}
-			return
-		}
-	}()
-
-	wg.Wait()
-	return
+	err = asOf(&dbPayload, "As_Of", req.As_Of)
 
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	resl, err := Ctr.Model.GetTask(GinCtx.Request.Context(), dbPayload)
+
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
+
+	if len(req.As_Of) > 0 {
+		GinCtx.JSON(http.StatusOK, resl)
+		return
+	}
+
+	if notModified(GinCtx, resl) {
+		return
+	}
+
+	setETag(GinCtx, resl)
+	GinCtx.JSON(http.StatusOK, resl)
 }
 
 func (Ctr *ControllerStruct) EditData(GinCtx *gin.Context) {
@@ -115,7 +83,7 @@ func (Ctr *ControllerStruct) EditData(GinCtx *gin.Context) {
 
 	err := GinCtx.ShouldBindBodyWithJSON(&req)
 	if err != nil {
-		GinCtx.JSON(http.StatusBadRequest, ErrorObjInitiator(err))
+		RespondError(GinCtx, bindingError(err))
 		return
 	}
 	updatedTask := Model.TaskStoreRequest{
@@ -125,7 +93,7 @@ func (Ctr *ControllerStruct) EditData(GinCtx *gin.Context) {
 
 	updatedTask.Task_Status, err = strconv.ParseBool(req.Task_Status)
 	if err != nil {
-		GinCtx.JSON(http.StatusBadRequest, ErrorObjInitiator(err))
+		RespondError(GinCtx, statusViolation())
 		return
 	}
 
@@ -134,37 +102,21 @@ func (Ctr *ControllerStruct) EditData(GinCtx *gin.Context) {
 	dbPayload.ID = req.ID
 	dbPayload.Task = updatedTask
 
-	errChannel := make(chan error, 1)
-	defer close(errChannel)
-	resChannel := make(chan Model.TaskStoreResponse, 1)
-	defer close(resChannel)
-	wg := sync.WaitGroup{}
-	//var IsexecutionFinished bool = false
-
-	wg.Add(1)
-
-	go Ctr.Model.EditTask(dbPayload, &wg, resChannel, errChannel)
-
-	go func() {
-
-		for err := range errChannel {
-			GinCtx.JSON(http.StatusBadRequest, ErrorObjInitiator(err))
-			return
-		}
-	}()
+	dbPayload.Version, err = Ctr.ifMatchVersion(GinCtx, req.ID)
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
 
-	go func() {
+	resl, err := Ctr.Model.EditTask(GinCtx.Request.Context(), dbPayload)
 
-		for resl := range resChannel {
-			if resl.ID >= 1 {
-				GinCtx.JSON(http.StatusOK, resl)
-			}
-			return
-		}
-	}()
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
 
-	wg.Wait()
-	return
+	setETag(GinCtx, resl)
+	GinCtx.JSON(http.StatusOK, resl)
 }
 
 func (Ctr *ControllerStruct) DeleteData(GinCtx *gin.Context) {
@@ -172,7 +124,7 @@ func (Ctr *ControllerStruct) DeleteData(GinCtx *gin.Context) {
 
 	err := GinCtx.ShouldBindBodyWithJSON(&req)
 	if err != nil {
-		GinCtx.JSON(http.StatusBadRequest, ErrorObjInitiator(err))
+		RespondError(GinCtx, bindingError(err))
 		return
 	}
 
@@ -181,36 +133,20 @@ func (Ctr *ControllerStruct) DeleteData(GinCtx *gin.Context) {
 	dbPayload.ID = req.ID
 	dbPayload.Task = Model.TaskStoreRequest{}
 
-	errChannel := make(chan error, 1)
-	defer close(errChannel)
-	resChannel := make(chan Model.DeleteTaskStoreResponse, 1)
-	defer close(resChannel)
-	wg := sync.WaitGroup{}
-
-	wg.Add(1)
-
-	go Ctr.Model.DeleteTask(dbPayload, &wg, resChannel, errChannel)
-
-	go func() {
-
-		for err := range errChannel {
-			GinCtx.JSON(http.StatusBadRequest, ErrorObjInitiator(err))
-			return
-		}
-	}()
+	dbPayload.Version, err = Ctr.ifMatchVersion(GinCtx, req.ID)
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
 
-	go func() {
+	resl, err := Ctr.Model.DeleteTask(GinCtx.Request.Context(), dbPayload)
 
-		for resl := range resChannel {
-			if resl.ID >= 1 {
-				GinCtx.JSON(http.StatusOK, resl)
-			}
-			return
-		}
-	}()
+	if err != nil {
+		RespondError(GinCtx, err)
+		return
+	}
 
-	wg.Wait()
-	return
+	GinCtx.JSON(http.StatusOK, resl)
 }
 
 func (Ctr *ControllerStruct) ListData(GinCtx *gin.Context) {
@@ -219,7 +155,7 @@ func (Ctr *ControllerStruct) ListData(GinCtx *gin.Context) {
 
 	err := GinCtx.ShouldBindBodyWithJSON(&req)
 	if err != nil {
-		GinCtx.JSON(http.StatusBadRequest, ErrorObjInitiator(err))
+		RespondError(GinCtx, bindingError(err))
 		return
 	}
 
@@ -229,10 +165,10 @@ func (Ctr *ControllerStruct) ListData(GinCtx *gin.Context) {
 	dbPayload.Offset = req.Offset
 	dbPayload.Page = req.Page
 
-	taskList, err = Ctr.Model.ListTask(dbPayload)
+	taskList, err = Ctr.Model.ListTask(GinCtx.Request.Context(), dbPayload)
 
 	if err != nil {
-		GinCtx.JSON(http.StatusBadRequest, ErrorObjInitiator(err))
+		RespondError(GinCtx, err)
 		return
 	}
 
@@ -240,8 +176,8 @@ func (Ctr *ControllerStruct) ListData(GinCtx *gin.Context) {
 	return
 }
 
-func ErrorObjInitiator(Err error) *gin.H {
-	return &gin.H{
-		"error": Err.Error(),
-	}
+func statusViolation() error {
+	violations := &Model.ValidationError{}
+	violations.Add("Task_Status", "must be one of [true false]")
+	return violations
 }
@@ -3,6 +3,8 @@ package Controller
 import (
 	"TaskManager/Helper/Route"
 	"TaskManager/Package/Model"
+	"log/slog"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,6 +15,24 @@ type ControllerInterface interface {
 	DeleteData(GinCtx *gin.Context)
 	ListData(GinCtx *gin.Context)
 	GetData(GinCtx *gin.Context)
+	CreateTask(GinCtx *gin.Context)
+	ReadTask(GinCtx *gin.Context)
+	ReplaceTask(GinCtx *gin.Context)
+	PatchTask(GinCtx *gin.Context)
+	RevertTask(GinCtx *gin.Context)
+	RemoveTask(GinCtx *gin.Context)
+	ListTasks(GinCtx *gin.Context)
+	BulkCreateTasks(GinCtx *gin.Context)
+	BulkUpdateTasks(GinCtx *gin.Context)
+	BulkDeleteTasks(GinCtx *gin.Context)
+	TransitionTask(GinCtx *gin.Context)
+	GetWorkflow(GinCtx *gin.Context)
+	ReadTaskHistory(GinCtx *gin.Context)
+	SearchTasks(GinCtx *gin.Context)
+	ListTrash(GinCtx *gin.Context)
+	ReadTrashedTask(GinCtx *gin.Context)
+	RestoreTask(GinCtx *gin.Context)
+	PurgeTask(GinCtx *gin.Context)
 }
 
 type ControllerStruct struct {
@@ -26,8 +46,11 @@ type AddTaskStruct struct {
 	Task_Status      string `json:"Task_Status" binding:"required,oneof=true false"`
 }
 
+// As_Of, a revision number or an RFC 3339 time, reads the task as it was
+// then instead of as it is now.
 type GetTaskStruct struct {
-	ID int64 `json:"ID" binding:"required,min=1"`
+	ID    int64  `json:"ID" binding:"required,min=1"`
+	As_Of string `json:"As_Of" binding:"omitempty,max=64"`
 }
 
 type UpdateTaskStruct struct {
@@ -47,15 +70,216 @@ type ListTaskStruct struct {
 	Offset int64 `json:"Offset"`
 }
 
+type TaskPathStruct struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// ReadTaskQueryStruct binds As_Of like GetTaskStruct does.
+type ReadTaskQueryStruct struct {
+	As_Of string `form:"as_of" binding:"omitempty,max=64"`
+}
+
+type RevertTaskStruct struct {
+	Revision int64 `json:"Revision" binding:"required,min=1"`
+}
+
+type TransitionTaskStruct struct {
+	Workflow_Status string `json:"Workflow_Status" binding:"required,max=32"`
+}
+
+// WorkflowResponse describes the state machine TransitionTask follows.
+type WorkflowResponse struct {
+	Initial     string
+	States      []string
+	Transitions map[string][]string
+	Terminal    []string
+}
+
+type PatchTaskStruct struct {
+	Title            *string `json:"Title" binding:"omitempty,min=1"`
+	Task_Description *string `json:"Task_Description" binding:"omitempty,min=1"`
+	Task_Status      *string `json:"Task_Status" binding:"omitempty,oneof=true false"`
+}
+
+// BulkCreateStruct, BulkUpdateStruct and BulkDeleteStruct carry up to
+// Model.MaxBulkItems tasks. Items are not validated on binding so that a bad
+// item gets its own Problem instead of failing the whole request. Mode
+// defaults to atomic.
+type BulkCreateStruct struct {
+	Mode  string          `json:"Mode" binding:"omitempty,oneof=atomic best_effort"`
+	Tasks []AddTaskStruct `json:"Tasks" binding:"required,min=1,max=100"`
+}
+
+// Version, when set, must match the stored version of the task like an
+// If-Match header does for a single write.
+type BulkUpdateItemStruct struct {
+	ID               int64  `json:"ID"`
+	Version          int64  `json:"Version"`
+	Title            string `json:"Title"`
+	Task_Description string `json:"Task_Description"`
+	Task_Status      string `json:"Task_Status"`
+}
+
+type BulkUpdateStruct struct {
+	Mode  string                 `json:"Mode" binding:"omitempty,oneof=atomic best_effort"`
+	Tasks []BulkUpdateItemStruct `json:"Tasks" binding:"required,min=1,max=100"`
+}
+
+type BulkDeleteItemStruct struct {
+	ID      int64 `json:"ID"`
+	Version int64 `json:"Version"`
+}
+
+type BulkDeleteStruct struct {
+	Mode  string                 `json:"Mode" binding:"omitempty,oneof=atomic best_effort"`
+	Tasks []BulkDeleteItemStruct `json:"Tasks" binding:"required,min=1,max=100"`
+}
+
+// BulkItemResponse is the outcome of the item at Index of a bulk request:
+// the status a single request would have got and either the task or the
+// Problem.
+type BulkItemResponse struct {
+	Index   int
+	Status  int
+	Task    *Model.TaskStoreResponse `json:"Task,omitempty"`
+	Problem *Problem                 `json:"Problem,omitempty"`
+}
+
+type BulkResponse struct {
+	Items   []BulkItemResponse
+	Applied int
+	Failed  int
+}
+
+// ListTaskQueryStruct binds the list query. Dates are RFC 3339, Sort is a
+// comma separated field list with - for descending order and Fields a
+// comma separated sparse fieldset. Page_Token continues from a previous
+// Next_Page_Token instead of Page, Total_Count=false skips the count.
+type ListTaskQueryStruct struct {
+	Limit                int64     `form:"limit" binding:"omitempty,min=1,max=1000"`
+	Page                 int64     `form:"page" binding:"omitempty,min=1"`
+	Status               string    `form:"status" binding:"omitempty,oneof=active deleted all"`
+	Workflow_Status      string    `form:"workflow_status" binding:"omitempty,max=32"`
+	Title_Contains       string    `form:"title_contains" binding:"omitempty,max=255"`
+	Description_Contains string    `form:"description_contains" binding:"omitempty,max=255"`
+	Created_After        time.Time `form:"created_after"`
+	Created_Before       time.Time `form:"created_before"`
+	Edited_After         time.Time `form:"edited_after"`
+	Edited_Before        time.Time `form:"edited_before"`
+	Sort                 string    `form:"sort"`
+	Fields               string    `form:"fields"`
+	Page_Token           string    `form:"page_token" binding:"omitempty,max=2048"`
+	Total_Count          *bool     `form:"total_count"`
+}
+
+// TrashQueryStruct binds the trash listing, a ListTaskQueryStruct without
+// the filters that do not apply to deleted tasks.
+type TrashQueryStruct struct {
+	Limit       int64  `form:"limit" binding:"omitempty,min=1,max=1000"`
+	Page        int64  `form:"page" binding:"omitempty,min=1"`
+	Sort        string `form:"sort"`
+	Page_Token  string `form:"page_token" binding:"omitempty,max=2048"`
+	Total_Count *bool  `form:"total_count"`
+}
+
+// TaskItem is a Model.TaskStoreResponse holding only the fields a sparse
+// fieldset asked for.
+type TaskItem struct {
+	ID              int64
+	Version         *int64  `json:"Version,omitempty"`
+	Workflow_Status *string `json:"Workflow_Status,omitempty"`
+	Task            TaskItemFields
+}
+
+type TaskItemFields struct {
+	Title            *string `json:"Title,omitempty"`
+	Task_Description *string `json:"Task_Description,omitempty"`
+	Task_Status      *bool   `json:"Task_Status,omitempty"`
+}
+
+type TaskListResponse struct {
+	Items           []TaskItem
+	Page            int64
+	Limit           int64
+	Has_More        bool
+	Next_Page_Token string `json:"Next_Page_Token,omitempty"`
+	Total_Count     *int64 `json:"Total_Count,omitempty"`
+}
+
+// SearchTaskQueryStruct binds the search query. Q holds words, word* prefixes
+// and "quoted phrases", all of which must match.
+type SearchTaskQueryStruct struct {
+	Q     string `form:"q" binding:"required,max=1024"`
+	Limit int64  `form:"limit" binding:"omitempty,min=1,max=100"`
+	Page  int64  `form:"page" binding:"omitempty,min=1"`
+}
+
+type TaskHistoryQueryStruct struct {
+	Limit int64 `form:"limit" binding:"omitempty,min=1,max=100"`
+	Page  int64 `form:"page" binding:"omitempty,min=1"`
+}
+
+type TaskHistoryResponse struct {
+	Items       []Model.HistoryEntry
+	Page        int64
+	Limit       int64
+	Total_Count int64
+}
+
+type TaskSearchResponse struct {
+	Items       []Model.SearchHit
+	Page        int64
+	Limit       int64
+	Total_Count int64
+}
+
+// skipAccessLog leaves requests out of the access log while the log level
+// holds back info lines.
+func skipAccessLog(GinCtx *gin.Context) bool {
+	return !slog.Default().Enabled(GinCtx.Request.Context(), slog.LevelInfo)
+}
+
 func NewController(Mdl Model.ModelInterface) ControllerStruct {
 	ctrl := ControllerStruct{}
-	router := gin.Default()
+	router := gin.New()
+	router.HandleMethodNotAllowed = true
+	router.Use(gin.LoggerWithConfig(gin.LoggerConfig{Skip: skipAccessLog}), gin.CustomRecovery(ctrl.Recovery), Audit(), Idempotency(Mdl))
+	router.NoRoute(ctrl.NoRoute)
+	router.NoMethod(ctrl.NoMethod)
+
+	legacy := router.Group("", Deprecated(Route.TasksURL))
+	legacy.POST(Route.PostURL, ctrl.AddData)
+	legacy.GET(Route.GetURL, ctrl.GetData)
+	legacy.PUT(Route.EditURL, ctrl.EditData)
+	legacy.DELETE(Route.DeleteURL, ctrl.DeleteData)
+	legacy.GET(Route.ListPaginationURL, ctrl.ListData)
+
+	router.GET(Route.TasksURL, ctrl.ListTasks)
+	router.GET(Route.TaskSearchURL, ctrl.SearchTasks)
+	router.POST(Route.TasksBulkURL, ctrl.BulkCreateTasks)
+	router.PUT(Route.TasksBulkURL, ctrl.BulkUpdateTasks)
+	router.DELETE(Route.TasksBulkURL, ctrl.BulkDeleteTasks)
+	router.POST(Route.TasksURL, ctrl.CreateTask)
+	router.GET(Route.TaskURL, ctrl.ReadTask)
+	router.PUT(Route.TaskURL, ctrl.ReplaceTask)
+	router.PATCH(Route.TaskURL, ctrl.PatchTask)
+	router.POST(Route.TaskRevertURL, ctrl.RevertTask)
+	router.DELETE(Route.TaskURL, ctrl.RemoveTask)
+	router.POST(Route.TaskTransitionsURL, ctrl.TransitionTask)
+	router.GET(Route.TaskHistoryURL, ctrl.ReadTaskHistory)
+	router.GET(Route.WorkflowURL, ctrl.GetWorkflow)
+
+	router.GET(Route.TrashURL, ctrl.ListTrash)
+	router.GET(Route.TrashTaskURL, ctrl.ReadTrashedTask)
+	router.DELETE(Route.TrashTaskURL, ctrl.PurgeTask)
+	router.POST(Route.TrashRestoreURL, ctrl.RestoreTask)
+
+	router.GET(Route.ProblemsURL, ctrl.ListProblems)
+	router.GET(Route.ProblemsURL+"/:slug", ctrl.GetProblem)
 
-	router.POST(Route.PostURL, ctrl.AddData)
-	router.GET(Route.GetURL, ctrl.GetData)
-	router.PUT(Route.EditURL, ctrl.EditData)
-	router.DELETE(Route.DeleteURL, ctrl.DeleteData)
-	router.GET(Route.ListPaginationURL, ctrl.ListData)
+	router.GET(Route.OpenAPIURL, ctrl.OpenAPI)
+	router.GET(Route.DocsURL+"/*filepath", ctrl.Docs)
+	router.GET(Route.MetricsURL, Metrics)
 
 	ctrl.Model = Mdl
 	ctrl.router = router
@@ -0,0 +1,173 @@
+package Controller
+
+import (
+	"TaskManager/Helper/Route"
+	"TaskManager/Package/Model"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type ControllerSuiteStruct struct {
+	suite.Suite
+	Controller ControllerStruct
+}
+
+func (Suite *ControllerSuiteStruct) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	Suite.Controller = NewController(Model.NewMemoryModel())
+}
+
+func (Suite *ControllerSuiteStruct) Do(Method string, URL string, Body any) *httptest.ResponseRecorder {
+	payload, err := json.Marshal(Body)
+	Suite.Require().NoError(err)
+
+	req := httptest.NewRequest(Method, URL, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	Suite.Controller.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (Suite *ControllerSuiteStruct) AddTask(Title string) Model.TaskStoreResponse {
+	rec := Suite.Do(http.MethodPost, Route.PostURL, AddTaskStruct{
+		Title:            Title,
+		Task_Description: "Description",
+		Task_Status:      "true",
+	})
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp Model.TaskStoreResponse
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &resp))
+	Suite.Require().True(resp.ID >= 1)
+	return resp
+}
+
+func (Suite *ControllerSuiteStruct) TestAddAndGetData() {
+	added := Suite.AddTask("Hello")
+
+	rec := Suite.Do(http.MethodGet, Route.GetURL, GetTaskStruct{ID: added.ID})
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp Model.TaskStoreResponse
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &resp))
+	Suite.Equal("Hello", resp.Task.Title)
+}
+
+func (Suite *ControllerSuiteStruct) TestAddDataRejectsInvalidBody() {
+	rec := Suite.Do(http.MethodPost, Route.PostURL, map[string]string{"Title": "Hello"})
+	Suite.Require().Equal(http.StatusUnprocessableEntity, rec.Code)
+
+	Suite.Equal(ProblemContentType, rec.Header().Get("Content-Type"))
+
+	var problem Problem
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &problem))
+	Suite.Equal(ProblemValidation.Type, problem.Type)
+	Suite.Equal(http.StatusUnprocessableEntity, problem.Status)
+	Suite.Equal(Route.PostURL, problem.Instance)
+
+	fields := []string{}
+	for _, param := range problem.InvalidParams {
+		fields = append(fields, param.Name)
+	}
+	Suite.ElementsMatch([]string{"Task_Description", "Task_Status"}, fields)
+}
+
+func (Suite *ControllerSuiteStruct) TestProblemTypesAreDocumented() {
+	rec := Suite.Do(http.MethodGet, Route.ProblemsURL, nil)
+	Suite.Require().Equal(http.StatusOK, rec.Code)
+
+	var registry []ProblemType
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &registry))
+	Suite.Equal(ProblemTypes, registry)
+
+	for _, kind := range ProblemTypes {
+		rec = Suite.Do(http.MethodGet, kind.Type, nil)
+		Suite.Equal(http.StatusOK, rec.Code, kind.Type)
+	}
+}
+
+func (Suite *ControllerSuiteStruct) TestUnknownRouteIsProblem() {
+	rec := Suite.Do(http.MethodGet, "/NoSuchRoute", nil)
+	Suite.Equal(http.StatusNotFound, rec.Code)
+	Suite.Equal(ProblemContentType, rec.Header().Get("Content-Type"))
+}
+
+func (Suite *ControllerSuiteStruct) TestMalformedBodyIsBadRequest() {
+	req := httptest.NewRequest(http.MethodPost, Route.PostURL, bytes.NewReader([]byte("{not json")))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	Suite.Controller.router.ServeHTTP(rec, req)
+
+	Suite.Equal(http.StatusBadRequest, rec.Code)
+}
+
+func (Suite *ControllerSuiteStruct) TestEditUnknownTaskIsNotFound() {
+	rec := Suite.Do(http.MethodPut, Route.EditURL, UpdateTaskStruct{
+		ID:               999,
+		Title:            "World",
+		Task_Description: "Description",
+		Task_Status:      "true",
+	})
+	Suite.Equal(http.StatusNotFound, rec.Code)
+}
+
+func (Suite *ControllerSuiteStruct) TestStatusForError() {
+	Suite.Equal(http.StatusConflict, StatusForError(&Model.ConflictError{Message: "Duplicate entry"}))
+	Suite.Equal(http.StatusServiceUnavailable, StatusForError(&Model.UnavailableError{Err: errors.New("connection refused")}))
+	Suite.Equal(http.StatusServiceUnavailable, StatusForError(fmt.Errorf("query: %w", context.DeadlineExceeded)))
+	Suite.Equal(http.StatusInternalServerError, StatusForError(errors.New("unexpected")))
+}
+
+func (Suite *ControllerSuiteStruct) TestEditData() {
+	added := Suite.AddTask("Hello")
+
+	rec := Suite.Do(http.MethodPut, Route.EditURL, UpdateTaskStruct{
+		ID:               added.ID,
+		Title:            "World",
+		Task_Description: "Description",
+		Task_Status:      "true",
+	})
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = Suite.Do(http.MethodGet, Route.GetURL, GetTaskStruct{ID: added.ID})
+	var resp Model.TaskStoreResponse
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &resp))
+	Suite.Equal("World", resp.Task.Title)
+}
+
+func (Suite *ControllerSuiteStruct) TestDeleteDataHidesTask() {
+	added := Suite.AddTask("Hello")
+
+	rec := Suite.Do(http.MethodDelete, Route.DeleteURL, DeleteTaskStruct{ID: added.ID})
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = Suite.Do(http.MethodGet, Route.GetURL, GetTaskStruct{ID: added.ID})
+	Suite.Equal(http.StatusNotFound, rec.Code)
+}
+
+func (Suite *ControllerSuiteStruct) TestListData() {
+	for _, title := range []string{"One", "Two", "Three"} {
+		Suite.AddTask(title)
+	}
+
+	rec := Suite.Do(http.MethodGet, Route.ListPaginationURL, ListTaskStruct{Limit: 2, Page: 2})
+	Suite.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp []Model.TaskStoreResponse
+	Suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &resp))
+	Suite.Require().Len(resp, 1)
+	Suite.Equal("Three", resp[0].Task.Title)
+}
+
+func TestControllerSuite(Testor *testing.T) {
+	suite.Run(Testor, new(ControllerSuiteStruct))
+}
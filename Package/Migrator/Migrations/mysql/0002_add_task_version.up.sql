@@ -0,0 +1 @@
+ALTER TABLE TaskStore ADD COLUMN Version bigint NOT NULL DEFAULT 1;
@@ -0,0 +1 @@
+ALTER TABLE TaskStore DROP INDEX TaskStore_Search;
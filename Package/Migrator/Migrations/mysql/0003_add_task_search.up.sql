@@ -0,0 +1 @@
+ALTER TABLE TaskStore ADD FULLTEXT INDEX TaskStore_Search (Title, Task_Description);
@@ -0,0 +1,4 @@
+ALTER TABLE TaskStore ADD COLUMN Workflow_Status varchar(32) NOT NULL DEFAULT 'todo';
+ALTER TABLE TaskStore ADD COLUMN Deleted_At timestamp NULL DEFAULT NULL;
+-- Task_Status false used to mean deleted, it is only the flag of the user from here on.
+UPDATE TaskStore SET Deleted_At = Edited_On , Task_Status = true WHERE Task_Status = false;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS IdempotencyKey (
+  Idempotency_Key varchar(255) PRIMARY KEY NOT NULL ,
+  Request_Hash char(64) NOT NULL ,
+  Status int NOT NULL ,
+  Headers text NOT NULL ,
+  Body mediumblob NOT NULL ,
+  Created_At timestamp NOT NULL ,
+  INDEX IdempotencyKey_Created (Created_At)
+);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS TaskStore (
+  ID INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL ,
+  Title varchar(255) NOT NULL,
+  Task_Description varchar(255) NOT NULL,
+  Task_Status boolean NOT NULL DEFAULT (true) ,
+  Edited_On  timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP) ,
+  Created_At  timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
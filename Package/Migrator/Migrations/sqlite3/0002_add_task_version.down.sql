@@ -0,0 +1 @@
+ALTER TABLE TaskStore DROP COLUMN Version;
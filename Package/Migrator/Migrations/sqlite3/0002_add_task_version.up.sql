@@ -0,0 +1 @@
+ALTER TABLE TaskStore ADD COLUMN Version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,5 @@
+-- Before this migration Task_Status false meant deleted, a live task cannot keep it.
+SELECT 'live tasks have Task_Status false, which would read as deleted without Deleted_At' FROM TaskStore WHERE Task_Status = false AND Deleted_At IS NULL LIMIT 1;
+UPDATE TaskStore SET Task_Status = false WHERE Deleted_At IS NOT NULL;
+ALTER TABLE TaskStore DROP COLUMN Deleted_At;
+ALTER TABLE TaskStore DROP COLUMN Workflow_Status;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS TaskHistory (
+  ID INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL ,
+  Task_ID bigint NOT NULL ,
+  Action varchar(16) NOT NULL ,
+  Version bigint NOT NULL ,
+  Actor varchar(255) NOT NULL ,
+  Request_ID varchar(128) NOT NULL ,
+  Changes text NOT NULL ,
+  Changed_At timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+CREATE INDEX IF NOT EXISTS TaskHistory_Task ON TaskHistory (Task_ID, ID);
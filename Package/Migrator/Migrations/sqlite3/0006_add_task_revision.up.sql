@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS TaskRevision (
+  Task_ID bigint NOT NULL ,
+  Version bigint NOT NULL ,
+  Title varchar(255) NOT NULL ,
+  Task_Description varchar(255) NOT NULL ,
+  Task_Status boolean NOT NULL ,
+  Workflow_Status varchar(32) NOT NULL ,
+  Revised_At timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP) ,
+  Deleted_At timestamp NULL DEFAULT NULL ,
+  PRIMARY KEY (Task_ID, Version)
+);
+INSERT INTO TaskRevision (Task_ID, Version, Title, Task_Description, Task_Status, Workflow_Status, Revised_At, Deleted_At)
+SELECT ID, Version, Title, Task_Description, Task_Status, Workflow_Status, Edited_On, Deleted_At FROM TaskStore;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS IdempotencyKey (
+  Idempotency_Key varchar(255) PRIMARY KEY NOT NULL ,
+  Request_Hash char(64) NOT NULL ,
+  Status integer NOT NULL ,
+  Headers text NOT NULL ,
+  Body blob NOT NULL ,
+  Created_At timestamp NOT NULL
+);
+CREATE INDEX IF NOT EXISTS IdempotencyKey_Created ON IdempotencyKey (Created_At);
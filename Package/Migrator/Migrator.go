@@ -0,0 +1,502 @@
+package Migrator
+
+import (
+	"TaskManager/Package/Configurator"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed Migrations
+var migrationFS embed.FS
+
+// LockName is the MySQL advisory lock held while migrations run so that two
+// instances starting together never migrate the same database at once.
+const LockName string = "TaskManager.schema_migrations"
+
+const LockTimeoutSeconds int = 60
+
+// A row of schema_migrations is Dirty while the script of its migration
+// runs, in either direction. MySQL cannot roll DDL back, so a script that
+// fails partway leaves its row Dirty and nothing runs until Resolve says
+// how the schema was repaired. SQLite runs the whole batch in a
+// transaction and never keeps a Dirty row.
+const CreateSchemaMigrationsQuery string = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+  Version bigint PRIMARY KEY NOT NULL,
+  Name varchar(255) NOT NULL,
+  Checksum char(64) NOT NULL,
+  Dirty boolean NOT NULL DEFAULT false,
+  Applied_At timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+)
+;
+`
+
+const ListSchemaMigrationsQuery string = `
+SELECT Version, Name, Checksum, Dirty, Applied_At FROM schema_migrations
+ORDER BY Version
+;
+`
+
+const InsertSchemaMigrationQuery string = `
+INSERT INTO schema_migrations (
+  Version, Name, Checksum, Dirty
+) VALUES (
+  ? , ? , ? , ?
+)
+;
+`
+
+const SetSchemaMigrationDirtyQuery string = `
+UPDATE schema_migrations
+SET Dirty = ?
+WHERE Version = ?
+;
+`
+
+const DeleteSchemaMigrationQuery string = `
+DELETE FROM schema_migrations
+WHERE Version = ?
+;
+`
+
+var ErrChecksumMismatch = errors.New("Applied migration does not match the embedded migration")
+var ErrUnknownVersion = errors.New("Database has a migration this binary does not know about")
+var ErrLockTimeout = errors.New("Timed out waiting for the migration lock held by another instance")
+var ErrMigrationRefused = errors.New("Migration refused")
+var ErrDirty = errors.New("Migration failed partway, repair the schema by hand and run migrate resolve")
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+type MigrationStatus struct {
+	Migration Migration
+	Applied   bool
+	Dirty     bool
+	AppliedAt string
+}
+
+type appliedMigration struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	Dirty     bool
+	AppliedAt string
+}
+
+type MigratorStruct struct {
+	DB         *sql.DB
+	Driver     string
+	Migrations []Migration
+}
+
+func NewMigrator(Configuration Configurator.ConfiguratorStruct) (*MigratorStruct, error) {
+	if Configuration.SqlDBConn == nil {
+		return nil, errors.New("Database is not Loaded. Please run LoadDBInstance() before creating a Migrator")
+	}
+
+	migrations, err := LoadMigrations(Configuration.DbDriver)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &MigratorStruct{
+		DB:         Configuration.SqlDBConn,
+		Driver:     Configuration.DbDriver,
+		Migrations: migrations,
+	}, nil
+}
+
+// LoadMigrations reads the embedded NNNN_name.up.sql / NNNN_name.down.sql
+// pairs for Driver, ordered by version.
+func LoadMigrations(Driver string) ([]Migration, error) {
+	dir := path.Join("Migrations", Driver)
+
+	entries, err := fs.ReadDir(migrationFS, dir)
+
+	if err != nil {
+		return nil, fmt.Errorf("No migrations for driver %q: %w", Driver, err)
+	}
+
+	byVersion := map[int64]*Migration{}
+
+	for _, entry := range entries {
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("Invalid migration file name %q", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := fs.ReadFile(migrationFS, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("Migration %d has two names %q and %q", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := []Migration{}
+
+	for _, migration := range byVersion {
+		if len(migration.Up) <= 0 || len(migration.Down) <= 0 {
+			return nil, fmt.Errorf("Migration %d_%s needs both an up and a down script", migration.Version, migration.Name)
+		}
+		sum := sha256.Sum256([]byte(migration.Up))
+		migration.Checksum = hex.EncodeToString(sum[:])
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// Up applies every pending migration in order and returns the ones it applied.
+func (Migrator *MigratorStruct) Up(Ctx context.Context) ([]Migration, error) {
+	applied := []Migration{}
+
+	err := Migrator.withLock(Ctx, func(Conn *sql.Conn) error {
+		done, err := Migrator.verify(Ctx, Conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range Migrator.Migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+
+			_, err = Conn.ExecContext(Ctx, InsertSchemaMigrationQuery, migration.Version, migration.Name, migration.Checksum, true)
+			if err != nil {
+				return err
+			}
+
+			err = execScript(Ctx, Conn, migration.Up)
+			if err != nil {
+				return fmt.Errorf("Migration %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+
+			_, err = Conn.ExecContext(Ctx, SetSchemaMigrationDirtyQuery, false, migration.Version)
+			if err != nil {
+				return err
+			}
+
+			applied = append(applied, migration)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+}
+
+// Down reverts the last Steps applied migrations, newest first.
+func (Migrator *MigratorStruct) Down(Ctx context.Context, Steps int) ([]Migration, error) {
+	reverted := []Migration{}
+
+	err := Migrator.withLock(Ctx, func(Conn *sql.Conn) error {
+		done, err := Migrator.verify(Ctx, Conn)
+		if err != nil {
+			return err
+		}
+
+		for index := len(Migrator.Migrations) - 1; index >= 0 && len(reverted) < Steps; index-- {
+			migration := Migrator.Migrations[index]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+
+			_, err = Conn.ExecContext(Ctx, SetSchemaMigrationDirtyQuery, true, migration.Version)
+			if err != nil {
+				return err
+			}
+
+			err = execScript(Ctx, Conn, migration.Down)
+			if err != nil {
+				return fmt.Errorf("Reverting migration %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+
+			_, err = Conn.ExecContext(Ctx, DeleteSchemaMigrationQuery, migration.Version)
+			if err != nil {
+				return err
+			}
+
+			reverted = append(reverted, migration)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return reverted, nil
+}
+
+// Status reports every known migration and whether it has been applied.
+func (Migrator *MigratorStruct) Status(Ctx context.Context) ([]MigrationStatus, error) {
+	statusList := []MigrationStatus{}
+
+	conn, err := Migrator.DB.Conn(Ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(Ctx, CreateSchemaMigrationsQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	done, err := listApplied(Ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, migration := range Migrator.Migrations {
+		status := MigrationStatus{Migration: migration}
+		if applied, ok := done[migration.Version]; ok {
+			status.Applied = true
+			status.Dirty = applied.Dirty
+			status.AppliedAt = applied.AppliedAt
+		}
+		statusList = append(statusList, status)
+	}
+
+	return statusList, nil
+}
+
+// Resolve clears the Dirty row of Version once the schema has been repaired
+// by hand: Applied says the migration is now fully applied, otherwise its
+// changes were undone and it is pending again.
+func (Migrator *MigratorStruct) Resolve(Ctx context.Context, Version int64, Applied bool) error {
+	return Migrator.withLock(Ctx, func(Conn *sql.Conn) error {
+		_, err := Conn.ExecContext(Ctx, CreateSchemaMigrationsQuery)
+		if err != nil {
+			return err
+		}
+
+		done, err := listApplied(Ctx, Conn)
+		if err != nil {
+			return err
+		}
+
+		if !done[Version].Dirty {
+			return fmt.Errorf("Migration %d is not dirty", Version)
+		}
+
+		if Applied {
+			_, err = Conn.ExecContext(Ctx, SetSchemaMigrationDirtyQuery, false, Version)
+		} else {
+			_, err = Conn.ExecContext(Ctx, DeleteSchemaMigrationQuery, Version)
+		}
+		return err
+	})
+}
+
+// verify creates schema_migrations when needed and checks that everything
+// recorded in it still matches the migrations embedded in this binary and
+// that no migration was left Dirty.
+func (Migrator *MigratorStruct) verify(Ctx context.Context, Conn *sql.Conn) (map[int64]appliedMigration, error) {
+	_, err := Conn.ExecContext(Ctx, CreateSchemaMigrationsQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	done, err := listApplied(Ctx, Conn)
+	if err != nil {
+		return nil, err
+	}
+
+	known := map[int64]Migration{}
+	for _, migration := range Migrator.Migrations {
+		known[migration.Version] = migration
+	}
+
+	for version, applied := range done {
+		if applied.Dirty {
+			return nil, fmt.Errorf("%w: %d_%s", ErrDirty, version, applied.Name)
+		}
+
+		migration, ok := known[version]
+		if !ok {
+			return nil, fmt.Errorf("%w: %d_%s", ErrUnknownVersion, version, applied.Name)
+		}
+		if migration.Checksum != applied.Checksum {
+			return nil, fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, version, applied.Name)
+		}
+	}
+
+	return done, nil
+}
+
+// withLock runs Fn on a dedicated connection while holding the migration lock.
+// MySQL uses a named advisory lock, SQLite an immediate transaction which
+// takes the database write lock for the whole run.
+func (Migrator *MigratorStruct) withLock(Ctx context.Context, Fn func(Conn *sql.Conn) error) error {
+	conn, err := Migrator.DB.Conn(Ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	switch Migrator.Driver {
+	case "mysql":
+		var acquired sql.NullInt64
+		err = conn.QueryRowContext(Ctx, "SELECT GET_LOCK(?, ?)", LockName, LockTimeoutSeconds).Scan(&acquired)
+		if err != nil {
+			return err
+		}
+		if !acquired.Valid || acquired.Int64 != 1 {
+			return ErrLockTimeout
+		}
+		defer func() {
+			ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*5)
+			defer cancelFunc()
+			conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", LockName)
+		}()
+
+		return Fn(conn)
+
+	case "sqlite3":
+		_, err = conn.ExecContext(Ctx, "BEGIN IMMEDIATE")
+		if err != nil {
+			return err
+		}
+
+		err = Fn(conn)
+		if err != nil {
+			conn.ExecContext(context.Background(), "ROLLBACK")
+			return err
+		}
+
+		_, err = conn.ExecContext(Ctx, "COMMIT")
+		return err
+	}
+
+	return fmt.Errorf("Unsupported driver %q", Migrator.Driver)
+}
+
+func listApplied(Ctx context.Context, Conn *sql.Conn) (map[int64]appliedMigration, error) {
+	done := map[int64]appliedMigration{}
+
+	rows, err := Conn.QueryContext(Ctx, ListSchemaMigrationsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var applied appliedMigration
+		err = rows.Scan(&applied.Version, &applied.Name, &applied.Checksum, &applied.Dirty, &applied.AppliedAt)
+		if err != nil {
+			return nil, err
+		}
+		done[applied.Version] = applied
+	}
+
+	return done, rows.Err()
+}
+
+// execScript runs each statement of Script in turn. Statements end with a
+// semicolon at the end of a line, which keeps the scripts driver agnostic
+// without enabling multi statement mode on the connection.
+//
+// A SELECT is a check: when it returns a row, the script stops there with
+// ErrMigrationRefused and the first column of the row as the reason. Scripts
+// that cannot undo a change without guessing check for it first.
+func execScript(Ctx context.Context, Conn *sql.Conn, Script string) error {
+	for _, statement := range splitStatements(Script) {
+		if !isCheck(statement) {
+			_, err := Conn.ExecContext(Ctx, statement)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		var reason string
+		err := Conn.QueryRowContext(Ctx, statement).Scan(&reason)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %s", ErrMigrationRefused, reason)
+	}
+	return nil
+}
+
+func isCheck(Statement string) bool {
+	keyword, _, _ := strings.Cut(strings.TrimSpace(Statement), " ")
+	return strings.EqualFold(keyword, "SELECT")
+}
+
+func splitStatements(Script string) []string {
+	statements := []string{}
+	current := strings.Builder{}
+
+	for _, line := range strings.Split(Script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if len(trimmed) <= 0 || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		current.WriteString(line)
+		current.WriteString("\n")
+
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, current.String())
+			current.Reset()
+		}
+	}
+
+	if len(strings.TrimSpace(current.String())) > 0 {
+		statements = append(statements, current.String())
+	}
+
+	return statements
+}
@@ -0,0 +1,178 @@
+package Migrator
+
+import (
+	"TaskManager/Package/Configurator"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type MigratorSuiteStruct struct {
+	suite.Suite
+	Config   *Configurator.ConfiguratorStruct
+	Migrator *MigratorStruct
+}
+
+func (Suite *MigratorSuiteStruct) SetupTest() {
+	config := Configurator.NewConfigurator()
+	config.DbDriver = "sqlite3"
+	config.DbConnString = filepath.Join(Suite.T().TempDir(), "TaskStore.db")
+	Suite.Require().NoError(config.LoadDBInstance())
+
+	migrator, err := NewMigrator(*config)
+	Suite.Require().NoError(err)
+
+	Suite.Config = config
+	Suite.Migrator = migrator
+}
+
+func (Suite *MigratorSuiteStruct) TearDownTest() {
+	Suite.Config.SqlDBConn.Close()
+}
+
+func (Suite *MigratorSuiteStruct) TestLoadMigrationsIsOrdered() {
+	for _, driver := range []string{"mysql", "sqlite3"} {
+		migrations, err := LoadMigrations(driver)
+		Suite.Require().NoError(err)
+		Suite.Require().NotEmpty(migrations)
+
+		for index := 1; index < len(migrations); index++ {
+			Suite.Less(migrations[index-1].Version, migrations[index].Version)
+		}
+	}
+}
+
+func (Suite *MigratorSuiteStruct) TestUpIsIdempotent() {
+	ctx := context.Background()
+
+	applied, err := Suite.Migrator.Up(ctx)
+	Suite.Require().NoError(err)
+	Suite.Len(applied, len(Suite.Migrator.Migrations))
+
+	applied, err = Suite.Migrator.Up(ctx)
+	Suite.Require().NoError(err)
+	Suite.Empty(applied)
+
+	statusList, err := Suite.Migrator.Status(ctx)
+	Suite.Require().NoError(err)
+	for _, status := range statusList {
+		Suite.True(status.Applied)
+	}
+}
+
+func (Suite *MigratorSuiteStruct) TestDownRevertsNewestFirst() {
+	ctx := context.Background()
+
+	_, err := Suite.Migrator.Up(ctx)
+	Suite.Require().NoError(err)
+
+	reverted, err := Suite.Migrator.Down(ctx, len(Suite.Migrator.Migrations))
+	Suite.Require().NoError(err)
+	Suite.Require().Len(reverted, len(Suite.Migrator.Migrations))
+	Suite.Equal(Suite.Migrator.Migrations[0].Version, reverted[len(reverted)-1].Version)
+
+	_, err = Suite.Config.SqlDBConn.Exec("SELECT * FROM TaskStore")
+	Suite.Error(err, "TaskStore must be dropped by the first migration's down script")
+}
+
+func (Suite *MigratorSuiteStruct) TestDownRefusesToGuess() {
+	ctx := context.Background()
+
+	_, err := Suite.Migrator.Up(ctx)
+	Suite.Require().NoError(err)
+
+	_, err = Suite.Config.SqlDBConn.Exec("INSERT INTO TaskStore (Title, Task_Description, Task_Status) VALUES ('Open', 'Not done yet', false)")
+	Suite.Require().NoError(err)
+
+	_, err = Suite.Migrator.Down(ctx, len(Suite.Migrator.Migrations))
+	Suite.ErrorIs(err, ErrMigrationRefused, "Before Deleted_At the task would read as deleted")
+
+	var status bool
+	Suite.Require().NoError(Suite.Config.SqlDBConn.QueryRow("SELECT Task_Status FROM TaskStore WHERE Deleted_At IS NULL").Scan(&status))
+	Suite.False(status, "A refused run changes nothing")
+
+	_, err = Suite.Config.SqlDBConn.Exec("UPDATE TaskStore SET Deleted_At = Edited_On")
+	Suite.Require().NoError(err)
+
+	_, err = Suite.Migrator.Down(ctx, len(Suite.Migrator.Migrations))
+	Suite.NoError(err)
+}
+
+func (Suite *MigratorSuiteStruct) TestDirtyMigrationBlocksUntilResolved() {
+	ctx := context.Background()
+
+	_, err := Suite.Migrator.Up(ctx)
+	Suite.Require().NoError(err)
+
+	last := Suite.Migrator.Migrations[len(Suite.Migrator.Migrations)-1]
+
+	// What a MySQL script that failed halfway leaves behind.
+	_, err = Suite.Config.SqlDBConn.Exec(SetSchemaMigrationDirtyQuery, true, last.Version)
+	Suite.Require().NoError(err)
+
+	_, err = Suite.Migrator.Up(ctx)
+	Suite.ErrorIs(err, ErrDirty)
+	_, err = Suite.Migrator.Down(ctx, 1)
+	Suite.ErrorIs(err, ErrDirty)
+
+	statusList, err := Suite.Migrator.Status(ctx)
+	Suite.Require().NoError(err)
+	Suite.True(statusList[len(statusList)-1].Dirty)
+
+	Suite.Error(Suite.Migrator.Resolve(ctx, Suite.Migrator.Migrations[0].Version, true), "only dirty migrations are resolved")
+	Suite.Require().NoError(Suite.Migrator.Resolve(ctx, last.Version, false))
+
+	_, err = Suite.Config.SqlDBConn.Exec(last.Down)
+	Suite.Require().NoError(err, "the hand repair undoes what the script did")
+
+	applied, err := Suite.Migrator.Up(ctx)
+	Suite.Require().NoError(err)
+	Suite.Equal([]Migration{last}, applied, "a resolved pending migration runs again")
+}
+
+func (Suite *MigratorSuiteStruct) TestFailedScriptLeavesNoDirtyRow() {
+	ctx := context.Background()
+
+	Suite.Migrator.Migrations = append(Suite.Migrator.Migrations, Migration{Version: 999999, Name: "broken", Up: "CREATE TABLE Broken (ID bigint);\nNOT SQL;\n", Down: "DROP TABLE Broken;\n"})
+
+	_, err := Suite.Migrator.Up(ctx)
+	Suite.Require().Error(err)
+
+	statusList, err := Suite.Migrator.Status(ctx)
+	Suite.Require().NoError(err)
+	for _, status := range statusList {
+		Suite.False(status.Applied, "SQLite rolls the whole run back, %d_%s", status.Migration.Version, status.Migration.Name)
+	}
+}
+
+func (Suite *MigratorSuiteStruct) TestChecksumMismatchIsRejected() {
+	ctx := context.Background()
+
+	_, err := Suite.Migrator.Up(ctx)
+	Suite.Require().NoError(err)
+
+	_, err = Suite.Config.SqlDBConn.Exec("UPDATE schema_migrations SET Checksum = 'tampered'")
+	Suite.Require().NoError(err)
+
+	_, err = Suite.Migrator.Up(ctx)
+	Suite.ErrorIs(err, ErrChecksumMismatch)
+}
+
+func (Suite *MigratorSuiteStruct) TestUnknownVersionIsRejected() {
+	ctx := context.Background()
+
+	_, err := Suite.Migrator.Up(ctx)
+	Suite.Require().NoError(err)
+
+	_, err = Suite.Config.SqlDBConn.Exec(InsertSchemaMigrationQuery, 999999, "from_the_future", "checksum", false)
+	Suite.Require().NoError(err)
+
+	_, err = Suite.Migrator.Up(ctx)
+	Suite.ErrorIs(err, ErrUnknownVersion)
+}
+
+func TestMigratorSuite(Testor *testing.T) {
+	suite.Run(Testor, new(MigratorSuiteStruct))
+}
@@ -0,0 +1,586 @@
+package Model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// A bulk request writes many tasks with one multi-row statement per table.
+// In BulkAtomic mode a single failing item aborts the whole request, in
+// BulkBestEffort mode the failing items are skipped and the others applied.
+// Either way every item gets its own BulkItem.
+
+const (
+	BulkAtomic     string = "atomic"
+	BulkBestEffort string = "best_effort"
+)
+
+// MaxBulkItems bounds the writes one bulk request may carry.
+const MaxBulkItems int = 100
+
+// BulkAbortedError is the outcome of an item that was fine on its own but
+// belonged to a BulkAtomic request in which another item failed.
+type BulkAbortedError struct{}
+
+func (Err *BulkAbortedError) Error() string {
+	return "Not applied, another item of the all-or-nothing request failed"
+}
+
+const BulkAddTaskQuery string = `
+INSERT INTO TaskStore (
+  Title, Task_Description, Task_Status, Workflow_Status
+) VALUES %s
+;
+`
+
+const BulkTaskQuery string = `
+SELECT ` + TaskColumns + ` FROM TaskStore
+WHERE Deleted_At IS NULL AND ID IN (%s)
+;
+`
+
+const BulkEditTaskQuery string = `
+UPDATE TaskStore
+SET Title = CASE ID %s END , Task_Description = CASE ID %s END , Task_Status = CASE ID %s END , Edited_On = %s , Version = Version + 1
+WHERE Deleted_At IS NULL AND ID IN (%s)
+;
+`
+
+const BulkDeleteTaskQuery string = `
+UPDATE TaskStore
+SET Deleted_At = %s , Edited_On = %s , Version = Version + 1
+WHERE Deleted_At IS NULL AND ID IN (%s)
+;
+`
+
+// placeholders lists Count parameters.
+func placeholders(Count int) string {
+	return strings.TrimSuffix(strings.Repeat("? , ", Count), " , ")
+}
+
+// valuesQuery fills the VALUES %s of Query with Rows tuples of Columns
+// parameters each.
+func valuesQuery(Query string, Columns int) func(Rows int) string {
+	tuple := "( " + placeholders(Columns) + " )"
+	return func(Rows int) string {
+		return fmt.Sprintf(Query, strings.TrimSuffix(strings.Repeat(tuple+" , ", Rows), " , "))
+	}
+}
+
+// inListQuery fills the IN (%s) of Query with Rows parameters.
+func inListQuery(Query string) func(Rows int) string {
+	return func(Rows int) string {
+		return fmt.Sprintf(Query, placeholders(Rows))
+	}
+}
+
+// bulkEditTaskQuery sets each column of Rows tasks from a CASE on their ID.
+// It takes the ID and value of every task per column, then the IDs again.
+func bulkEditTaskQuery(Now string) func(Rows int) string {
+	return func(Rows int) string {
+		cases := strings.TrimSuffix(strings.Repeat("WHEN ? THEN ? ", Rows), " ")
+		return fmt.Sprintf(BulkEditTaskQuery, cases, cases, cases, Now, placeholders(Rows))
+	}
+}
+
+func bulkDeleteTaskQuery(Now string) func(Rows int) string {
+	return func(Rows int) string {
+		return fmt.Sprintf(BulkDeleteTaskQuery, Now, Now, placeholders(Rows))
+	}
+}
+
+// firstInsertIDOfLast serves drivers whose LastInsertId is the ID of the last
+// row a multi-row INSERT wrote, IDs being consecutive within a statement.
+func firstInsertIDOfLast(Result sql.Result, Rows int) (int64, error) {
+	last, err := Result.LastInsertId()
+	return last - int64(Rows) + 1, err
+}
+
+// firstInsertIDOfFirst serves drivers whose LastInsertId is already the ID
+// of the first row.
+func firstInsertIDOfFirst(Result sql.Result, Rows int) (int64, error) {
+	return Result.LastInsertId()
+}
+
+func newBulkResult(Count int) BulkResult {
+	return BulkResult{Items: make([]BulkItem, Count)}
+}
+
+// settle lists the items still to be applied. In BulkAtomic mode a failed
+// item aborts the others and nothing is left to apply.
+func (Result *BulkResult) settle(Mode string) []int {
+	pending := []int{}
+	failed := false
+
+	for index, item := range Result.Items {
+		if item.Err != nil {
+			failed = true
+			continue
+		}
+		pending = append(pending, index)
+	}
+
+	if failed && Mode == BulkAtomic {
+		for _, index := range pending {
+			Result.Items[index].Err = &BulkAbortedError{}
+		}
+		return []int{}
+	}
+
+	return pending
+}
+
+// checkBulkTargets fails the pending items naming a task that is not in
+// Current, that another item names too or whose Version has moved on.
+// IDs and Versions hold the target of every item.
+func checkBulkTargets(Result *BulkResult, Pending []int, IDs []int64, Versions []int64, Current map[int64]TaskStoreResponse) {
+	seen := map[int64]bool{}
+
+	for _, index := range Pending {
+		id := IDs[index]
+		task, ok := Current[id]
+
+		switch {
+		case seen[id]:
+			violations := &ValidationError{}
+			violations.Add("ID", "names a task another item of the request already writes")
+			Result.Items[index].Err = violations
+		case !ok:
+			Result.Items[index].Err = taskNotFound(id)
+		case Versions[index] > 0 && Versions[index] != task.Version:
+			Result.Items[index].Err = &VersionConflictError{ID: id, Version: Versions[index]}
+		}
+
+		seen[id] = true
+	}
+}
+
+// bulkCurrent reads the active tasks among IDs inside Tx.
+func (Model *ModelStruct) bulkCurrent(Ctx context.Context, Tx *sql.Tx, IDs []int64) (map[int64]TaskStoreResponse, error) {
+	args := []any{}
+	for _, id := range IDs {
+		args = append(args, id)
+	}
+
+	resp, err := Tx.QueryContext(Ctx, Model.Dialect.BulkTaskQuery(len(IDs)), args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Close()
+
+	current := map[int64]TaskStoreResponse{}
+
+	for resp.Next() {
+		task, err := scanTask(resp)
+		if err != nil {
+			return nil, err
+		}
+		current[task.ID] = task
+	}
+
+	return current, resp.Err()
+}
+
+// bulkTargets reads the tasks the pending items name inside Tx and fails
+// the items checkBulkTargets rejects.
+func (Model *ModelStruct) bulkTargets(Ctx context.Context, Tx *sql.Tx, Result *BulkResult, Pending []int, IDs []int64, Versions []int64) (map[int64]TaskStoreResponse, error) {
+	pendingIDs := []int64{}
+	for _, index := range Pending {
+		pendingIDs = append(pendingIDs, IDs[index])
+	}
+
+	current, err := Model.bulkCurrent(Ctx, Tx, pendingIDs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	checkBulkTargets(Result, Pending, IDs, Versions, current)
+
+	return current, nil
+}
+
+// BulkCreate adds Task.Tasks with one INSERT.
+func (Model *ModelStruct) BulkCreate(Ctx context.Context, Task BulkCreateStore) (BulkResult, error) {
+
+	err := validateBulk(Task.Mode, len(Task.Tasks)).OrNil()
+
+	if err != nil {
+		return BulkResult{}, err
+	}
+
+	result := newBulkResult(len(Task.Tasks))
+
+	for index, task := range Task.Tasks {
+		result.Items[index].Err = Task.Rejected[index].Join(validateAddTask(task)).OrNil()
+	}
+
+	pending := result.settle(Task.Mode)
+
+	if len(pending) <= 0 {
+		return result, nil
+	}
+
+	err = Model.transact(Ctx, func(db *sql.Tx) error {
+		args := []any{}
+		for _, index := range pending {
+			args = append(args, Task.Tasks[index].Title, Task.Tasks[index].Task_Description, Task.Tasks[index].Task_Status, Model.Workflow.Initial)
+		}
+
+		res, err := db.ExecContext(Ctx, Model.Dialect.BulkAddTaskQuery(len(pending)), args...)
+
+		if err != nil {
+			return err
+		}
+
+		firstID, err := Model.Dialect.FirstInsertID(res, len(pending))
+
+		if err != nil {
+			return err
+		}
+
+		after := []*TaskStoreResponse{}
+		for offset, index := range pending {
+			result.Items[index].Task = TaskStoreResponse{
+				ID:              firstID + int64(offset),
+				Version:         1,
+				Workflow_Status: Model.Workflow.Initial,
+				Task:            Task.Tasks[index],
+			}
+			after = append(after, &result.Items[index].Task)
+		}
+
+		return Model.recordAll(Ctx, db, ActionCreated, make([]*TaskStoreResponse, len(pending)), after)
+	})
+
+	if err != nil {
+		return BulkResult{}, err
+	}
+
+	result.Applied = len(pending)
+
+	return result, nil
+}
+
+// BulkUpdate replaces Task.Tasks with one UPDATE.
+func (Model *ModelStruct) BulkUpdate(Ctx context.Context, Task BulkUpdateStore) (BulkResult, error) {
+
+	err := validateBulk(Task.Mode, len(Task.Tasks)).OrNil()
+
+	if err != nil {
+		return BulkResult{}, err
+	}
+
+	result := newBulkResult(len(Task.Tasks))
+	ids := make([]int64, len(Task.Tasks))
+	versions := make([]int64, len(Task.Tasks))
+
+	for index, task := range Task.Tasks {
+		result.Items[index].Err = Task.Rejected[index].Join(validateEditTask(task)).OrNil()
+		ids[index], versions[index] = task.ID, task.Version
+	}
+
+	pending := result.settle(Task.Mode)
+
+	if len(pending) <= 0 {
+		return result, nil
+	}
+
+	// The checks against the stored tasks run again with every attempt, so
+	// each attempt settles its own copy of the items.
+	var outcome BulkResult
+
+	err = Model.transact(Ctx, func(db *sql.Tx) error {
+		outcome = BulkResult{Items: slices.Clone(result.Items)}
+
+		current, err := Model.bulkTargets(Ctx, db, &outcome, pending, ids, versions)
+
+		if err != nil {
+			return err
+		}
+
+		pending := outcome.settle(Task.Mode)
+
+		if len(pending) <= 0 {
+			return nil
+		}
+
+		titles, descriptions, statuses, targets := []any{}, []any{}, []any{}, []any{}
+		before, after := []*TaskStoreResponse{}, []*TaskStoreResponse{}
+
+		for _, index := range pending {
+			task := Task.Tasks[index]
+			titles = append(titles, task.ID, task.Task.Title)
+			descriptions = append(descriptions, task.ID, task.Task.Task_Description)
+			statuses = append(statuses, task.ID, task.Task.Task_Status)
+			targets = append(targets, task.ID)
+
+			previous := current[task.ID]
+			outcome.Items[index].Task = TaskStoreResponse{
+				ID:              task.ID,
+				Version:         previous.Version + 1,
+				Workflow_Status: previous.Workflow_Status,
+				Task:            task.Task,
+			}
+			before = append(before, &previous)
+			after = append(after, &outcome.Items[index].Task)
+		}
+
+		args := append(append(append(titles, descriptions...), statuses...), targets...)
+
+		err = Model.bulkExec(Ctx, db, Model.Dialect.BulkEditTaskQuery(len(pending)), args, len(pending))
+
+		if err != nil {
+			return err
+		}
+
+		outcome.Applied = len(pending)
+
+		return Model.recordAll(Ctx, db, ActionUpdated, before, after)
+	})
+
+	if err != nil {
+		return BulkResult{}, err
+	}
+
+	return outcome, nil
+}
+
+// BulkDelete moves Task.Tasks to the trash with one UPDATE.
+func (Model *ModelStruct) BulkDelete(Ctx context.Context, Task BulkDeleteStore) (BulkResult, error) {
+
+	err := validateBulk(Task.Mode, len(Task.Tasks)).OrNil()
+
+	if err != nil {
+		return BulkResult{}, err
+	}
+
+	result := newBulkResult(len(Task.Tasks))
+	ids := make([]int64, len(Task.Tasks))
+	versions := make([]int64, len(Task.Tasks))
+
+	for index, task := range Task.Tasks {
+		result.Items[index].Err = validateGetTask(GetTask{ID: task.ID}).OrNil()
+		ids[index], versions[index] = task.ID, task.Version
+	}
+
+	pending := result.settle(Task.Mode)
+
+	if len(pending) <= 0 {
+		return result, nil
+	}
+
+	var outcome BulkResult
+
+	err = Model.transact(Ctx, func(db *sql.Tx) error {
+		outcome = BulkResult{Items: slices.Clone(result.Items)}
+
+		current, err := Model.bulkTargets(Ctx, db, &outcome, pending, ids, versions)
+
+		if err != nil {
+			return err
+		}
+
+		pending := outcome.settle(Task.Mode)
+
+		if len(pending) <= 0 {
+			return nil
+		}
+
+		targets := []any{}
+		before, after := []*TaskStoreResponse{}, []*TaskStoreResponse{}
+
+		for _, index := range pending {
+			previous := current[ids[index]]
+			targets = append(targets, previous.ID)
+
+			outcome.Items[index].Task = previous
+			outcome.Items[index].Task.Version++
+			before = append(before, &previous)
+			after = append(after, &outcome.Items[index].Task)
+		}
+
+		err = Model.bulkExec(Ctx, db, Model.Dialect.BulkDeleteTaskQuery(len(pending)), targets, len(pending))
+
+		if err != nil {
+			return err
+		}
+
+		outcome.Applied = len(pending)
+
+		return Model.recordAll(Ctx, db, ActionDeleted, before, after)
+	})
+
+	if err != nil {
+		return BulkResult{}, err
+	}
+
+	return outcome, nil
+}
+
+// bulkExec runs a bulk write that must reach exactly Rows tasks.
+func (Model *ModelStruct) bulkExec(Ctx context.Context, Tx *sql.Tx, Query string, Args []any, Rows int) error {
+	resp, err := Tx.ExecContext(Ctx, Query, Args...)
+
+	if err != nil {
+		return err
+	}
+
+	numRowAffected, err := resp.RowsAffected()
+
+	if err != nil {
+		return err
+	}
+
+	if numRowAffected != int64(Rows) {
+		return &ConflictError{Message: "Tasks changed while the bulk request ran, retry it"}
+	}
+
+	return nil
+}
+
+// memoryTargets returns the active tasks the pending items name and fails
+// the items checkBulkTargets rejects. The caller holds the mutex.
+func (Model *MemoryModelStruct) memoryTargets(Result *BulkResult, Pending []int, IDs []int64, Versions []int64) map[int64]TaskStoreResponse {
+	current := map[int64]TaskStoreResponse{}
+
+	for _, index := range Pending {
+		row, ok := Model.rows[IDs[index]]
+		if ok && !row.trashed() {
+			current[row.ID] = row.response()
+		}
+	}
+
+	checkBulkTargets(Result, Pending, IDs, Versions, current)
+
+	return current
+}
+
+func (Model *MemoryModelStruct) BulkCreate(Ctx context.Context, Task BulkCreateStore) (BulkResult, error) {
+	if err := Ctx.Err(); err != nil {
+		return BulkResult{}, err
+	}
+
+	err := validateBulk(Task.Mode, len(Task.Tasks)).OrNil()
+
+	if err != nil {
+		return BulkResult{}, err
+	}
+
+	result := newBulkResult(len(Task.Tasks))
+
+	for index, task := range Task.Tasks {
+		result.Items[index].Err = Task.Rejected[index].Join(validateAddTask(task)).OrNil()
+	}
+
+	Model.mutex.Lock()
+	defer Model.mutex.Unlock()
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	for _, index := range result.settle(Task.Mode) {
+		Model.lastID++
+		row := taskRow{
+			ID:              Model.lastID,
+			Version:         1,
+			Workflow_Status: Model.Workflow.Initial,
+			Task:            Task.Tasks[index],
+			Edited_On:       now,
+			Created_At:      now,
+		}
+		Model.store(row)
+		result.Items[index].Task = row.response()
+		Model.record(Ctx, ActionCreated, nil, &result.Items[index].Task)
+		result.Applied++
+	}
+
+	return result, nil
+}
+
+func (Model *MemoryModelStruct) BulkUpdate(Ctx context.Context, Task BulkUpdateStore) (BulkResult, error) {
+	if err := Ctx.Err(); err != nil {
+		return BulkResult{}, err
+	}
+
+	err := validateBulk(Task.Mode, len(Task.Tasks)).OrNil()
+
+	if err != nil {
+		return BulkResult{}, err
+	}
+
+	result := newBulkResult(len(Task.Tasks))
+	ids := make([]int64, len(Task.Tasks))
+	versions := make([]int64, len(Task.Tasks))
+
+	for index, task := range Task.Tasks {
+		result.Items[index].Err = Task.Rejected[index].Join(validateEditTask(task)).OrNil()
+		ids[index], versions[index] = task.ID, task.Version
+	}
+
+	Model.mutex.Lock()
+	defer Model.mutex.Unlock()
+
+	Model.memoryTargets(&result, result.settle(Task.Mode), ids, versions)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	for _, index := range result.settle(Task.Mode) {
+		row := Model.rows[ids[index]]
+		before := row.response()
+		row.Task = Task.Tasks[index].Task
+		row.Version++
+		row.Edited_On = now
+		Model.store(row)
+		result.Items[index].Task = row.response()
+		Model.record(Ctx, ActionUpdated, &before, &result.Items[index].Task)
+		result.Applied++
+	}
+
+	return result, nil
+}
+
+func (Model *MemoryModelStruct) BulkDelete(Ctx context.Context, Task BulkDeleteStore) (BulkResult, error) {
+	if err := Ctx.Err(); err != nil {
+		return BulkResult{}, err
+	}
+
+	err := validateBulk(Task.Mode, len(Task.Tasks)).OrNil()
+
+	if err != nil {
+		return BulkResult{}, err
+	}
+
+	result := newBulkResult(len(Task.Tasks))
+	ids := make([]int64, len(Task.Tasks))
+	versions := make([]int64, len(Task.Tasks))
+
+	for index, task := range Task.Tasks {
+		result.Items[index].Err = validateGetTask(GetTask{ID: task.ID}).OrNil()
+		ids[index], versions[index] = task.ID, task.Version
+	}
+
+	Model.mutex.Lock()
+	defer Model.mutex.Unlock()
+
+	Model.memoryTargets(&result, result.settle(Task.Mode), ids, versions)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	for _, index := range result.settle(Task.Mode) {
+		row := Model.rows[ids[index]]
+		before := row.response()
+		row.Version++
+		row.Edited_On = now
+		row.Deleted_At = now
+		Model.store(row)
+		result.Items[index].Task = row.response()
+		Model.record(Ctx, ActionDeleted, &before, &result.Items[index].Task)
+		result.Applied++
+	}
+
+	return result, nil
+}
@@ -0,0 +1,277 @@
+package Model
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/mattn/go-sqlite3"
+)
+
+// Dialect holds the SQL a storage driver needs to serve ModelInterface.
+// ModelStruct never builds SQL on its own, it always goes through the
+// Dialect picked for Configurator.ConfiguratorStruct.DbDriver.
+//
+// SearchTaskQuery and CountSearchTaskQuery use the native full-text index of
+// the database. Without them ModelStruct searches an in-process SearchIndex
+// loaded with SearchIndexQuery.
+//
+// The Bulk queries write many rows per statement, FirstInsertID finds the ID
+// of the first row a multi-row INSERT wrote.
+//
+// The IdempotencyKey queries keep the responses replayed to retried requests.
+//
+// RetryableError names the reason a driver error may go away when the
+// transaction runs again, see transact.
+type Dialect struct {
+	Driver                      string
+	AddTaskQuery                string
+	EditTaskQuery               string
+	DeleteTaskQuery             string
+	ListTaskQuery               string
+	CountTaskQuery              string
+	GetTaskQuery                string
+	PatchTaskQuery              func(Columns []string) string
+	TransitionTaskQuery         string
+	RevertTaskQuery             string
+	TrashedTaskQuery            string
+	RestoreTaskQuery            string
+	PurgeTaskQuery              string
+	PurgeTrashQuery             string
+	SearchTaskQuery             string
+	CountSearchTaskQuery        string
+	SearchFingerprintQuery      string
+	SearchIndexQuery            string
+	AddHistoryQuery             func(Rows int) string
+	TaskHistoryQuery            string
+	CountTaskHistoryQuery       string
+	TaskExistsQuery             string
+	ExpiredTrashQuery           string
+	AddRevisionQuery            func(Rows int) string
+	RevisionQuery               string
+	RevisionAsOfQuery           string
+	PurgeRevisionsQuery         string
+	BulkAddTaskQuery            func(Rows int) string
+	BulkTaskQuery               func(Rows int) string
+	BulkEditTaskQuery           func(Rows int) string
+	BulkDeleteTaskQuery         func(Rows int) string
+	FirstInsertID               func(Result sql.Result, Rows int) (int64, error)
+	IdempotencyKeyQuery         string
+	DropIdempotencyKeyQuery     string
+	ReserveIdempotencyKeyQuery  string
+	CompleteIdempotencyKeyQuery string
+	ReleaseIdempotencyKeyQuery  string
+	PurgeIdempotencyKeysQuery   string
+	ClassifyError               func(Err error) error
+	RetryableError              func(Err error) string
+}
+
+const MySQLDriver string = "mysql"
+const SQLiteDriver string = "sqlite3"
+
+var MySQLDialect = Dialect{
+	Driver:                      MySQLDriver,
+	AddTaskQuery:                AddTaskQuery,
+	EditTaskQuery:               EditTaskQuery,
+	DeleteTaskQuery:             DeleteTaskQuery,
+	ListTaskQuery:               ListTaskQuery,
+	CountTaskQuery:              CountTaskQuery,
+	GetTaskQuery:                GetTaskQuery,
+	PatchTaskQuery:              patchTaskQuery("CURRENT_TIMESTAMP()"),
+	TransitionTaskQuery:         TransitionTaskQuery,
+	RevertTaskQuery:             RevertTaskQuery,
+	TrashedTaskQuery:            TrashedTaskQuery,
+	RestoreTaskQuery:            RestoreTaskQuery,
+	PurgeTaskQuery:              PurgeTaskQuery,
+	PurgeTrashQuery:             PurgeTrashQuery,
+	SearchTaskQuery:             MySQLSearchTaskQuery,
+	CountSearchTaskQuery:        MySQLCountSearchTaskQuery,
+	SearchFingerprintQuery:      SearchFingerprintQuery,
+	SearchIndexQuery:            SearchIndexQuery,
+	AddHistoryQuery:             valuesQuery(AddHistoryQuery, 6),
+	TaskHistoryQuery:            TaskHistoryQuery,
+	CountTaskHistoryQuery:       CountTaskHistoryQuery,
+	TaskExistsQuery:             TaskExistsQuery,
+	ExpiredTrashQuery:           ExpiredTrashQuery,
+	AddRevisionQuery:            inListQuery(AddRevisionQuery),
+	RevisionQuery:               RevisionQuery,
+	RevisionAsOfQuery:           RevisionAsOfQuery,
+	PurgeRevisionsQuery:         PurgeRevisionsQuery,
+	BulkAddTaskQuery:            valuesQuery(BulkAddTaskQuery, 4),
+	BulkTaskQuery:               inListQuery(BulkTaskQuery),
+	BulkEditTaskQuery:           bulkEditTaskQuery("CURRENT_TIMESTAMP()"),
+	BulkDeleteTaskQuery:         bulkDeleteTaskQuery("CURRENT_TIMESTAMP()"),
+	FirstInsertID:               firstInsertIDOfFirst,
+	IdempotencyKeyQuery:         IdempotencyKeyQuery,
+	DropIdempotencyKeyQuery:     DropIdempotencyKeyQuery,
+	ReserveIdempotencyKeyQuery:  ReserveIdempotencyKeyQuery,
+	CompleteIdempotencyKeyQuery: CompleteIdempotencyKeyQuery,
+	ReleaseIdempotencyKeyQuery:  ReleaseIdempotencyKeyQuery,
+	PurgeIdempotencyKeysQuery:   PurgeIdempotencyKeysQuery,
+	ClassifyError:               classifyMySQLError,
+	RetryableError:              retryableMySQLError,
+}
+
+const PatchTaskQuery string = `
+UPDATE TaskStore
+SET %s , Edited_On = %s , Version = Version + 1
+WHERE ID = ? AND Version = ?
+;
+`
+
+// patchTaskQuery builds the UPDATE of a patch that only writes Columns.
+// Columns always come from patchableColumns, never from the request.
+func patchTaskQuery(Now string) func(Columns []string) string {
+	return func(Columns []string) string {
+		assignments := []string{}
+		for _, column := range Columns {
+			assignments = append(assignments, column+" = ?")
+		}
+		return fmt.Sprintf(PatchTaskQuery, strings.Join(assignments, " , "), Now)
+	}
+}
+
+func classifyMySQLError(Err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(Err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1062:
+			return &ConflictError{Message: "Duplicate entry", Err: Err}
+		case 1213:
+			return &ConflictError{Message: "Deadlock found, retry the request", Err: Err}
+		case 1040, 1203, 1205:
+			return &UnavailableError{Err: Err}
+		}
+	}
+
+	if errors.Is(Err, mysql.ErrInvalidConn) {
+		return &UnavailableError{Err: Err}
+	}
+
+	return classifyConnError(Err)
+}
+
+// retryableMySQLError recognises the errors InnoDB raises to break a
+// conflict between transactions.
+func retryableMySQLError(Err error) string {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(Err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1213:
+			return "deadlock"
+		case 1205:
+			return "lock_wait_timeout"
+		}
+	}
+	return ""
+}
+
+const SQLiteEditTaskQuery string = `
+UPDATE TaskStore
+SET Title = ? , Task_Description = ? , Task_Status = ? , Edited_On = CURRENT_TIMESTAMP , Version = Version + 1
+WHERE ID = ? AND Version = ?
+;
+`
+
+const SQLiteDeleteTaskQuery string = `
+UPDATE TaskStore
+SET Deleted_At = CURRENT_TIMESTAMP , Edited_On = CURRENT_TIMESTAMP , Version = Version + 1
+WHERE ID = ? AND Version = ?
+;
+`
+
+const SQLiteTransitionTaskQuery string = `
+UPDATE TaskStore
+SET Workflow_Status = ? , Edited_On = CURRENT_TIMESTAMP , Version = Version + 1
+WHERE ID = ? AND Version = ?
+;
+`
+
+const SQLiteRevertTaskQuery string = `
+UPDATE TaskStore
+SET Title = ? , Task_Description = ? , Task_Status = ? , Workflow_Status = ? , Edited_On = CURRENT_TIMESTAMP , Version = Version + 1
+WHERE ID = ? AND Version = ?
+;
+`
+
+var SQLiteDialect = Dialect{
+	Driver:                      SQLiteDriver,
+	AddTaskQuery:                AddTaskQuery,
+	EditTaskQuery:               SQLiteEditTaskQuery,
+	DeleteTaskQuery:             SQLiteDeleteTaskQuery,
+	ListTaskQuery:               ListTaskQuery,
+	CountTaskQuery:              CountTaskQuery,
+	GetTaskQuery:                GetTaskQuery,
+	PatchTaskQuery:              patchTaskQuery("CURRENT_TIMESTAMP"),
+	TransitionTaskQuery:         SQLiteTransitionTaskQuery,
+	RevertTaskQuery:             SQLiteRevertTaskQuery,
+	TrashedTaskQuery:            TrashedTaskQuery,
+	RestoreTaskQuery:            SQLiteRestoreTaskQuery,
+	PurgeTaskQuery:              PurgeTaskQuery,
+	PurgeTrashQuery:             PurgeTrashQuery,
+	SearchFingerprintQuery:      SearchFingerprintQuery,
+	SearchIndexQuery:            SearchIndexQuery,
+	AddHistoryQuery:             valuesQuery(AddHistoryQuery, 6),
+	TaskHistoryQuery:            TaskHistoryQuery,
+	CountTaskHistoryQuery:       CountTaskHistoryQuery,
+	TaskExistsQuery:             TaskExistsQuery,
+	ExpiredTrashQuery:           ExpiredTrashQuery,
+	AddRevisionQuery:            inListQuery(AddRevisionQuery),
+	RevisionQuery:               RevisionQuery,
+	RevisionAsOfQuery:           RevisionAsOfQuery,
+	PurgeRevisionsQuery:         PurgeRevisionsQuery,
+	BulkAddTaskQuery:            valuesQuery(BulkAddTaskQuery, 4),
+	BulkTaskQuery:               inListQuery(BulkTaskQuery),
+	BulkEditTaskQuery:           bulkEditTaskQuery("CURRENT_TIMESTAMP"),
+	BulkDeleteTaskQuery:         bulkDeleteTaskQuery("CURRENT_TIMESTAMP"),
+	FirstInsertID:               firstInsertIDOfLast,
+	IdempotencyKeyQuery:         IdempotencyKeyQuery,
+	DropIdempotencyKeyQuery:     DropIdempotencyKeyQuery,
+	ReserveIdempotencyKeyQuery:  ReserveIdempotencyKeyQuery,
+	CompleteIdempotencyKeyQuery: CompleteIdempotencyKeyQuery,
+	ReleaseIdempotencyKeyQuery:  ReleaseIdempotencyKeyQuery,
+	PurgeIdempotencyKeysQuery:   PurgeIdempotencyKeysQuery,
+	ClassifyError:               classifySQLiteError,
+	RetryableError:              retryableSQLiteError,
+}
+
+func classifySQLiteError(Err error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(Err, &sqliteErr) {
+		switch sqliteErr.Code {
+		case sqlite3.ErrConstraint:
+			return &ConflictError{Message: "Constraint violated", Err: Err}
+		case sqlite3.ErrBusy, sqlite3.ErrLocked, sqlite3.ErrCantOpen, sqlite3.ErrIoErr, sqlite3.ErrFull:
+			return &UnavailableError{Err: Err}
+		}
+	}
+
+	return classifyConnError(Err)
+}
+
+// retryableSQLiteError recognises a database another connection is writing.
+func retryableSQLiteError(Err error) string {
+	var sqliteErr sqlite3.Error
+	if errors.As(Err, &sqliteErr) {
+		switch sqliteErr.Code {
+		case sqlite3.ErrBusy:
+			return "busy"
+		case sqlite3.ErrLocked:
+			return "locked"
+		}
+	}
+	return ""
+}
+
+var dialects = map[string]Dialect{
+	MySQLDriver:  MySQLDialect,
+	SQLiteDriver: SQLiteDialect,
+}
+
+// DialectFor returns the Dialect registered for Driver.
+func DialectFor(Driver string) (Dialect, bool) {
+	dialect, ok := dialects[Driver]
+	return dialect, ok
+}
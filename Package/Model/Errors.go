@@ -0,0 +1,133 @@
+package Model
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// FieldViolation describes one invalid field of a request.
+type FieldViolation struct {
+	Field   string
+	Message string
+}
+
+// ValidationError carries every field violation found in a request.
+type ValidationError struct {
+	Violations []FieldViolation
+}
+
+func (Err *ValidationError) Error() string {
+	messages := []string{}
+	for _, violation := range Err.Violations {
+		messages = append(messages, violation.Field+": "+violation.Message)
+	}
+	return "Validation Failed: " + strings.Join(messages, ", ")
+}
+
+func (Err *ValidationError) Add(Field string, Message string) {
+	Err.Violations = append(Err.Violations, FieldViolation{Field: Field, Message: Message})
+}
+
+// Join returns the violations of Err followed by those of Other. Either may
+// be nil.
+func (Err *ValidationError) Join(Other *ValidationError) *ValidationError {
+	joined := &ValidationError{}
+	if Err != nil {
+		joined.Violations = append(joined.Violations, Err.Violations...)
+	}
+	if Other != nil {
+		joined.Violations = append(joined.Violations, Other.Violations...)
+	}
+	return joined
+}
+
+// OrNil returns Err as an error only when it holds violations.
+func (Err *ValidationError) OrNil() error {
+	if Err == nil || len(Err.Violations) <= 0 {
+		return nil
+	}
+	return Err
+}
+
+// NotFoundError reports a missing Resource. Two NotFoundErrors match with
+// errors.Is when their Resource matches, so ErrTaskNotFound matches any task.
+type NotFoundError struct {
+	Resource string
+	ID       int64
+}
+
+func (Err *NotFoundError) Error() string {
+	if Err.ID > 0 {
+		return fmt.Sprintf("%s %d Not Found", Err.Resource, Err.ID)
+	}
+	return Err.Resource + " Not Found"
+}
+
+func (Err *NotFoundError) Is(Target error) bool {
+	target, ok := Target.(*NotFoundError)
+	return ok && target.Resource == Err.Resource
+}
+
+// ConflictError reports a write that clashes with the current state of the store.
+type ConflictError struct {
+	Message string
+	Err     error
+}
+
+func (Err *ConflictError) Error() string {
+	return "Conflict: " + Err.Message
+}
+
+func (Err *ConflictError) Unwrap() error {
+	return Err.Err
+}
+
+// VersionConflictError reports a write made against a Version of the task
+// that is no longer the stored one.
+type VersionConflictError struct {
+	ID      int64
+	Version int64
+}
+
+func (Err *VersionConflictError) Error() string {
+	return fmt.Sprintf("Task %d has changed since version %d", Err.ID, Err.Version)
+}
+
+// UnavailableError reports that the store could not be reached or is overloaded.
+type UnavailableError struct {
+	Err error
+}
+
+func (Err *UnavailableError) Error() string {
+	return "Storage Unavailable: " + Err.Err.Error()
+}
+
+func (Err *UnavailableError) Unwrap() error {
+	return Err.Err
+}
+
+var ErrTaskNotFound = &NotFoundError{Resource: "Task"}
+
+func taskNotFound(ID int64) error {
+	return &NotFoundError{Resource: "Task", ID: ID}
+}
+
+// ErrTrashedTaskNotFound matches a task missing from the trash, either
+// because it does not exist or because it was never deleted.
+var ErrTrashedTaskNotFound = &NotFoundError{Resource: "Trashed Task"}
+
+func trashedTaskNotFound(ID int64) error {
+	return &NotFoundError{Resource: "Trashed Task", ID: ID}
+}
+
+// classifyConnError recognises connection failures common to every driver.
+func classifyConnError(Err error) error {
+	var netErr net.Error
+	if errors.Is(Err, driver.ErrBadConn) || errors.As(Err, &netErr) {
+		return &UnavailableError{Err: Err}
+	}
+	return Err
+}
@@ -0,0 +1,284 @@
+package Model
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// Every write of a task appends a HistoryEntry in the transaction of the
+// write, so a change and its record commit or roll back together. Nothing
+// updates or deletes TaskHistory: purging a task keeps its timeline.
+
+const (
+	ActionCreated      string = "created"
+	ActionUpdated      string = "updated"
+	ActionTransitioned string = "transitioned"
+	ActionDeleted      string = "deleted"
+	ActionRestored     string = "restored"
+	ActionReverted     string = "reverted"
+	ActionPurged       string = "purged"
+)
+
+// SystemActor is recorded for changes made without an Auditor, such as the
+// trash purge job.
+const SystemActor string = "system"
+
+// Auditor says who is behind the changes made with a context and which
+// request carried them.
+type Auditor struct {
+	Actor      string
+	Request_ID string
+}
+
+type auditorKey struct{}
+
+// WithAuditor returns a copy of Ctx whose writes are recorded as Auditor's.
+func WithAuditor(Ctx context.Context, Auditor Auditor) context.Context {
+	return context.WithValue(Ctx, auditorKey{}, Auditor)
+}
+
+// AuditorFrom returns the Auditor of Ctx, SystemActor when there is none.
+func AuditorFrom(Ctx context.Context) Auditor {
+	auditor, _ := Ctx.Value(auditorKey{}).(Auditor)
+	if len(auditor.Actor) <= 0 {
+		auditor.Actor = SystemActor
+	}
+	return auditor
+}
+
+// FieldChange is the value of a field before and after a change. Before is
+// nil when the task was created and After when it was purged.
+type FieldChange struct {
+	Before any
+	After  any
+}
+
+// HistoryEntry is one change of a task. Version is the version the change
+// produced, or the last one the task had when it was purged.
+type HistoryEntry struct {
+	ID         int64
+	Task_ID    int64
+	Action     string
+	Version    int64
+	Actor      string
+	Request_ID string
+	Changes    map[string]FieldChange
+	Changed_At time.Time
+}
+
+// historyFields snapshots the fields of Task a HistoryEntry tracks.
+func historyFields(Task *TaskStoreResponse) map[string]any {
+	if Task == nil {
+		return map[string]any{}
+	}
+	return map[string]any{
+		"Title":            Task.Task.Title,
+		"Task_Description": Task.Task.Task_Description,
+		"Task_Status":      Task.Task.Task_Status,
+		"Workflow_Status":  Task.Workflow_Status,
+	}
+}
+
+// diffTasks lists the fields that differ between Before and After, either of
+// which is nil when the task did not exist on that side.
+func diffTasks(Before *TaskStoreResponse, After *TaskStoreResponse) map[string]FieldChange {
+	before, after := historyFields(Before), historyFields(After)
+	changes := map[string]FieldChange{}
+
+	for _, fields := range []map[string]any{before, after} {
+		for field := range fields {
+			if before[field] != after[field] {
+				changes[field] = FieldChange{Before: before[field], After: after[field]}
+			}
+		}
+	}
+
+	return changes
+}
+
+// newHistoryEntry describes the change of a task from Before to After made
+// with Ctx. It leaves ID and Changed_At to the store.
+func newHistoryEntry(Ctx context.Context, Action string, Before *TaskStoreResponse, After *TaskStoreResponse) HistoryEntry {
+	auditor := AuditorFrom(Ctx)
+	entry := HistoryEntry{
+		Action:     Action,
+		Actor:      auditor.Actor,
+		Request_ID: auditor.Request_ID,
+		Changes:    diffTasks(Before, After),
+	}
+
+	for _, task := range []*TaskStoreResponse{Before, After} {
+		if task != nil {
+			entry.Task_ID = task.ID
+			entry.Version = task.Version
+		}
+	}
+
+	return entry
+}
+
+// AddHistoryQuery inserts the rows valuesQuery lists.
+const AddHistoryQuery string = `
+INSERT INTO TaskHistory (
+  Task_ID, Action, Version, Actor, Request_ID, Changes
+) VALUES %s
+;
+`
+
+const TaskHistoryQuery string = `
+SELECT ID , Task_ID , Action , Version , Actor , Request_ID , Changes , Changed_At FROM TaskHistory
+WHERE Task_ID = ?
+ORDER BY ID
+LIMIT ? OFFSET ?
+;
+`
+
+const CountTaskHistoryQuery string = `
+SELECT COUNT(*) FROM TaskHistory
+WHERE Task_ID = ?
+;
+`
+
+// TaskExistsQuery counts task ID whether it is active or in the trash.
+const TaskExistsQuery string = `
+SELECT COUNT(*) FROM TaskStore
+WHERE ID = ?
+;
+`
+
+// ExpiredTrashQuery reads the tasks PurgeTrashQuery is about to delete.
+const ExpiredTrashQuery string = `
+SELECT ` + TaskColumns + ` FROM TaskStore
+WHERE Deleted_At < ?
+;
+`
+
+// record appends the change of a task from Before to After to TaskHistory
+// inside Tx. It keeps After as a revision, or drops the revisions of a task
+// that is gone for good.
+func (Model *ModelStruct) record(Ctx context.Context, Tx *sql.Tx, Action string, Before *TaskStoreResponse, After *TaskStoreResponse) error {
+	return Model.recordAll(Ctx, Tx, Action, []*TaskStoreResponse{Before}, []*TaskStoreResponse{After})
+}
+
+// recordAll records the changes of several tasks, Before[i] to After[i],
+// with one statement per table.
+func (Model *ModelStruct) recordAll(Ctx context.Context, Tx *sql.Tx, Action string, Before []*TaskStoreResponse, After []*TaskStoreResponse) error {
+	entries := []any{}
+	revised := []TaskStoreResponse{}
+
+	for index := range Before {
+		entry := newHistoryEntry(Ctx, Action, Before[index], After[index])
+
+		changes, err := json.Marshal(entry.Changes)
+
+		if err != nil {
+			return err
+		}
+
+		entries = append(entries, entry.Task_ID, entry.Action, entry.Version, entry.Actor, entry.Request_ID, string(changes))
+
+		if After[index] == nil {
+			_, err = Tx.ExecContext(Ctx, Model.Dialect.PurgeRevisionsQuery, entry.Task_ID)
+
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		revised = append(revised, *After[index])
+	}
+
+	_, err := Tx.ExecContext(Ctx, Model.Dialect.AddHistoryQuery(len(Before)), entries...)
+
+	if err != nil {
+		return err
+	}
+
+	return Model.revise(Ctx, Tx, revised)
+}
+
+// TaskHistory returns a page of the timeline of a task, oldest change first.
+// It keeps working after the task has been purged.
+func (Model *ModelStruct) TaskHistory(Ctx context.Context, Task TaskHistoryStore) (TaskHistoryPage, error) {
+
+	err := Model.ValidateParamGetTask(GetTask{ID: Task.ID})
+
+	if err != nil {
+		return TaskHistoryPage{}, err
+	}
+
+	if Task.Limit < 1 || Task.Page < 1 {
+		Task.Limit = 10
+		Task.Page = 1
+	}
+
+	var page TaskHistoryPage
+
+	err = Model.transact(Ctx, func(db *sql.Tx) error {
+		var err error
+		page, err = Model.readHistory(Ctx, db, Task)
+		return err
+	})
+
+	if err != nil {
+		return TaskHistoryPage{}, err
+	}
+
+	return page, nil
+}
+
+func (Model *ModelStruct) readHistory(Ctx context.Context, Tx *sql.Tx, Task TaskHistoryStore) (TaskHistoryPage, error) {
+	page := TaskHistoryPage{Items: []HistoryEntry{}}
+
+	err := Tx.QueryRowContext(Ctx, Model.Dialect.CountTaskHistoryQuery, Task.ID).Scan(&page.Total_Count)
+
+	if err != nil {
+		return TaskHistoryPage{}, err
+	}
+
+	if page.Total_Count <= 0 {
+		// Tasks written before TaskHistory existed have an empty timeline.
+		var tasks int64
+		err = Tx.QueryRowContext(Ctx, Model.Dialect.TaskExistsQuery, Task.ID).Scan(&tasks)
+
+		if err != nil {
+			return TaskHistoryPage{}, err
+		}
+
+		if tasks <= 0 {
+			return TaskHistoryPage{}, taskNotFound(Task.ID)
+		}
+
+		return page, nil
+	}
+
+	resp, err := Tx.QueryContext(Ctx, Model.Dialect.TaskHistoryQuery, Task.ID, Task.Limit, (Task.Page-1)*Task.Limit)
+
+	if err != nil {
+		return TaskHistoryPage{}, err
+	}
+
+	defer resp.Close()
+
+	for resp.Next() {
+		var entry HistoryEntry
+		var changes string
+
+		err := resp.Scan(&entry.ID, &entry.Task_ID, &entry.Action, &entry.Version, &entry.Actor, &entry.Request_ID, &changes, sqlTimestamp{Time: &entry.Changed_At})
+		if err != nil {
+			return TaskHistoryPage{}, err
+		}
+
+		err = json.Unmarshal([]byte(changes), &entry.Changes)
+		if err != nil {
+			return TaskHistoryPage{}, err
+		}
+
+		page.Items = append(page.Items, entry)
+	}
+
+	return page, resp.Err()
+}
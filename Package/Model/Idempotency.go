@@ -0,0 +1,464 @@
+package Model
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// A request that carries an Idempotency-Key reserves the key before it runs
+// and stores its response under the key once it is done, so a retry of the
+// request gets that response back instead of running again. Keys live in
+// the IdempotencyKey table, next to the tasks they protect, for
+// IdempotencyWindow.
+
+// DefaultIdempotencyWindow is how long a stored response is replayed when
+// the model is not given another window.
+const DefaultIdempotencyWindow time.Duration = 24 * time.Hour
+
+// IdempotencyLockTimeout is how long a reservation whose response was never
+// stored holds its key. After that the request is assumed lost and a retry
+// may run again.
+const IdempotencyLockTimeout time.Duration = time.Minute
+
+// IdempotencyPurgeInterval is how often PurgeIdempotencyKeysEvery looks for
+// expired keys.
+const IdempotencyPurgeInterval time.Duration = time.Hour
+
+// MaxIdempotencyKeyLength is the longest Idempotency-Key the store accepts.
+const MaxIdempotencyKeyLength int = 255
+
+// IdempotencyKeyStore names a key and the request it is used for.
+// Request_Hash is a digest of everything that makes two requests the same.
+type IdempotencyKeyStore struct {
+	Key          string
+	Request_Hash string
+}
+
+// IdempotencyRecord is what the store holds for a key. Status is zero while
+// the request that reserved the key is still running.
+type IdempotencyRecord struct {
+	Key          string
+	Request_Hash string
+	Status       int
+	Headers      map[string][]string
+	Body         []byte
+	Created_At   time.Time
+	// Reserved is true when the caller now holds the key and must complete
+	// or release it.
+	Reserved bool
+}
+
+type PurgeIdempotencyKeyStore struct {
+	Before time.Time
+}
+
+// IdempotencyKeyReusedError reports a key already used for a request with a
+// different method, path or body.
+type IdempotencyKeyReusedError struct {
+	Key string
+}
+
+func (Err *IdempotencyKeyReusedError) Error() string {
+	return fmt.Sprintf("Idempotency-Key %q was already used for a different request", Err.Key)
+}
+
+// IdempotencyKeyInUseError reports a key whose first request is still
+// running.
+type IdempotencyKeyInUseError struct {
+	Key string
+}
+
+func (Err *IdempotencyKeyInUseError) Error() string {
+	return fmt.Sprintf("A request with Idempotency-Key %q is still in progress", Err.Key)
+}
+
+// ErrIdempotencyKeyLost matches a reservation that expired or was taken over
+// before its response was stored.
+var ErrIdempotencyKeyLost = errors.New("Idempotency-Key reservation was lost before the response was stored")
+
+// live reports whether Record still holds its key at Now.
+func (Record IdempotencyRecord) live(Now time.Time, Window time.Duration) bool {
+	if Record.Created_At.Before(Now.Add(-Window)) {
+		return false
+	}
+	return Record.Status > 0 || !Record.Created_At.Before(Now.Add(-IdempotencyLockTimeout))
+}
+
+// claim decides what a request for Task gets when Existing holds the key.
+func (Existing IdempotencyRecord) claim(Task IdempotencyKeyStore) (IdempotencyRecord, error) {
+	if Existing.Request_Hash != Task.Request_Hash {
+		return IdempotencyRecord{}, &IdempotencyKeyReusedError{Key: Task.Key}
+	}
+
+	if Existing.Status <= 0 {
+		return IdempotencyRecord{}, &IdempotencyKeyInUseError{Key: Task.Key}
+	}
+
+	return Existing, nil
+}
+
+// SetIdempotencyWindow changes IdempotencyWindow while requests are served.
+// Reads of the field in the model go through idempotencyWindowNow.
+func (Model *ModelStruct) SetIdempotencyWindow(Window time.Duration) {
+	atomic.StoreInt64((*int64)(&Model.IdempotencyWindow), int64(Window))
+}
+
+func (Model *ModelStruct) idempotencyWindowNow() time.Duration {
+	return idempotencyWindow(time.Duration(atomic.LoadInt64((*int64)(&Model.IdempotencyWindow))))
+}
+
+// SetIdempotencyWindow changes IdempotencyWindow while requests are served.
+func (Model *MemoryModelStruct) SetIdempotencyWindow(Window time.Duration) {
+	Model.mutex.Lock()
+	defer Model.mutex.Unlock()
+
+	Model.IdempotencyWindow = Window
+}
+
+func idempotencyWindow(Window time.Duration) time.Duration {
+	if Window <= 0 {
+		return DefaultIdempotencyWindow
+	}
+	return Window
+}
+
+const IdempotencyKeyQuery string = `
+SELECT Idempotency_Key , Request_Hash , Status , Headers , Body , Created_At FROM IdempotencyKey
+WHERE Idempotency_Key = ?
+;
+`
+
+const DropIdempotencyKeyQuery string = `
+DELETE FROM IdempotencyKey
+WHERE Idempotency_Key = ?
+;
+`
+
+const ReserveIdempotencyKeyQuery string = `
+INSERT INTO IdempotencyKey (
+  Idempotency_Key, Request_Hash, Status, Headers, Body, Created_At
+) VALUES (
+  ?, ?, 0, '{}', ?, ?
+)
+;
+`
+
+const CompleteIdempotencyKeyQuery string = `
+UPDATE IdempotencyKey
+SET Status = ? , Headers = ? , Body = ?
+WHERE Idempotency_Key = ? AND Request_Hash = ? AND Status = 0
+;
+`
+
+const ReleaseIdempotencyKeyQuery string = `
+DELETE FROM IdempotencyKey
+WHERE Idempotency_Key = ? AND Request_Hash = ? AND Status = 0
+;
+`
+
+const PurgeIdempotencyKeysQuery string = `
+DELETE FROM IdempotencyKey
+WHERE Created_At < ?
+;
+`
+
+// ReserveIdempotencyKey hands back the stored response of Task.Key, or
+// reserves the key for Task when nothing live holds it.
+func (Model *ModelStruct) ReserveIdempotencyKey(Ctx context.Context, Task IdempotencyKeyStore) (IdempotencyRecord, error) {
+
+	err := validateIdempotencyKey(Task).OrNil()
+
+	if err != nil {
+		return IdempotencyRecord{}, err
+	}
+
+	var record IdempotencyRecord
+
+	err = Model.transact(Ctx, func(db *sql.Tx) error {
+		now := time.Now().UTC().Truncate(time.Second)
+
+		existing, err := Model.readIdempotencyKey(Ctx, db, Task.Key)
+
+		if err == nil && existing.live(now, Model.idempotencyWindowNow()) {
+			record, err = existing.claim(Task)
+			return err
+		}
+
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		_, err = db.ExecContext(Ctx, Model.Dialect.DropIdempotencyKeyQuery, Task.Key)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(Ctx, Model.Dialect.ReserveIdempotencyKeyQuery, Task.Key, Task.Request_Hash, []byte{}, sqlTime(now))
+
+		if err != nil {
+			return err
+		}
+
+		record = IdempotencyRecord{
+			Key:          Task.Key,
+			Request_Hash: Task.Request_Hash,
+			Created_At:   now,
+			Reserved:     true,
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return IdempotencyRecord{}, err
+	}
+
+	return record, nil
+}
+
+func (Model *ModelStruct) readIdempotencyKey(Ctx context.Context, Tx *sql.Tx, Key string) (IdempotencyRecord, error) {
+	var record IdempotencyRecord
+	var headers string
+
+	err := Tx.QueryRowContext(Ctx, Model.Dialect.IdempotencyKeyQuery, Key).Scan(
+		&record.Key,
+		&record.Request_Hash,
+		&record.Status,
+		&headers,
+		&record.Body,
+		sqlTimestamp{Time: &record.Created_At},
+	)
+
+	if err != nil {
+		return IdempotencyRecord{}, err
+	}
+
+	err = json.Unmarshal([]byte(headers), &record.Headers)
+
+	return record, err
+}
+
+// CompleteIdempotencyKey stores the response of the request holding
+// Task.Key. It fails with ErrIdempotencyKeyLost when the request no longer
+// holds the key.
+func (Model *ModelStruct) CompleteIdempotencyKey(Ctx context.Context, Task IdempotencyRecord) error {
+
+	err := validateCompleteIdempotencyKey(Task).OrNil()
+
+	if err != nil {
+		return err
+	}
+
+	headers, err := json.Marshal(Task.Headers)
+
+	if err != nil {
+		return err
+	}
+
+	return Model.transact(Ctx, func(db *sql.Tx) error {
+		resp, err := db.ExecContext(Ctx, Model.Dialect.CompleteIdempotencyKeyQuery, Task.Status, string(headers), Task.Body, Task.Key, Task.Request_Hash)
+
+		if err != nil {
+			return err
+		}
+
+		numRowAffected, err := resp.RowsAffected()
+
+		if err != nil {
+			return err
+		}
+
+		if numRowAffected != 1 {
+			return ErrIdempotencyKeyLost
+		}
+
+		return nil
+	})
+}
+
+// ReleaseIdempotencyKey gives up the reservation of Task.Key without storing
+// a response, so a retry runs the request again.
+func (Model *ModelStruct) ReleaseIdempotencyKey(Ctx context.Context, Task IdempotencyKeyStore) error {
+
+	err := validateIdempotencyKey(Task).OrNil()
+
+	if err != nil {
+		return err
+	}
+
+	return Model.transact(Ctx, func(db *sql.Tx) error {
+		_, err := db.ExecContext(Ctx, Model.Dialect.ReleaseIdempotencyKeyQuery, Task.Key, Task.Request_Hash)
+		return err
+	})
+}
+
+// PurgeIdempotencyKeys deletes every key reserved before Task.Before and
+// returns how many there were.
+func (Model *ModelStruct) PurgeIdempotencyKeys(Ctx context.Context, Task PurgeIdempotencyKeyStore) (int64, error) {
+
+	err := validatePurgeIdempotencyKeys(Task).OrNil()
+
+	if err != nil {
+		return 0, err
+	}
+
+	var purged int64
+
+	err = Model.transact(Ctx, func(db *sql.Tx) error {
+		resp, err := db.ExecContext(Ctx, Model.Dialect.PurgeIdempotencyKeysQuery, sqlTime(Task.Before))
+
+		if err != nil {
+			return err
+		}
+
+		purged, err = resp.RowsAffected()
+
+		return err
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+// PurgeIdempotencyKeysEvery deletes the keys older than Window, right away
+// and then every Interval, until Ctx is done. Failures are logged and
+// retried on the next run.
+func PurgeIdempotencyKeysEvery(Ctx context.Context, Store ModelInterface, Window time.Duration, Interval time.Duration) {
+	ticker := time.NewTicker(Interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := Store.PurgeIdempotencyKeys(Ctx, PurgeIdempotencyKeyStore{Before: time.Now().Add(-idempotencyWindow(Window))})
+
+		if err != nil {
+			slog.Error("purging idempotency keys failed", "error", err)
+		} else if purged > 0 {
+			slog.Info("purged idempotency keys", "keys", purged, "window", idempotencyWindow(Window))
+		}
+
+		select {
+		case <-Ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (Model *MemoryModelStruct) ReserveIdempotencyKey(Ctx context.Context, Task IdempotencyKeyStore) (IdempotencyRecord, error) {
+	if err := Ctx.Err(); err != nil {
+		return IdempotencyRecord{}, err
+	}
+
+	err := validateIdempotencyKey(Task).OrNil()
+
+	if err != nil {
+		return IdempotencyRecord{}, err
+	}
+
+	Model.mutex.Lock()
+	defer Model.mutex.Unlock()
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	existing, ok := Model.idempotency[Task.Key]
+	if ok && existing.live(now, idempotencyWindow(Model.IdempotencyWindow)) {
+		return existing.claim(Task)
+	}
+
+	Model.idempotency[Task.Key] = IdempotencyRecord{
+		Key:          Task.Key,
+		Request_Hash: Task.Request_Hash,
+		Created_At:   now,
+	}
+
+	return IdempotencyRecord{
+		Key:          Task.Key,
+		Request_Hash: Task.Request_Hash,
+		Created_At:   now,
+		Reserved:     true,
+	}, nil
+}
+
+func (Model *MemoryModelStruct) CompleteIdempotencyKey(Ctx context.Context, Task IdempotencyRecord) error {
+	if err := Ctx.Err(); err != nil {
+		return err
+	}
+
+	err := validateCompleteIdempotencyKey(Task).OrNil()
+
+	if err != nil {
+		return err
+	}
+
+	Model.mutex.Lock()
+	defer Model.mutex.Unlock()
+
+	existing, ok := Model.idempotency[Task.Key]
+	if !ok || existing.Request_Hash != Task.Request_Hash || existing.Status > 0 {
+		return ErrIdempotencyKeyLost
+	}
+
+	existing.Status = Task.Status
+	existing.Headers = Task.Headers
+	existing.Body = Task.Body
+	Model.idempotency[Task.Key] = existing
+
+	return nil
+}
+
+func (Model *MemoryModelStruct) ReleaseIdempotencyKey(Ctx context.Context, Task IdempotencyKeyStore) error {
+	if err := Ctx.Err(); err != nil {
+		return err
+	}
+
+	err := validateIdempotencyKey(Task).OrNil()
+
+	if err != nil {
+		return err
+	}
+
+	Model.mutex.Lock()
+	defer Model.mutex.Unlock()
+
+	existing, ok := Model.idempotency[Task.Key]
+	if ok && existing.Request_Hash == Task.Request_Hash && existing.Status <= 0 {
+		delete(Model.idempotency, Task.Key)
+	}
+
+	return nil
+}
+
+func (Model *MemoryModelStruct) PurgeIdempotencyKeys(Ctx context.Context, Task PurgeIdempotencyKeyStore) (int64, error) {
+	if err := Ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	err := validatePurgeIdempotencyKeys(Task).OrNil()
+
+	if err != nil {
+		return 0, err
+	}
+
+	Model.mutex.Lock()
+	defer Model.mutex.Unlock()
+
+	purged := int64(0)
+	for key, record := range Model.idempotency {
+		if record.Created_At.Before(Task.Before) {
+			delete(Model.idempotency, key)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
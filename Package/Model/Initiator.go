@@ -5,478 +5,899 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"sync"
+	"fmt"
 	"time"
 )
 
 type ModelInterface interface {
-	AddTask(Task TaskStoreRequest, Wg *sync.WaitGroup, ResultChannel chan<- TaskStoreResponse, ErrorChannel chan<- error)
-	GetTask(Task GetTask, Wg *sync.WaitGroup, ResultChannel chan<- TaskStoreResponse, ErrorChannel chan<- error)
-	EditTask(Task UpdateTaskStoreRequest, Wg *sync.WaitGroup, ResultChannel chan<- TaskStoreResponse, ErrorChannel chan<- error)
-	DeleteTask(Task DeleteTaskStoreRequest, Wg *sync.WaitGroup, ResultChannel chan<- DeleteTaskStoreResponse, ErrorChannel chan<- error)
-	ListTask(Task ListTaskStore) ([]TaskStoreResponse, error)
+	AddTask(Ctx context.Context, Task TaskStoreRequest) (TaskStoreResponse, error)
+	GetTask(Ctx context.Context, Task GetTask) (TaskStoreResponse, error)
+	EditTask(Ctx context.Context, Task UpdateTaskStoreRequest) (TaskStoreResponse, error)
+	PatchTask(Ctx context.Context, Task PatchTaskStoreRequest) (TaskStoreResponse, error)
+	RevertTask(Ctx context.Context, Task RevertTaskStoreRequest) (TaskStoreResponse, error)
+	TransitionTask(Ctx context.Context, Task TransitionTaskStoreRequest) (TaskStoreResponse, error)
+	TaskWorkflow() Workflow
+	DeleteTask(Ctx context.Context, Task DeleteTaskStoreRequest) (DeleteTaskStoreResponse, error)
+	BulkCreate(Ctx context.Context, Task BulkCreateStore) (BulkResult, error)
+	BulkUpdate(Ctx context.Context, Task BulkUpdateStore) (BulkResult, error)
+	BulkDelete(Ctx context.Context, Task BulkDeleteStore) (BulkResult, error)
+	ListTask(Ctx context.Context, Task ListTaskStore) ([]TaskStoreResponse, error)
+	PageTask(Ctx context.Context, Task ListTaskStore) (ListTaskPage, error)
+	SearchTask(Ctx context.Context, Task SearchTaskStore) (SearchTaskPage, error)
+	GetTrashedTask(Ctx context.Context, Task GetTask) (TaskStoreResponse, error)
+	RestoreTask(Ctx context.Context, Task TrashTaskStoreRequest) (TaskStoreResponse, error)
+	PurgeTask(Ctx context.Context, Task TrashTaskStoreRequest) error
+	PurgeTrash(Ctx context.Context, Task PurgeTrashStore) (int64, error)
+	TaskHistory(Ctx context.Context, Task TaskHistoryStore) (TaskHistoryPage, error)
+	ReserveIdempotencyKey(Ctx context.Context, Task IdempotencyKeyStore) (IdempotencyRecord, error)
+	CompleteIdempotencyKey(Ctx context.Context, Task IdempotencyRecord) error
+	ReleaseIdempotencyKey(Ctx context.Context, Task IdempotencyKeyStore) error
+	PurgeIdempotencyKeys(Ctx context.Context, Task PurgeIdempotencyKeyStore) (int64, error)
 }
 
+var _ ModelInterface = (*ModelStruct)(nil)
+
 type ModelStruct struct {
-	Config   Configurator.ConfiguratorStruct
-	TxOption sql.TxOptions
+	Config       Configurator.ConfiguratorStruct
+	TxOption     sql.TxOptions
+	Dialect      Dialect
+	PageTokenKey []byte
+	// SearchIndex serves SearchTask when the Dialect has no full-text query.
+	SearchIndex *SearchIndex
+	Workflow    Workflow
+	// IdempotencyWindow is how long responses stored under an
+	// Idempotency-Key are replayed, DefaultIdempotencyWindow when zero.
+	IdempotencyWindow time.Duration
+	// Retry says how transact runs transactions that lost a conflict again.
+	Retry RetryPolicy
 }
 
-func NewModel(Configuration Configurator.ConfiguratorStruct) ModelStruct {
+// NewModel returns a model on the database of Configuration. It fails for a
+// driver without a Dialect rather than sending it the SQL of another one.
+func NewModel(Configuration Configurator.ConfiguratorStruct) (ModelStruct, error) {
 	txOption := sql.TxOptions{
 		Isolation: sql.LevelSerializable,
 	}
+
+	dialect, ok := DialectFor(Configuration.DbDriver)
+	if !ok {
+		return ModelStruct{}, fmt.Errorf("Unsupported DbDriver %q, no SQL dialect for it", Configuration.DbDriver)
+	}
+
 	return ModelStruct{
-		Config:   Configuration,
-		TxOption: txOption,
+		Config:       Configuration,
+		TxOption:     txOption,
+		Dialect:      dialect,
+		PageTokenKey: NewPageTokenKey(Configuration.PageTokenSecret),
+		SearchIndex:  NewSearchIndex(),
+		Workflow:     DefaultWorkflow,
+
+		IdempotencyWindow: DefaultIdempotencyWindow,
+		Retry:             DefaultRetryPolicy,
+	}, nil
+}
+
+// classify turns driver errors into the package's typed errors.
+func (Model *ModelStruct) classify(Err error) error {
+	if Model.Dialect.ClassifyError == nil {
+		return classifyConnError(Err)
 	}
+	return Model.Dialect.ClassifyError(Err)
+}
+
+// TaskColumns are the columns scanTask reads, in order.
+const TaskColumns string = "ID , Title , Task_Description , Task_Status , Version , Workflow_Status"
+
+// scanTask reads a row of TaskColumns from a *sql.Row or *sql.Rows.
+func scanTask(Row interface{ Scan(Dest ...any) error }) (TaskStoreResponse, error) {
+	task := TaskStoreResponse{}
+
+	err := Row.Scan(
+		&task.ID,
+		&task.Task.Title,
+		&task.Task.Task_Description,
+		&task.Task.Task_Status,
+		&task.Version,
+		&task.Workflow_Status,
+	)
+
+	return task, err
+}
+
+// checkVersion reads active task ID inside Tx and fails with a
+// VersionConflictError when Expected is set and no longer matches it.
+func (Model *ModelStruct) checkVersion(Ctx context.Context, Tx *sql.Tx, ID int64, Expected int64) (TaskStoreResponse, error) {
+	task, err := scanTask(Tx.QueryRowContext(Ctx, Model.Dialect.GetTaskQuery, ID))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return TaskStoreResponse{}, taskNotFound(ID)
+	}
+
+	if err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	if Expected > 0 && Expected != task.Version {
+		return TaskStoreResponse{}, &VersionConflictError{ID: ID, Version: Expected}
+	}
+
+	return task, nil
 }
 
 const AddTaskQuery string = `
 INSERT INTO TaskStore (
-  Title, Task_Description
+  Title, Task_Description, Task_Status, Workflow_Status
 ) VALUES (
-  ? , ? 
+  ? , ? , ? , ?
 )
 ;
 `
 
-func (Model *ModelStruct) AddTask(Task TaskStoreRequest, Wg *sync.WaitGroup, ResultChannel chan<- TaskStoreResponse, ErrorChannel chan<- error) {
+func (Model *ModelStruct) AddTask(Ctx context.Context, Task TaskStoreRequest) (TaskStoreResponse, error) {
 
-	defer Wg.Done()
+	err := Model.ValidateParamAddTask(Task)
 
-	isValid, errorMessage := Model.ValidateParamAddTask(Task)
-
-	if isValid == true {
-		errorObj := errors.New(errorMessage)
-		ErrorChannel <- errorObj
-		return
+	if err != nil {
+		return TaskStoreResponse{}, err
 	}
 
-	ctx := context.WithoutCancel(context.Background())
+	var resp TaskStoreResponse
+
+	err = Model.transact(Ctx, func(db *sql.Tx) error {
+		res, err := db.ExecContext(Ctx, Model.Dialect.AddTaskQuery, Task.Title, Task.Task_Description, Task.Task_Status, Model.Workflow.Initial)
+
+		if err != nil {
+			return err
+		}
+
+		taskID, err := res.LastInsertId()
+
+		if err != nil {
+			return err
+		}
+
+		resp = TaskStoreResponse{
+			ID:              taskID,
+			Version:         1,
+			Workflow_Status: Model.Workflow.Initial,
+			Task:            Task,
+		}
 
-	db, err := Model.Config.SqlDBConn.BeginTx(ctx, &Model.TxOption)
+		return Model.record(Ctx, db, ActionCreated, nil, &resp)
+	})
 
 	if err != nil {
-		ErrorChannel <- err
-		return
+		return TaskStoreResponse{}, err
 	}
 
-	res, err := db.ExecContext(ctx, AddTaskQuery, Task.Title, Task.Task_Description)
+	return resp, nil
+}
+
+func (Model *ModelStruct) ValidateParamAddTask(Task TaskStoreRequest) error {
+	return validateAddTask(Task).OrNil()
+}
+
+const EditTaskQuery string = `
+UPDATE TaskStore 
+SET Title = ? , Task_Description = ? , Task_Status = ? , Edited_On = CURRENT_TIMESTAMP() , Version = Version + 1
+WHERE ID = ? AND Version = ?
+;
+`
+
+func (Model *ModelStruct) ValidateParamEditTask(Task UpdateTaskStoreRequest) error {
+	return validateEditTask(Task).OrNil()
+}
+
+func (Model *ModelStruct) EditTask(Ctx context.Context, Task UpdateTaskStoreRequest) (TaskStoreResponse, error) {
+
+	err := Model.ValidateParamEditTask(Task)
 
 	if err != nil {
-		nerr := db.Rollback()
-		if nerr != nil {
-			ErrorChannel <- nerr
-			return
-		} else {
-			ErrorChannel <- err
-			return
-		}
+		return TaskStoreResponse{}, err
 	}
 
-	taskID, err := res.LastInsertId()
+	var reslt TaskStoreResponse
 
-	if err != nil {
-		nerr := db.Rollback()
-		if nerr != nil {
-			ErrorChannel <- nerr
-			return
-		} else {
-			ErrorChannel <- err
-			return
+	err = Model.transact(Ctx, func(db *sql.Tx) error {
+		current, err := Model.checkVersion(Ctx, db, Task.ID, Task.Version)
+
+		if err != nil {
+			return err
 		}
-	}
 
-	err = db.Commit()
+		resp, err := db.ExecContext(Ctx, Model.Dialect.EditTaskQuery, Task.Task.Title, Task.Task.Task_Description, Task.Task.Task_Status, Task.ID, current.Version)
 
-	if err != nil {
-		nerr := db.Rollback()
-		if nerr != nil {
-			ErrorChannel <- nerr
-			return
-		} else {
-			ErrorChannel <- err
-			return
+		if err != nil {
+			return err
 		}
-	}
 
-	resp := TaskStoreResponse{
-		ID:   taskID,
-		Task: Task,
-	}
+		numRowAffected, err := resp.RowsAffected()
 
-	ResultChannel <- resp
-	return
+		if err != nil {
+			return err
+		}
 
-}
+		if numRowAffected > 1 || numRowAffected <= 0 {
+			return &VersionConflictError{ID: Task.ID, Version: current.Version}
+		}
 
-func (Model *ModelStruct) ValidateParamAddTask(Task TaskStoreRequest) (bool, string) {
-	errorMessages := []string{}
-	var isValid bool = false
-	if Task.Task_Status != true {
-		isValid = true
-		errorMessages = append(errorMessages, "Invalid Task Status")
+		reslt = TaskStoreResponse{
+			ID:              Task.ID,
+			Version:         current.Version + 1,
+			Workflow_Status: current.Workflow_Status,
+			Task:            Task.Task,
+		}
 
+		return Model.record(Ctx, db, ActionUpdated, &current, &reslt)
+	})
+
+	if err != nil {
+		return TaskStoreResponse{}, err
 	}
-	if len(Task.Title) <= 0 {
 
-		isValid = true
-		errorMessages = append(errorMessages, "Invalid Title .")
+	return reslt, nil
+}
 
-	}
+// PatchTask reads the row, applies Task.Patch and writes back only the
+// columns the patch changed, all inside one serializable transaction.
+func (Model *ModelStruct) PatchTask(Ctx context.Context, Task PatchTaskStoreRequest) (TaskStoreResponse, error) {
 
-	if len(Task.Task_Description) <= 0 {
+	err := Model.ValidateParamGetTask(GetTask{ID: Task.ID})
 
-		isValid = true
-		errorMessages = append(errorMessages, "Invalid Description")
+	if err != nil {
+		return TaskStoreResponse{}, err
 	}
 
-	errorMessage := ""
+	var reslt TaskStoreResponse
+
+	err = Model.transact(Ctx, func(db *sql.Tx) error {
+		current, err := Model.checkVersion(Ctx, db, Task.ID, Task.Version)
+
+		if err != nil {
+			return err
+		}
+
+		patched, columns, err := applyTaskPatch(current, Task.Patch)
+
+		if err != nil {
+			return err
+		}
+
+		reslt = TaskStoreResponse{
+			ID:              Task.ID,
+			Version:         current.Version,
+			Workflow_Status: current.Workflow_Status,
+			Task:            patched,
+		}
+
+		if len(columns) <= 0 {
+			return nil
+		}
+
+		names := []string{}
+		args := []any{}
+		for _, column := range columns {
+			names = append(names, column.Name)
+			args = append(args, column.Value(patched))
+		}
+		args = append(args, Task.ID, current.Version)
+
+		resp, err := db.ExecContext(Ctx, Model.Dialect.PatchTaskQuery(names), args...)
 
-	for _, message := range errorMessages {
-		errorMessage = errorMessage + message + " , "
+		if err != nil {
+			return err
+		}
+
+		numRowAffected, err := resp.RowsAffected()
+
+		if err != nil {
+			return err
+		}
+
+		if numRowAffected != 1 {
+			return &VersionConflictError{ID: Task.ID, Version: current.Version}
+		}
+
+		reslt.Version++
+
+		return Model.record(Ctx, db, ActionUpdated, &current, &reslt)
+	})
+
+	if err != nil {
+		return TaskStoreResponse{}, err
 	}
 
-	return isValid, errorMessage
+	return reslt, nil
+}
 
+func (Model *ModelStruct) TaskWorkflow() Workflow {
+	return Model.Workflow
 }
 
-const EditTaskQuery string = `
-UPDATE TaskStore 
-SET Title = ? , Task_Description = ? , Task_Status = ? , Edited_On = CURRENT_TIMESTAMP()
-WHERE ID = ? 
+const TransitionTaskQuery string = `
+UPDATE TaskStore
+SET Workflow_Status = ? , Edited_On = CURRENT_TIMESTAMP() , Version = Version + 1
+WHERE ID = ? AND Version = ?
 ;
 `
 
-func (Model *ModelStruct) ValidateParamEditTask(Task UpdateTaskStoreRequest) (bool, string) {
-	var IsValid bool = false
-	errMessages := []string{}
-	errorMessage := ""
+// TransitionTask moves the task to Task.Workflow_Status when Model.Workflow
+// allows it from the status it is in.
+func (Model *ModelStruct) TransitionTask(Ctx context.Context, Task TransitionTaskStoreRequest) (TaskStoreResponse, error) {
+
+	err := validateTransitionTask(Task, Model.Workflow).OrNil()
 
-	if Task.ID < 1 {
-		IsValid = true
-		errMessages = append(errMessages, "Invalid ID!")
+	if err != nil {
+		return TaskStoreResponse{}, err
 	}
 
-	validity, message := Model.ValidateParamAddTask(Task.Task)
+	var reslt TaskStoreResponse
 
-	if validity == true {
-		IsValid = validity
-		errMessages = append(errMessages, message)
-	}
+	err = Model.transact(Ctx, func(db *sql.Tx) error {
+		current, err := Model.checkVersion(Ctx, db, Task.ID, Task.Version)
+
+		if err != nil {
+			return err
+		}
 
-	for _, message := range errMessages {
-		errorMessage = errorMessage + message + " , "
+		err = Model.Workflow.transition(Task.ID, current.Workflow_Status, Task.Workflow_Status)
+
+		if err != nil {
+			return err
+		}
+
+		resp, err := db.ExecContext(Ctx, Model.Dialect.TransitionTaskQuery, Task.Workflow_Status, Task.ID, current.Version)
+
+		if err != nil {
+			return err
+		}
+
+		numRowAffected, err := resp.RowsAffected()
+
+		if err != nil {
+			return err
+		}
+
+		if numRowAffected != 1 {
+			return &VersionConflictError{ID: Task.ID, Version: current.Version}
+		}
+
+		reslt = current
+		reslt.Version++
+		reslt.Workflow_Status = Task.Workflow_Status
+
+		return Model.record(Ctx, db, ActionTransitioned, &current, &reslt)
+	})
+
+	if err != nil {
+		return TaskStoreResponse{}, err
 	}
 
-	return IsValid, errorMessage
+	return reslt, nil
 }
 
-func (Model *ModelStruct) EditTask(Task UpdateTaskStoreRequest, Wg *sync.WaitGroup, ResultChannel chan<- TaskStoreResponse, ErrorChannel chan<- error) {
-	defer Wg.Done()
-	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*100)
-	defer cancelFunc()
+const DeleteTaskQuery string = `
+UPDATE TaskStore 
+SET Deleted_At = CURRENT_TIMESTAMP() , Edited_On = CURRENT_TIMESTAMP() , Version = Version + 1
+WHERE ID = ? AND Version = ?
+;
+`
+
+func (Model *ModelStruct) DeleteTask(Ctx context.Context, Task DeleteTaskStoreRequest) (DeleteTaskStoreResponse, error) {
 
-	isValid, message := Model.ValidateParamEditTask(Task)
+	err := Model.transact(Ctx, func(db *sql.Tx) error {
+		current, err := Model.checkVersion(Ctx, db, Task.ID, Task.Version)
 
-	if isValid == true {
-		errObj := errors.New(message)
-		ErrorChannel <- errObj
-	}
+		if err != nil {
+			return err
+		}
 
-	db, err := Model.Config.SqlDBConn.BeginTx(ctx, &Model.TxOption)
+		resp, err := db.ExecContext(Ctx, Model.Dialect.DeleteTaskQuery, Task.ID, current.Version)
+
+		if err != nil {
+			return err
+		}
+
+		numRowAffected, err := resp.RowsAffected()
+
+		if err != nil {
+			return err
+		}
+
+		if numRowAffected > 1 || numRowAffected <= 0 {
+			return &VersionConflictError{ID: Task.ID, Version: current.Version}
+		}
+
+		deleted := current
+		deleted.Version++
+
+		return Model.record(Ctx, db, ActionDeleted, &current, &deleted)
+	})
 
 	if err != nil {
-		ErrorChannel <- err
-		return
+		return DeleteTaskStoreResponse{}, err
 	}
 
-	resp, err := db.ExecContext(ctx, EditTaskQuery, Task.Task.Title, Task.Task.Task_Description, Task.Task.Task_Status, Task.ID)
+	resl := DeleteTaskStoreResponse{
+		ID:     Task.ID,
+		Status: true,
+		Task:   Task.Task,
+	}
 
+	return resl, nil
+}
+
+func (Model *ModelStruct) ListTask(Ctx context.Context, Task ListTaskStore) ([]TaskStoreResponse, error) {
+	page, err := Model.PageTask(Ctx, Task)
 	if err != nil {
-		rollBackErr := db.Rollback()
-		if rollBackErr != nil {
-			ErrorChannel <- rollBackErr
-			return
-		} else {
-			ErrorChannel <- err
-			return
-		}
+		return []TaskStoreResponse{}, err
 	}
+	return page.Items, nil
+}
 
-	numRowAffected, err := resp.RowsAffected()
+// PageTask reads one page of tasks with Page/Limit or, when Task.Page_Token
+// is set, with a keyset cursor that does not skip or repeat rows when tasks
+// are added between pages.
+func (Model *ModelStruct) PageTask(Ctx context.Context, Task ListTaskStore) (ListTaskPage, error) {
+
+	err := Model.ValidateParamListTask(Task)
 
 	if err != nil {
-		rollBackErr := db.Rollback()
-		if rollBackErr != nil {
-			ErrorChannel <- rollBackErr
-			return
-		} else {
-			ErrorChannel <- err
-			return
-		}
+		return ListTaskPage{}, err
 	}
 
-	if numRowAffected > 1 || numRowAffected <= 0 {
-		rollBackErr := db.Rollback()
-		if rollBackErr != nil {
-			ErrorChannel <- rollBackErr
-			return
-		} else {
-			ErrorChannel <- err
-			return
+	if Task.Limit < 1 || Task.Page < 1 {
+		Task.Limit = 10
+		Task.Page = 1
+	}
+
+	Task.Offset = (Task.Page - 1) * Task.Limit
+
+	var cursor *taskRow
+
+	if len(Task.Page_Token) > 0 {
+		token, err := decodePageToken(Model.PageTokenKey, Task.Page_Token, Task)
+		if err != nil {
+			return ListTaskPage{}, err
+		}
+		row, err := cursorRow(Task, token)
+		if err != nil {
+			return ListTaskPage{}, err
 		}
+		cursor = &row
+		Task.Offset = 0
 	}
 
-	err = db.Commit()
+	var page ListTaskPage
+
+	err = Model.transact(Ctx, func(db *sql.Tx) error {
+		// Read one row past the page to learn whether another page follows.
+		probe := Task
+		probe.Limit++
+
+		query, args, columns := Model.Dialect.listTaskQuery(probe, cursor)
+
+		resp, err := db.QueryContext(Ctx, query, args...)
+
+		if err != nil {
+			return err
+		}
+
+		defer resp.Close()
+
+		rows := []taskRow{}
+
+		for resp.Next() {
+			var row taskRow
+			targets := []any{}
+			for _, column := range columns {
+				targets = append(targets, scanTarget(&row, column))
+			}
+			if err := resp.Scan(targets...); err != nil {
+				return err
+			}
+			rows = append(rows, row)
+		}
+
+		err = resp.Err()
+
+		if err != nil {
+			return err
+		}
+
+		page = pageOf(Model.PageTokenKey, Task, rows)
+
+		if !Task.Count_Total {
+			return nil
+		}
+
+		var total int64
+		query, args = Model.Dialect.countTaskQuery(Task)
+
+		err = db.QueryRowContext(Ctx, query, args...).Scan(&total)
+
+		if err != nil {
+			return err
+		}
+
+		page.Total_Count = &total
+
+		return nil
+	})
 
 	if err != nil {
-		ErrorChannel <- err
-		return
+		return ListTaskPage{}, err
 	}
 
-	reslt := TaskStoreResponse{
-		ID:   Task.ID,
-		Task: Task.Task,
-	}
+	return page, nil
 
-	ResultChannel <- reslt
-	return
+}
 
+func (Model *ModelStruct) ValidateParamListTask(Task ListTaskStore) error {
+	return validateListTask(Task).OrNil()
 }
 
-const DeleteTaskQuery string = `
-UPDATE TaskStore 
-SET Task_Status = false ,Edited_On = CURRENT_TIMESTAMP()
-WHERE ID = ? 
+const GetTaskQuery string = `
+SELECT ` + TaskColumns + ` FROM TaskStore
+WHERE Deleted_At IS NULL AND ID = ?
 ;
 `
 
-func (Model *ModelStruct) DeleteTask(Task DeleteTaskStoreRequest, Wg *sync.WaitGroup, ResultChannel chan<- DeleteTaskStoreResponse, ErrorChannel chan<- error) {
+func (Model *ModelStruct) GetTask(Ctx context.Context, Task GetTask) (TaskStoreResponse, error) {
+
+	err := Model.ValidateParamGetTask(Task)
+
+	if err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	var rsul TaskStoreResponse
+
+	err = Model.transact(Ctx, func(db *sql.Tx) error {
+		var err error
+
+		if Task.Revision > 0 || !Task.As_Of.IsZero() {
+			rsul, err = Model.readRevision(Ctx, db, Task)
+		} else {
+			rsul, err = Model.checkVersion(Ctx, db, Task.ID, 0)
+		}
+
+		return err
+	})
+
+	if err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	return rsul, nil
+}
 
-	defer Wg.Done()
+func (Model *ModelStruct) ValidateParamGetTask(Task GetTask) error {
+	return validateGetTask(Task).OrNil()
+}
 
-	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*10)
-	defer cancelFunc()
+// readTrashedTask reads task ID from the trash inside Tx and fails with a
+// VersionConflictError when Expected is set and no longer matches it.
+func (Model *ModelStruct) readTrashedTask(Ctx context.Context, Tx *sql.Tx, ID int64, Expected int64) (TaskStoreResponse, error) {
+	task, err := scanTask(Tx.QueryRowContext(Ctx, Model.Dialect.TrashedTaskQuery, ID))
 
-	db, err := Model.Config.SqlDBConn.BeginTx(ctx, &Model.TxOption)
+	if errors.Is(err, sql.ErrNoRows) {
+		return TaskStoreResponse{}, trashedTaskNotFound(ID)
+	}
 
 	if err != nil {
+		return TaskStoreResponse{}, err
+	}
 
-		ErrorChannel <- err
-		return
+	if Expected > 0 && Expected != task.Version {
+		return TaskStoreResponse{}, &VersionConflictError{ID: ID, Version: Expected}
 	}
 
-	resp, err := db.ExecContext(ctx, DeleteTaskQuery, Task.ID)
+	return task, nil
+}
+
+func (Model *ModelStruct) GetTrashedTask(Ctx context.Context, Task GetTask) (TaskStoreResponse, error) {
+
+	err := Model.ValidateParamGetTask(Task)
 
 	if err != nil {
-		nerr := db.Rollback()
-		if nerr != nil {
-			ErrorChannel <- nerr
-			return
-		} else {
-			ErrorChannel <- err
-			return
-		}
+		return TaskStoreResponse{}, err
 	}
 
-	numRowAffected, err := resp.RowsAffected()
+	var task TaskStoreResponse
+
+	err = Model.transact(Ctx, func(db *sql.Tx) error {
+		var err error
+		task, err = Model.readTrashedTask(Ctx, db, Task.ID, 0)
+		return err
+	})
 
 	if err != nil {
-		nerr := db.Rollback()
-		if nerr != nil {
-			ErrorChannel <- nerr
-			return
-		} else {
-			ErrorChannel <- err
-			return
-		}
+		return TaskStoreResponse{}, err
 	}
 
-	if numRowAffected > 1 || numRowAffected <= 0 {
-		nerr := db.Rollback()
-		if nerr != nil {
-			ErrorChannel <- nerr
-			return
-		} else {
-			ErrorChannel <- err
-			return
-		}
+	return task, nil
+}
+
+// RestoreTask takes a task out of the trash and makes it active again.
+func (Model *ModelStruct) RestoreTask(Ctx context.Context, Task TrashTaskStoreRequest) (TaskStoreResponse, error) {
+
+	err := Model.ValidateParamGetTask(GetTask{ID: Task.ID})
+
+	if err != nil {
+		return TaskStoreResponse{}, err
 	}
 
-	errMessage := db.Commit()
+	var restored TaskStoreResponse
 
-	if errMessage != nil {
-		ErrorChannel <- errMessage
-		return
+	err = Model.transact(Ctx, func(db *sql.Tx) error {
+		task, err := Model.readTrashedTask(Ctx, db, Task.ID, Task.Version)
+
+		if err != nil {
+			return err
+		}
+
+		resp, err := db.ExecContext(Ctx, Model.Dialect.RestoreTaskQuery, Task.ID, task.Version)
+
+		if err != nil {
+			return err
+		}
+
+		numRowAffected, err := resp.RowsAffected()
+
+		if err != nil {
+			return err
+		}
+
+		if numRowAffected != 1 {
+			return &VersionConflictError{ID: Task.ID, Version: task.Version}
+		}
+
+		restored = task
+		restored.Version++
+
+		return Model.record(Ctx, db, ActionRestored, &task, &restored)
+	})
+
+	if err != nil {
+		return TaskStoreResponse{}, err
 	}
 
-	resl := DeleteTaskStoreResponse{
-		ID:     Task.ID,
-		Status: true,
-		Task:   Task.Task,
+	return restored, nil
+}
+
+// PurgeTask permanently deletes a task from the trash. Active tasks have to
+// be deleted first.
+func (Model *ModelStruct) PurgeTask(Ctx context.Context, Task TrashTaskStoreRequest) error {
+
+	err := Model.ValidateParamGetTask(GetTask{ID: Task.ID})
+
+	if err != nil {
+		return err
 	}
 
-	ResultChannel <- resl
+	return Model.transact(Ctx, func(db *sql.Tx) error {
+		task, err := Model.readTrashedTask(Ctx, db, Task.ID, Task.Version)
 
-	return
+		if err != nil {
+			return err
+		}
 
-}
+		resp, err := db.ExecContext(Ctx, Model.Dialect.PurgeTaskQuery, Task.ID, task.Version)
 
-const ListTaskQuery string = `
-SELECT * FROM TaskStore
-LIMIT ?, ? 
-;
-`
+		if err != nil {
+			return err
+		}
+
+		numRowAffected, err := resp.RowsAffected()
+
+		if err != nil {
+			return err
+		}
 
-func (Model *ModelStruct) ListTask(Task ListTaskStore) ([]TaskStoreResponse, error) {
+		if numRowAffected != 1 {
+			return &VersionConflictError{ID: Task.ID, Version: task.Version}
+		}
 
-	respList := []TaskStoreResponse{}
+		return Model.record(Ctx, db, ActionPurged, &task, nil)
+	})
+}
 
-	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*10)
-	defer cancelFunc()
+// PurgeTrash permanently deletes every task deleted before Task.Before and
+// returns how many there were.
+func (Model *ModelStruct) PurgeTrash(Ctx context.Context, Task PurgeTrashStore) (int64, error) {
 
-	db, err := Model.Config.SqlDBConn.BeginTx(ctx, &Model.TxOption)
+	err := validatePurgeTrash(Task).OrNil()
 
 	if err != nil {
-		return respList, err
+		return 0, err
 	}
 
-	if Task.Limit < 1 || Task.Page < 1 {
-		Task.Limit = 10
-		Task.Page = 1
+	var purged int64
+
+	err = Model.transact(Ctx, func(db *sql.Tx) error {
+		expired, err := Model.expiredTrash(Ctx, db, Task.Before)
+
+		if err != nil {
+			return err
+		}
+
+		if len(expired) > 0 {
+			before := []*TaskStoreResponse{}
+			for index := range expired {
+				before = append(before, &expired[index])
+			}
+
+			err = Model.recordAll(Ctx, db, ActionPurged, before, make([]*TaskStoreResponse, len(expired)))
+
+			if err != nil {
+				return err
+			}
+		}
+
+		resp, err := db.ExecContext(Ctx, Model.Dialect.PurgeTrashQuery, sqlTime(Task.Before))
+
+		if err != nil {
+			return err
+		}
+
+		purged, err = resp.RowsAffected()
+
+		return err
+	})
+
+	if err != nil {
+		return 0, err
 	}
 
-	Task.Offset = (Task.Page - 1) * Task.Limit
+	return purged, nil
+}
 
-	resp, err := db.QueryContext(ctx, ListTaskQuery, Task.Offset, Task.Limit)
+// expiredTrash reads the tasks deleted before Before inside Tx.
+func (Model *ModelStruct) expiredTrash(Ctx context.Context, Tx *sql.Tx, Before time.Time) ([]TaskStoreResponse, error) {
+	resp, err := Tx.QueryContext(Ctx, Model.Dialect.ExpiredTrashQuery, sqlTime(Before))
 
 	if err != nil {
-		nerr := db.Rollback()
-		if nerr != nil {
-			return respList, nerr
-		}
+		return nil, err
 	}
 
+	defer resp.Close()
+
+	tasks := []TaskStoreResponse{}
+
 	for resp.Next() {
-		var taskResp TaskStoreResponse
-		var task TaskStoreRequest
-		t := ""
-		e := ""
-		if err := resp.Scan(
-			&taskResp.ID,
-			&task.Title,
-			&task.Task_Description,
-			&task.Task_Status,
-			&t,
-			&e,
-		); err != nil {
+		task, err := scanTask(resp)
+		if err != nil {
 			return nil, err
 		}
-		taskResp.Task = task
-		respList = append(respList, taskResp)
+		tasks = append(tasks, task)
 	}
 
-	errMessage := db.Commit()
+	return tasks, resp.Err()
+}
+
+// SearchTask ranks the active tasks matching Task.Query, with the full-text
+// index of the database when the Dialect has one and a SearchIndex otherwise.
+func (Model *ModelStruct) SearchTask(Ctx context.Context, Task SearchTaskStore) (SearchTaskPage, error) {
 
-	if errMessage != nil {
-		return nil, errMessage
+	err := Model.ValidateParamSearchTask(Task)
+
+	if err != nil {
+		return SearchTaskPage{}, err
 	}
 
-	return respList, nil
+	if Task.Limit < 1 || Task.Page < 1 {
+		Task.Limit = 10
+		Task.Page = 1
+	}
 
-}
+	offset := (Task.Page - 1) * Task.Limit
+	terms := ParseSearchQuery(Task.Query)
 
-const GetTaskQuery string = `
-SELECT * FROM TaskStore 
-WHERE Task_Status = true AND ID = ? 
-;
-`
+	var page SearchTaskPage
 
-func (Model *ModelStruct) GetTask(Task GetTask, Wg *sync.WaitGroup, ResultChannel chan<- TaskStoreResponse, ErrorChannel chan<- error) {
+	err = Model.transact(Ctx, func(db *sql.Tx) error {
+		var err error
 
-	defer Wg.Done()
+		if len(Model.Dialect.SearchTaskQuery) > 0 {
+			page, err = Model.searchFullText(Ctx, db, terms, Task.Limit, offset)
+		} else {
+			page, err = Model.searchIndex(Ctx, db, terms, Task.Limit, offset)
+		}
 
-	isValid, message := Model.ValidateParamGetTask(Task)
+		return err
+	})
 
-	if isValid == true {
-		errObj := errors.New(message)
-		ErrorChannel <- errObj
+	if err != nil {
+		return SearchTaskPage{}, err
 	}
 
-	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*100)
-	defer cancelFunc()
-	rsul := TaskStoreResponse{}
-
-	db, err := Model.Config.SqlDBConn.BeginTx(ctx, &Model.TxOption)
+	return page, nil
+}
 
-	if err != nil {
+func (Model *ModelStruct) ValidateParamSearchTask(Task SearchTaskStore) error {
+	return validateSearchTask(Task).OrNil()
+}
 
-		ErrorChannel <- err
-		return
-	}
+func (Model *ModelStruct) searchFullText(Ctx context.Context, Tx *sql.Tx, Terms []SearchTerm, Limit int64, Offset int64) (SearchTaskPage, error) {
+	page := SearchTaskPage{Items: []SearchHit{}}
+	query := MySQLBooleanQuery(Terms)
 
-	resp, err := db.QueryContext(ctx, GetTaskQuery, Task.ID)
+	resp, err := Tx.QueryContext(Ctx, Model.Dialect.SearchTaskQuery, query, query, Limit, Offset)
 
 	if err != nil {
-		nerr := db.Rollback()
-		if nerr != nil {
-			ErrorChannel <- nerr
-			return
-		} else {
-			ErrorChannel <- err
-			return
-		}
+		return SearchTaskPage{}, err
 	}
 
-	for resp.Next() {
-		var task TaskStoreRequest
-		t := ""
-		e := ""
+	defer resp.Close()
 
+	for resp.Next() {
+		var hit SearchHit
 		err := resp.Scan(
-			&rsul.ID,
-			&task.Title,
-			&task.Task_Description,
-			&task.Task_Status,
-			&t,
-			&e,
+			&hit.Task.ID,
+			&hit.Task.Task.Title,
+			&hit.Task.Task.Task_Description,
+			&hit.Task.Task.Task_Status,
+			&hit.Task.Version,
+			&hit.Task.Workflow_Status,
+			&hit.Score,
 		)
-
 		if err != nil {
-			ErrorChannel <- err
-			return
+			return SearchTaskPage{}, err
 		}
-		rsul.Task = task
+		page.Items = append(page.Items, highlightHit(hit, Terms))
 	}
 
-	errMessage := db.Commit()
+	err = resp.Err()
 
-	if errMessage != nil {
-		ErrorChannel <- errMessage
-		return
+	if err != nil {
+		return SearchTaskPage{}, err
 	}
 
-	ResultChannel <- rsul
+	err = Tx.QueryRowContext(Ctx, Model.Dialect.CountSearchTaskQuery, query).Scan(&page.Total_Count)
 
-	return
+	if err != nil {
+		return SearchTaskPage{}, err
+	}
 
+	return page, nil
 }
 
-func (Model *ModelStruct) ValidateParamGetTask(Task GetTask) (bool, string) {
-	var IsValid bool = false
-	errMessages := []string{}
-	errorMessage := ""
+// searchIndex brings Model.SearchIndex up to date with the table when it has
+// changed since the last search, then searches it.
+func (Model *ModelStruct) searchIndex(Ctx context.Context, Tx *sql.Tx, Terms []SearchTerm, Limit int64, Offset int64) (SearchTaskPage, error) {
+	var fingerprint [3]int64
+
+	err := Tx.QueryRowContext(Ctx, Model.Dialect.SearchFingerprintQuery).Scan(&fingerprint[0], &fingerprint[1], &fingerprint[2])
 
-	if Task.ID < 1 {
-		IsValid = true
-		errMessages = append(errMessages, "Invalid ID!")
+	if err != nil {
+		return SearchTaskPage{}, err
 	}
 
-	for _, message := range errMessages {
-		errorMessage = errorMessage + message + " , "
+	err = Model.SearchIndex.refresh(fingerprint, func() ([]TaskStoreResponse, error) {
+		resp, err := Tx.QueryContext(Ctx, Model.Dialect.SearchIndexQuery)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Close()
+
+		tasks := []TaskStoreResponse{}
+		for resp.Next() {
+			var task TaskStoreResponse
+			err := resp.Scan(&task.ID, &task.Task.Title, &task.Task.Task_Description, &task.Task.Task_Status, &task.Version, &task.Workflow_Status)
+			if err != nil {
+				return nil, err
+			}
+			tasks = append(tasks, task)
+		}
+		return tasks, resp.Err()
+	})
+
+	if err != nil {
+		return SearchTaskPage{}, err
 	}
 
-	return IsValid, errorMessage
+	hits, total := Model.SearchIndex.Search(Terms, Limit, Offset)
+
+	return SearchTaskPage{Items: hits, Total_Count: total}, nil
 }
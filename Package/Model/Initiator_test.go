@@ -1,36 +1,62 @@
 package Model
 
 import (
-	"TaskManager/Helper/Startup"
 	"TaskManager/Package/Configurator"
+	"TaskManager/Package/Migrator"
+	"context"
+	"database/sql"
+	"expvar"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strconv"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/go-sql-driver/mysql"
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
 type SuiteStruct struct {
 	suite.Suite
-	Model     ModelStruct
+	Memory    bool
+	Model     ModelInterface
+	Config    *Configurator.ConfiguratorStruct
 	RespStore []TaskStoreResponse
 }
 
 func (Suite *SuiteStruct) SetupSuite() {
-	config := Configurator.NewConfigurator()
-	config.LoadConfig(Startup.DebugMode)
-	config.LoadDBInstance()
-	//fmt.Println(config.DbConnString)
-	//fmt.Println(config.DbDriver)
-
-	model := NewModel(*config)
-	Suite.Model = model
 	Suite.RespStore = make([]TaskStoreResponse, 10)
+
+	if Suite.Memory {
+		Suite.Model = NewMemoryModel()
+		return
+	}
+
+	config := Configurator.NewConfigurator()
+	config.DbDriver = SQLiteDriver
+	config.DbConnString = filepath.Join(Suite.T().TempDir(), "TaskStore.db")
+	err := config.LoadDBInstance()
+	Suite.Require().NoError(err)
+
+	migrator, err := Migrator.NewMigrator(*config)
+	Suite.Require().NoError(err)
+	_, err = migrator.Up(context.Background())
+	Suite.Require().NoError(err)
+
+	model, err := NewModel(*config)
+	Suite.Require().NoError(err)
+	Suite.Model = &model
+	Suite.Config = config
 }
 
 func (Suite *SuiteStruct) TearDownSuite() {
-	Suite.Model.Config.SqlDBConn.Close()
+	if Suite.Config != nil {
+		Suite.Config.SqlDBConn.Close()
+	}
 	Suite.RespStore = nil
 }
 
@@ -44,277 +70,1053 @@ func (Suite *SuiteStruct) TearDownTest() {
 	Suite.RespStore = nil
 }
 
-// func (Model *ModelStruct) AddTask(Task TaskStoreRequest, WaitGroup *sync.WaitGroup) (TaskStoreResponse, error)
+// func (Model *ModelStruct) AddTask(Ctx context.Context, Task TaskStoreRequest) (TaskStoreResponse, error)
 func (Suite *SuiteStruct) TestAddTask() {
 
-	errorChannel := make(chan error)
-	requestChannel := make(chan TaskStoreResponse)
-	defer close(errorChannel)
-	defer close(requestChannel)
+	Suite.RespStore = Suite.AddConcurrently(10)
 
+	for _, res := range Suite.RespStore {
+		Suite.Suite.True(res.ID >= 1, fmt.Sprintf("Error Has occured in a Concurent Test Case %v", res.ID))
+	}
+}
+
+// AddConcurrently inserts Count tasks from Count goroutines and returns the stored responses.
+func (Suite *SuiteStruct) AddConcurrently(Count int) []TaskStoreResponse {
+
+	ctx := context.Background()
+	results := make([]TaskStoreResponse, Count)
+	errs := make([]error, Count)
 	wg := sync.WaitGroup{}
 
-	for i := 0; i < 10; i++ {
+	for i := 0; i < Count; i++ {
 		wg.Add(1)
 
-		go Suite.Model.AddTask(TaskStoreRequest{
-			Title:            strconv.Itoa(100 + i),
-			Task_Description: strconv.Itoa(100),
-			Task_Status:      true,
-		}, &wg, requestChannel, errorChannel)
+		go func(Index int) {
+			defer wg.Done()
+			results[Index], errs[Index] = Suite.Model.AddTask(ctx, TaskStoreRequest{
+				Title:            strconv.Itoa(100 + Index),
+				Task_Description: strconv.Itoa(100),
+				Task_Status:      true,
+			})
+		}(i)
+	}
+
+	wg.Wait()
 
+	for _, err := range errs {
+		Suite.Require().NoError(err, "Error occured even before the Test Case Start! .")
 	}
 
-	go func() {
+	return results
+}
 
-		for err := range errorChannel {
-			Suite.Suite.NoError(err, "Error occured even before the edit Test Case Start! .", err.Error())
-		}
-	}()
+// func (Model *ModelStruct) EditTask(Ctx context.Context, Task UpdateTaskStoreRequest) (TaskStoreResponse, error)
+func (Suite *SuiteStruct) TestEditTask() {
 
-	go func() {
+	ctx := context.Background()
+	Suite.RespStore = Suite.AddConcurrently(10)
 
-		for res := range requestChannel {
-			Suite.Suite.True(res.ID >= 1, fmt.Sprintf("Error Has occured in a Concurent Test Case %v", res.ID))
-		}
-	}()
+	wg := sync.WaitGroup{}
+	errs := make([]error, len(Suite.RespStore))
+
+	for index, prevRes := range Suite.RespStore {
+		wg.Add(1)
+
+		go func(Index int, ID int64) {
+			defer wg.Done()
+			_, errs[Index] = Suite.Model.EditTask(ctx, UpdateTaskStoreRequest{
+				ID: ID,
+				Task: TaskStoreRequest{
+					Title:            "Hello ",
+					Task_Description: "World",
+					Task_Status:      true,
+				},
+			})
+		}(index, prevRes.ID)
+	}
 
 	wg.Wait()
 
-}
+	for _, err := range errs {
+		Suite.Suite.NoError(err, "Error occured in a Concurent Edit Test Case")
+	}
 
-// func (Model *ModelStruct) EditTask(Task UpdateTaskStoreRequest, WaitGroup *sync.WaitGroup) (TaskStoreResponse, error) {
-func (Suite *SuiteStruct) TestEditTask() {
+	for _, prevRes := range Suite.RespStore {
+		res, err := Suite.Model.GetTask(ctx, GetTask{ID: prevRes.ID})
+		Suite.Require().NoError(err)
+		Suite.Suite.Equal("Hello ", res.Task.Title)
+		Suite.Suite.Equal("World", res.Task.Task_Description)
+	}
+}
 
-	errorChannel := make(chan error)
-	resultChannel := make(chan TaskStoreResponse)
-	wg := sync.WaitGroup{}
-	resultStore := []TaskStoreResponse{}
+// func (Model *ModelStruct) DeleteTask(Ctx context.Context, Task DeleteTaskStoreRequest) (DeleteTaskStoreResponse, error)
+func (Suite *SuiteStruct) TestDeleteTask() {
 
-	defer close(errorChannel)
-	defer close(resultChannel)
-	defer wg.Wait()
+	ctx := context.Background()
 
-	for i := 0; i < 10; i++ {
-		wg.Add(1)
-		go Suite.Model.AddTask(TaskStoreRequest{
-			Title:            strconv.Itoa(100 + i),
-			Task_Description: strconv.Itoa(100),
-			Task_Status:      true,
-		}, &wg, resultChannel, errorChannel)
+	task := TaskStoreRequest{
+		Title:            strconv.Itoa(100),
+		Task_Description: strconv.Itoa(100),
+		Task_Status:      true,
 	}
 
-	go func() {
+	added, err := Suite.Model.AddTask(ctx, task)
+	Suite.Require().NoError(err, "Error occured even before the Delete Test Case Start! .")
 
-		for err := range errorChannel {
-			Suite.Suite.NoError(err, "Error occured even before the Add Test Case Start! ."+err.Error())
-		}
-	}()
+	res, err := Suite.Model.DeleteTask(ctx, DeleteTaskStoreRequest{
+		ID:   added.ID,
+		Task: task,
+	})
 
-	go func() {
+	Suite.Require().NoError(err)
+	Suite.Suite.True(res.ID >= 1, fmt.Sprintf("Error Has occured in a Delete Test Case %v", res.ID))
+	Suite.Suite.True(res.Status)
+}
 
-		for res := range resultChannel {
-			Suite.Suite.True(res.ID >= 1, fmt.Sprintf("Error Has occured in a Add Test Case %v", res.ID))
-			resultStore = append(resultStore, res)
-		}
-	}()
+// func (Model *ModelStruct) ListTask(Ctx context.Context, Task ListTaskStore) ([]TaskStoreResponse, error)
+func (Suite *SuiteStruct) TestListTask() {
 
-	wg.Wait()
+	Suite.AddConcurrently(3)
 
-	if len(resultStore) >= 1 {
-		for _, prevRes := range resultStore {
+	resp, err := Suite.Model.ListTask(context.Background(), ListTaskStore{
+		Limit: 10,
+		Page:  1,
+	})
 
-			wg.Add(1)
-			newTask := TaskStoreRequest{
-				Title:            "Hello ",
-				Task_Description: "World",
-				Task_Status:      true,
-			}
-			go Suite.Model.EditTask(UpdateTaskStoreRequest{
-				ID:   prevRes.ID,
-				Task: newTask,
-			}, &wg, resultChannel, errorChannel)
-		}
+	Suite.Suite.NoError(err, "Error Occured")
+	Suite.Suite.NotEmpty(resp)
+
+	for _, task := range resp {
+		Suite.Suite.True(task.ID > 0, "Defective Data REturned!")
 	}
 
-	go func() {
+}
 
-		for err := range errorChannel {
-			Suite.Suite.NoError(err, "Error occured even before the edit Test Case Start! .")
-		}
-	}()
+// func (Model *ModelStruct) GetTask(Ctx context.Context, Task GetTask) (TaskStoreResponse, error)
+func (Suite *SuiteStruct) TestGetTask() {
 
-	go func() {
+	ctx := context.Background()
 
-		for res := range resultChannel {
-			Suite.Suite.True(res.ID >= 1, fmt.Sprintf("Error Has occured in a Concurent Test Case %v", res.ID))
-			resultStore = append(resultStore, res)
-		}
-	}()
+	task := TaskStoreRequest{
+		Title:            strconv.Itoa(100),
+		Task_Description: strconv.Itoa(100),
+		Task_Status:      true,
+	}
 
-	wg.Wait()
-}
+	added, err := Suite.Model.AddTask(ctx, task)
+	Suite.Require().NoError(err, "Error occured even before the Get Test Case Start! .")
 
-// func (Model *ModelStruct) DeleteTask(Task DeleteTaskStoreRequest, Wg *sync.WaitGroup, ResultChannel chan<- DeleteTaskStoreResponse, ErrorChannel chan<- error)
-func (Suite *SuiteStruct) TestDeleteTask() {
+	res, err := Suite.Model.GetTask(ctx, GetTask{ID: added.ID})
 
-	errorChannel := make(chan error)
-	resultChannel := make(chan TaskStoreResponse)
-	deleteResultChannel := make(chan DeleteTaskStoreResponse)
+	Suite.Require().NoError(err, "Error Has Occured with responce of GetTask !")
+	Suite.Suite.Equal(added.ID, res.ID)
+	Suite.Suite.Equal(task, res.Task)
+}
 
-	wg := sync.WaitGroup{}
-	savedTaskID := 0
+func (Suite *SuiteStruct) TestDeletedTaskIsHidden() {
 
-	defer close(errorChannel)
-	defer close(resultChannel)
-	defer close(deleteResultChannel)
-	defer wg.Wait()
+	ctx := context.Background()
 
 	task := TaskStoreRequest{
-		Title:            strconv.Itoa(100),
-		Task_Description: strconv.Itoa(100),
+		Title:            "Hidden",
+		Task_Description: "Task",
 		Task_Status:      true,
 	}
 
-	wg.Add(1)
-	go Suite.Model.AddTask(task, &wg, resultChannel, errorChannel)
+	added, err := Suite.Model.AddTask(ctx, task)
+	Suite.Require().NoError(err)
 
-	go func() {
+	deleted, err := Suite.Model.DeleteTask(ctx, DeleteTaskStoreRequest{ID: added.ID, Task: task})
+	Suite.Require().NoError(err)
+	Suite.Suite.True(deleted.Status)
 
-		for err := range errorChannel {
-			errme := "dError occured even before the Add Test Case Start! ." + err.Error()
-			Suite.Suite.NoError(err, errme)
-		}
-	}()
-	go func() {
+	_, err = Suite.Model.GetTask(ctx, GetTask{ID: added.ID})
+	Suite.Suite.ErrorIs(err, ErrTaskNotFound, "Soft deleted task must not be returned")
 
-		for res := range resultChannel {
-			Suite.Suite.True(res.ID >= 1, fmt.Sprintf("Error Has occured in a Add Test Case %v", res.ID))
-			savedTaskID = int(res.ID)
-		}
-	}()
+	_, err = Suite.Model.EditTask(ctx, UpdateTaskStoreRequest{ID: added.ID + 1000, Task: task})
+	Suite.Suite.ErrorIs(err, ErrTaskNotFound)
 
-	wg.Wait()
+	_, err = Suite.Model.AddTask(ctx, TaskStoreRequest{})
+	var validationErr *ValidationError
+	Suite.Require().ErrorAs(err, &validationErr)
+	Suite.Suite.Len(validationErr.Violations, 2, "Every invalid field must be reported")
 
-	for {
+	open, err := Suite.Model.AddTask(ctx, TaskStoreRequest{Title: "Open", Task_Description: "Not done", Task_Status: false})
+	Suite.Require().NoError(err, "Task_Status is the task's own flag, false is a valid value")
 
-		if savedTaskID >= 1 {
-			wg.Add(1)
-			go Suite.Model.DeleteTask(DeleteTaskStoreRequest{
-				ID:   int64(savedTaskID),
-				Task: task,
-			}, &wg, deleteResultChannel, errorChannel)
+	stored, err := Suite.Model.GetTask(ctx, GetTask{ID: open.ID})
+	Suite.Require().NoError(err, "A task with Task_Status false is not deleted")
+	Suite.Suite.False(stored.Task.Task_Status)
+}
 
-			go func() {
+func (Suite *SuiteStruct) TestPatchTask() {
 
-				for err := range errorChannel {
-					Suite.Suite.NoError(err, "d2Error occured even before the Add Test Case Start! ."+err.Error())
-				}
-			}()
+	ctx := context.Background()
+	added := Suite.AddConcurrently(1)[0]
 
-			go func() {
+	// Two clients patching different fields at the same time must both win.
+	wg := sync.WaitGroup{}
+	errs := make([]error, 2)
+	patches := []TaskPatch{
+		MergePatch(`{"Task": {"Title": "Merged"}}`),
+		JSONPatch{{Op: "replace", Path: "/Task/Task_Description", Value: []byte(`"Replaced"`)}},
+	}
 
-				for res := range deleteResultChannel {
-					Suite.Suite.True(res.ID >= 1, fmt.Sprintf("Error Has occured in a Add Test Case %v", res.ID))
-				}
-			}()
+	for index, patch := range patches {
+		wg.Add(1)
 
-			wg.Wait()
-			break
-		}
+		go func(Index int, Patch TaskPatch) {
+			defer wg.Done()
+			_, errs[Index] = Suite.Model.PatchTask(ctx, PatchTaskStoreRequest{ID: added.ID, Patch: Patch})
+		}(index, patch)
 	}
 
+	wg.Wait()
+
+	for _, err := range errs {
+		Suite.Require().NoError(err)
+	}
+
+	res, err := Suite.Model.GetTask(ctx, GetTask{ID: added.ID})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal("Merged", res.Task.Title)
+	Suite.Suite.Equal("Replaced", res.Task.Task_Description)
+
+	res, err = Suite.Model.PatchTask(ctx, PatchTaskStoreRequest{ID: added.ID, Patch: JSONPatch{
+		{Op: "test", Path: "/Task/Title", Value: []byte(`"Merged"`)},
+		{Op: "copy", From: "/Task/Title", Path: "/Task/Task_Description"},
+	}})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal("Merged", res.Task.Task_Description)
 }
 
-// func (Model *ModelStruct) ListTask(Task ListTaskStore) ([]TaskStoreResponse, error)
-func (Suite *SuiteStruct) TestListTask() {
+func (Suite *SuiteStruct) TestPatchTaskErrors() {
 
-	resp, err := Suite.Model.ListTask(ListTaskStore{
-		Limit: 10,
-		Page:  1,
+	ctx := context.Background()
+	added := Suite.AddConcurrently(1)[0]
+
+	_, err := Suite.Model.PatchTask(ctx, PatchTaskStoreRequest{ID: added.ID, Patch: JSONPatch{
+		{Op: "test", Path: "/Task/Title", Value: []byte(`"Something Else"`)},
+		{Op: "replace", Path: "/Task/Title", Value: []byte(`"Never"`)},
+	}})
+	var conflictErr *ConflictError
+	Suite.Suite.ErrorAs(err, &conflictErr, "A failed test op must abort the whole patch")
+
+	var validationErr *ValidationError
+
+	_, err = Suite.Model.PatchTask(ctx, PatchTaskStoreRequest{ID: added.ID, Patch: JSONPatch{{Op: "upsert", Path: "Task"}}})
+	Suite.Require().ErrorAs(err, &validationErr)
+	Suite.Suite.Len(validationErr.Violations, 2)
+
+	_, err = Suite.Model.PatchTask(ctx, PatchTaskStoreRequest{ID: added.ID, Patch: MergePatch(`{"ID": 7, "Task": {"Title": null}}`)})
+	Suite.Require().ErrorAs(err, &validationErr)
+	Suite.Suite.Len(validationErr.Violations, 2, "Both the cleared title and the changed ID must be reported")
+
+	_, err = Suite.Model.PatchTask(ctx, PatchTaskStoreRequest{ID: added.ID, Patch: MergePatch(`{"Task": {"Title": 5}}`)})
+	Suite.Suite.ErrorAs(err, &validationErr)
+
+	_, err = Suite.Model.PatchTask(ctx, PatchTaskStoreRequest{ID: added.ID, Patch: MergePatch(`{"Owner": "someone"}`)})
+	Suite.Suite.ErrorAs(err, &validationErr)
+
+	_, err = Suite.Model.PatchTask(ctx, PatchTaskStoreRequest{ID: added.ID + 1000, Patch: MergePatch(`{}`)})
+	Suite.Suite.ErrorIs(err, ErrTaskNotFound)
+
+	res, err := Suite.Model.GetTask(ctx, GetTask{ID: added.ID})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal(added.Task, res.Task, "Rejected patches must not change the row")
+}
+
+func (Suite *SuiteStruct) TestVersionedWrites() {
+
+	ctx := context.Background()
+	added := Suite.AddConcurrently(1)[0]
+	Suite.Suite.Equal(int64(1), added.Version)
+
+	edited, err := Suite.Model.EditTask(ctx, UpdateTaskStoreRequest{ID: added.ID, Version: added.Version, Task: added.Task})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal(added.Version+1, edited.Version)
+
+	var versionErr *VersionConflictError
+
+	_, err = Suite.Model.EditTask(ctx, UpdateTaskStoreRequest{ID: added.ID, Version: added.Version, Task: added.Task})
+	Suite.Suite.ErrorAs(err, &versionErr, "A write against a stale version must not overwrite")
+
+	_, err = Suite.Model.PatchTask(ctx, PatchTaskStoreRequest{ID: added.ID, Version: added.Version, Patch: MergePatch(`{}`)})
+	Suite.Suite.ErrorAs(err, &versionErr)
+
+	_, err = Suite.Model.DeleteTask(ctx, DeleteTaskStoreRequest{ID: added.ID, Version: added.Version})
+	Suite.Suite.ErrorAs(err, &versionErr)
+
+	patched, err := Suite.Model.PatchTask(ctx, PatchTaskStoreRequest{ID: added.ID, Version: edited.Version, Patch: MergePatch(`{"Task": {"Title": "Versioned"}}`)})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal(edited.Version+1, patched.Version)
+
+	res, err := Suite.Model.GetTask(ctx, GetTask{ID: added.ID})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal(patched.Version, res.Version)
+
+	_, err = Suite.Model.DeleteTask(ctx, DeleteTaskStoreRequest{ID: added.ID, Version: res.Version})
+	Suite.Suite.NoError(err)
+}
+
+func (Suite *SuiteStruct) TestListTaskFilters() {
+
+	ctx := context.Background()
+	before := time.Now().Add(-time.Minute)
+
+	for _, title := range []string{"Filter_b", "Filter_a", "Filter_c"} {
+		_, err := Suite.Model.AddTask(ctx, TaskStoreRequest{Title: title, Task_Description: "Filter 50%", Task_Status: true})
+		Suite.Require().NoError(err)
+	}
+
+	_, err := Suite.Model.AddTask(ctx, TaskStoreRequest{Title: "Filterxa", Task_Description: "Wildcard", Task_Status: true})
+	Suite.Require().NoError(err)
+
+	resp, err := Suite.Model.ListTask(ctx, ListTaskStore{
+		Limit:  10,
+		Page:   1,
+		Filter: ListTaskFilter{Title_Contains: "filter_", Description_Contains: "50%", Created_After: before},
+		Sort:   []SortKey{{Field: "Title", Descending: true}},
+		Fields: []string{"Title"},
+	})
+	Suite.Require().NoError(err)
+	Suite.Require().Len(resp, 3, "_ and % must match literally")
+	Suite.Suite.Equal("Filter_c", resp[0].Task.Title)
+	Suite.Suite.Equal("Filter_a", resp[2].Task.Title)
+	Suite.Suite.Empty(resp[0].Task.Task_Description, "Unselected fields stay empty")
+	Suite.Suite.NotZero(resp[0].ID)
+
+	resp, err = Suite.Model.ListTask(ctx, ListTaskStore{
+		Limit:  10,
+		Page:   1,
+		Filter: ListTaskFilter{Title_Contains: "Filter", Created_Before: before},
 	})
+	Suite.Require().NoError(err)
+	Suite.Suite.Empty(resp)
 
-	Suite.Suite.NoError(err, "Error Occured")
-	respList := []TaskStoreResponse{}
-	respList = append(respList, resp...)
+	_, err = Suite.Model.ListTask(ctx, ListTaskStore{
+		Filter: ListTaskFilter{Status: "archived", Created_After: before, Created_Before: before},
+		Sort:   []SortKey{{Field: "Title; DROP TABLE TaskStore"}},
+	})
+	var validationErr *ValidationError
+	Suite.Require().ErrorAs(err, &validationErr)
+	Suite.Suite.Len(validationErr.Violations, 3)
+}
 
-	for _, task := range respList {
-		Suite.Suite.True(task.ID > 0, "Defective Data REturned!")
+func (Suite *SuiteStruct) TestPageTaskKeyset() {
+
+	ctx := context.Background()
+
+	for _, title := range []string{"Keyset_e", "Keyset_d", "Keyset_c", "Keyset_b"} {
+		_, err := Suite.Model.AddTask(ctx, TaskStoreRequest{Title: title, Task_Description: "Keyset", Task_Status: true})
+		Suite.Require().NoError(err)
+	}
+
+	query := ListTaskStore{
+		Limit:       2,
+		Page:        1,
+		Filter:      ListTaskFilter{Title_Contains: "Keyset_"},
+		Sort:        []SortKey{{Field: "Title"}},
+		Count_Total: true,
 	}
 
+	first, err := Suite.Model.PageTask(ctx, query)
+	Suite.Require().NoError(err)
+	Suite.Require().Len(first.Items, 2)
+	Suite.Suite.True(first.Has_More)
+	Suite.Suite.NotEmpty(first.Next_Page_Token)
+	Suite.Require().NotNil(first.Total_Count)
+	Suite.Suite.Equal(int64(4), *first.Total_Count)
+	Suite.Suite.Equal("Keyset_c", first.Items[1].Task.Title)
+
+	// A task inserted before the cursor must not shift the next page.
+	_, err = Suite.Model.AddTask(ctx, TaskStoreRequest{Title: "Keyset_a", Task_Description: "Keyset", Task_Status: true})
+	Suite.Require().NoError(err)
+
+	query.Page_Token = first.Next_Page_Token
+	query.Count_Total = false
+	second, err := Suite.Model.PageTask(ctx, query)
+	Suite.Require().NoError(err)
+	Suite.Require().Len(second.Items, 2)
+	Suite.Suite.Equal("Keyset_d", second.Items[0].Task.Title)
+	Suite.Suite.Equal("Keyset_e", second.Items[1].Task.Title)
+	Suite.Suite.False(second.Has_More)
+	Suite.Suite.Empty(second.Next_Page_Token)
+	Suite.Suite.Nil(second.Total_Count)
+
+	var validationErr *ValidationError
+
+	query.Page_Token = first.Next_Page_Token[:len(first.Next_Page_Token)-2] + "xx"
+	_, err = Suite.Model.PageTask(ctx, query)
+	Suite.Suite.ErrorAs(err, &validationErr, "A tampered token must be rejected")
+
+	query.Page_Token = first.Next_Page_Token
+	query.Sort = []SortKey{{Field: "Title", Descending: true}}
+	_, err = Suite.Model.PageTask(ctx, query)
+	Suite.Suite.ErrorAs(err, &validationErr, "A token only continues the listing it came from")
 }
 
-// func (Model *ModelStruct) GetTask(Task GetTask, Wg *sync.WaitGroup, ResultChannel chan<- TaskStoreResponse, ErrorChannel chan<- error)
-func (Suite *SuiteStruct) TestGetTask() {
+func (Suite *SuiteStruct) TestSearchTask() {
 
-	errorChannel := make(chan error)
-	resultChannel := make(chan TaskStoreResponse)
-	taskStoreResponseChannel := make(chan TaskStoreResponse)
+	ctx := context.Background()
 
-	wg := sync.WaitGroup{}
-	savedTaskID := 0
+	tasks := []TaskStoreRequest{
+		{Title: "Quokka feeding", Task_Description: "Give the zoo quokka its leaves", Task_Status: true},
+		{Title: "Buy groceries", Task_Description: "Leaves for the quokka <and> the wombat", Task_Status: true},
+		{Title: "Quokkas photo", Task_Description: "Photograph the wombat near the zoo gate", Task_Status: true},
+	}
 
-	defer close(errorChannel)
-	defer close(resultChannel)
-	defer close(taskStoreResponseChannel)
-	defer wg.Wait()
+	added := []TaskStoreResponse{}
+	for _, task := range tasks {
+		res, err := Suite.Model.AddTask(ctx, task)
+		Suite.Require().NoError(err)
+		added = append(added, res)
+	}
 
-	task := TaskStoreRequest{
-		Title:            strconv.Itoa(100),
-		Task_Description: strconv.Itoa(100),
-		Task_Status:      true,
+	page, err := Suite.Model.SearchTask(ctx, SearchTaskStore{Query: "quokka", Limit: 10, Page: 1})
+	Suite.Require().NoError(err)
+	Suite.Require().Len(page.Items, 2)
+	Suite.Suite.Equal(int64(2), page.Total_Count)
+	Suite.Suite.Equal(added[0].ID, page.Items[0].Task.ID, "A title match ranks first")
+	Suite.Suite.Greater(page.Items[0].Score, page.Items[1].Score)
+	Suite.Suite.Equal("<mark>Quokka</mark> feeding", page.Items[0].Title_Snippet)
+	Suite.Suite.Equal("Leaves for the <mark>quokka</mark> &lt;and&gt; the wombat", page.Items[1].Description_Snippet)
+
+	page, err = Suite.Model.SearchTask(ctx, SearchTaskStore{Query: "quokk* wombat", Limit: 10, Page: 1})
+	Suite.Require().NoError(err)
+	Suite.Require().Len(page.Items, 2)
+	Suite.Suite.ElementsMatch([]int64{added[1].ID, added[2].ID}, []int64{page.Items[0].Task.ID, page.Items[1].Task.ID})
+
+	page, err = Suite.Model.SearchTask(ctx, SearchTaskStore{Query: `"the wombat near"`, Limit: 10, Page: 1})
+	Suite.Require().NoError(err)
+	Suite.Require().Len(page.Items, 1)
+	Suite.Suite.Equal(added[2].ID, page.Items[0].Task.ID)
+	Suite.Suite.Contains(page.Items[0].Description_Snippet, "<mark>the wombat near</mark>")
+
+	page, err = Suite.Model.SearchTask(ctx, SearchTaskStore{Query: "quokk*", Limit: 1, Page: 2})
+	Suite.Require().NoError(err)
+	Suite.Suite.Len(page.Items, 1)
+	Suite.Suite.Equal(int64(3), page.Total_Count)
+
+	// Writes reach the index, deleted tasks drop out of it.
+	_, err = Suite.Model.EditTask(ctx, UpdateTaskStoreRequest{ID: added[0].ID, Task: TaskStoreRequest{Title: "Platypus feeding", Task_Description: "Worms", Task_Status: true}})
+	Suite.Require().NoError(err)
+	_, err = Suite.Model.DeleteTask(ctx, DeleteTaskStoreRequest{ID: added[1].ID})
+	Suite.Require().NoError(err)
+
+	page, err = Suite.Model.SearchTask(ctx, SearchTaskStore{Query: "quokka", Limit: 10, Page: 1})
+	Suite.Require().NoError(err)
+	Suite.Suite.Empty(page.Items)
+
+	page, err = Suite.Model.SearchTask(ctx, SearchTaskStore{Query: "platypus", Limit: 10, Page: 1})
+	Suite.Require().NoError(err)
+	Suite.Require().Len(page.Items, 1)
+	Suite.Suite.Equal(added[0].ID, page.Items[0].Task.ID)
+
+	_, err = Suite.Model.SearchTask(ctx, SearchTaskStore{Query: " *!? "})
+	var validationErr *ValidationError
+	Suite.Suite.ErrorAs(err, &validationErr)
+}
+
+func (Suite *SuiteStruct) TestParseSearchQuery() {
+
+	terms := ParseSearchQuery(`Zoo* "the  Wombat" +gate; -- DROP`)
+	Suite.Suite.Equal([]SearchTerm{
+		{Words: []string{"zoo"}, Prefix: true},
+		{Words: []string{"the", "wombat"}},
+		{Words: []string{"gate"}},
+		{Words: []string{"drop"}},
+	}, terms)
+	Suite.Suite.Equal(`+zoo* +"the wombat" +gate +drop`, MySQLBooleanQuery(terms))
+
+	snippet := Highlight("one two three four five six seven eight nine ten eleven twelve thirteen fourteen fifteen wombat", ParseSearchQuery("wombat"), 6)
+	Suite.Suite.Equal("… eleven twelve thirteen fourteen fifteen <mark>wombat</mark>", snippet)
+
+	snippet = Highlight("one two wombat four five six seven eight", ParseSearchQuery("wombat"), 6)
+	Suite.Suite.Equal("one two <mark>wombat</mark> four five six …", snippet)
+}
+
+func (Suite *SuiteStruct) TestTrash() {
+
+	ctx := context.Background()
+
+	added := []TaskStoreResponse{}
+	for _, title := range []string{"Trash_restored", "Trash_purged", "Trash_expired"} {
+		res, err := Suite.Model.AddTask(ctx, TaskStoreRequest{Title: title, Task_Description: "Trash", Task_Status: true})
+		Suite.Require().NoError(err)
+		added = append(added, res)
 	}
 
-	wg.Add(1)
-	go Suite.Model.AddTask(task, &wg, resultChannel, errorChannel)
+	_, err := Suite.Model.RestoreTask(ctx, TrashTaskStoreRequest{ID: added[0].ID})
+	Suite.Suite.ErrorIs(err, ErrTrashedTaskNotFound, "Active tasks are not in the trash")
 
-	go func() {
+	err = Suite.Model.PurgeTask(ctx, TrashTaskStoreRequest{ID: added[0].ID})
+	Suite.Suite.ErrorIs(err, ErrTrashedTaskNotFound, "Active tasks cannot be purged")
 
-		for err := range errorChannel {
-			errme := "dError occured even before the Add Test Case Start! ." + err.Error()
-			Suite.Suite.NoError(err, errme)
-		}
-	}()
-	go func() {
+	for _, task := range added {
+		_, err := Suite.Model.DeleteTask(ctx, DeleteTaskStoreRequest{ID: task.ID})
+		Suite.Require().NoError(err)
+	}
 
-		for res := range resultChannel {
-			Suite.Suite.True(res.ID >= 1, fmt.Sprintf("Error Has occured in a Add Test Case %v", res.ID))
-			savedTaskID = int(res.ID)
-		}
-	}()
+	trash, err := Suite.Model.ListTask(ctx, ListTaskStore{Limit: 10, Page: 1, Filter: ListTaskFilter{Status: StatusDeleted, Title_Contains: "Trash_"}})
+	Suite.Require().NoError(err)
+	Suite.Suite.Len(trash, 3)
 
-	wg.Wait()
+	trashed, err := Suite.Model.GetTrashedTask(ctx, GetTask{ID: added[0].ID})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal(added[0].Version+1, trashed.Version)
 
-	for {
+	_, err = Suite.Model.RestoreTask(ctx, TrashTaskStoreRequest{ID: added[0].ID, Version: added[0].Version})
+	var conflictErr *VersionConflictError
+	Suite.Suite.ErrorAs(err, &conflictErr)
 
-		if savedTaskID >= 1 {
-			wg.Add(1)
+	restored, err := Suite.Model.RestoreTask(ctx, TrashTaskStoreRequest{ID: added[0].ID, Version: trashed.Version})
+	Suite.Require().NoError(err)
+	Suite.Suite.True(restored.Task.Task_Status)
+	Suite.Suite.Equal(trashed.Version+1, restored.Version)
 
-			go Suite.Model.GetTask(GetTask{
-				ID: int64(savedTaskID),
-			}, &wg, taskStoreResponseChannel, errorChannel)
+	res, err := Suite.Model.GetTask(ctx, GetTask{ID: added[0].ID})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal(restored, res)
 
-			go func() {
+	err = Suite.Model.PurgeTask(ctx, TrashTaskStoreRequest{ID: added[1].ID})
+	Suite.Require().NoError(err)
 
-				for err := range errorChannel {
-					Suite.Suite.NoError(err, "d2Error occured even before the Add Test Case Start! ."+err.Error())
-				}
-			}()
+	_, err = Suite.Model.GetTrashedTask(ctx, GetTask{ID: added[1].ID})
+	Suite.Suite.ErrorIs(err, ErrTrashedTaskNotFound)
 
-			go func() {
+	_, err = Suite.Model.RestoreTask(ctx, TrashTaskStoreRequest{ID: added[1].ID})
+	Suite.Suite.ErrorIs(err, ErrTrashedTaskNotFound, "Purged tasks are gone for good")
 
-				for res := range taskStoreResponseChannel {
-					Suite.Suite.NotNil(res, fmt.Sprintf("Error Has Occured with responce of GetTask !"))
-				}
-			}()
+	purged, err := Suite.Model.PurgeTrash(ctx, PurgeTrashStore{Before: time.Now().Add(-time.Hour)})
+	Suite.Require().NoError(err)
+	Suite.Suite.Zero(purged, "Nothing was deleted an hour ago")
 
-			wg.Wait()
-			break
-		}
+	purged, err = Suite.Model.PurgeTrash(ctx, PurgeTrashStore{Before: time.Now().Add(time.Minute)})
+	Suite.Require().NoError(err)
+	Suite.Suite.GreaterOrEqual(purged, int64(1))
+
+	_, err = Suite.Model.GetTrashedTask(ctx, GetTask{ID: added[2].ID})
+	Suite.Suite.ErrorIs(err, ErrTrashedTaskNotFound)
+
+	_, err = Suite.Model.GetTask(ctx, GetTask{ID: added[0].ID})
+	Suite.Suite.NoError(err, "Restored tasks are not purged")
+
+	_, err = Suite.Model.PurgeTrash(ctx, PurgeTrashStore{})
+	var validationErr *ValidationError
+	Suite.Suite.ErrorAs(err, &validationErr)
+}
+
+func (Suite *SuiteStruct) TestTransitionTask() {
+
+	ctx := context.Background()
+
+	added, err := Suite.Model.AddTask(ctx, TaskStoreRequest{Title: "Workflow", Task_Description: "Workflow", Task_Status: true})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal(DefaultWorkflow.Initial, added.Workflow_Status)
+
+	started, err := Suite.Model.TransitionTask(ctx, TransitionTaskStoreRequest{ID: added.ID, Version: added.Version, Workflow_Status: "in_progress"})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal("in_progress", started.Workflow_Status)
+	Suite.Suite.Equal(added.Version+1, started.Version)
+
+	_, err = Suite.Model.TransitionTask(ctx, TransitionTaskStoreRequest{ID: added.ID, Version: added.Version, Workflow_Status: "done"})
+	var conflictErr *VersionConflictError
+	Suite.Suite.ErrorAs(err, &conflictErr)
+
+	edited, err := Suite.Model.EditTask(ctx, UpdateTaskStoreRequest{ID: added.ID, Task: TaskStoreRequest{Title: "Workflow edited", Task_Description: "Workflow", Task_Status: true}})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal("in_progress", edited.Workflow_Status, "Writes keep the workflow status")
+
+	_, err = Suite.Model.PatchTask(ctx, PatchTaskStoreRequest{ID: added.ID, Patch: MergePatch(`{"Workflow_Status":"done"}`)})
+	var validationErr *ValidationError
+	Suite.Suite.ErrorAs(err, &validationErr, "Only transitions move the workflow status")
+
+	done, err := Suite.Model.TransitionTask(ctx, TransitionTaskStoreRequest{ID: added.ID, Workflow_Status: "done"})
+	Suite.Require().NoError(err)
+
+	res, err := Suite.Model.GetTask(ctx, GetTask{ID: added.ID})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal(done, res)
+
+	_, err = Suite.Model.TransitionTask(ctx, TransitionTaskStoreRequest{ID: added.ID, Workflow_Status: "todo"})
+	var transitionErr *TransitionError
+	Suite.Require().ErrorAs(err, &transitionErr, "done is terminal")
+	Suite.Suite.Equal("done", transitionErr.From)
+	Suite.Suite.Empty(transitionErr.Allowed)
+
+	_, err = Suite.Model.TransitionTask(ctx, TransitionTaskStoreRequest{ID: added.ID, Workflow_Status: "archived"})
+	Suite.Suite.ErrorAs(err, &validationErr)
+
+	resp, err := Suite.Model.ListTask(ctx, ListTaskStore{Limit: 10, Page: 1, Filter: ListTaskFilter{Workflow_Status: "done", Title_Contains: "Workflow"}, Fields: []string{"Workflow_Status"}})
+	Suite.Require().NoError(err)
+	Suite.Require().Len(resp, 1)
+	Suite.Suite.Equal("done", resp[0].Workflow_Status)
+
+	_, err = Suite.Model.DeleteTask(ctx, DeleteTaskStoreRequest{ID: added.ID})
+	Suite.Require().NoError(err)
+
+	_, err = Suite.Model.TransitionTask(ctx, TransitionTaskStoreRequest{ID: added.ID, Workflow_Status: "done"})
+	Suite.Suite.ErrorIs(err, ErrTaskNotFound, "Deleted tasks do not move")
+
+	_, err = Suite.Model.DeleteTask(ctx, DeleteTaskStoreRequest{ID: added.ID})
+	Suite.Suite.ErrorIs(err, ErrTaskNotFound, "A deleted task is only reachable through the trash")
+}
+
+func (Suite *SuiteStruct) TestParseWorkflow() {
+
+	flow, err := ParseWorkflow("", "", "")
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal(DefaultWorkflow, flow)
+
+	flow, err = ParseWorkflow("open", "open>review, review>open, review>closed", "closed")
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal([]string{"closed", "open", "review"}, flow.States())
+	Suite.Suite.Equal([]string{"open", "closed"}, flow.Transitions["review"])
+
+	_, err = ParseWorkflow("open", "open>closed, closed>open", "closed")
+	Suite.Suite.ErrorContains(err, "terminal")
+
+	_, err = ParseWorkflow("open", "open>review", "")
+	Suite.Suite.ErrorContains(err, "review")
+
+	_, err = ParseWorkflow("open", "open-closed", "closed")
+	Suite.Suite.ErrorContains(err, "from>to")
+}
+
+func (Suite *SuiteStruct) TestTaskHistory() {
+
+	ctx := WithAuditor(context.Background(), Auditor{Actor: "alice", Request_ID: "req-1"})
+
+	added, err := Suite.Model.AddTask(ctx, TaskStoreRequest{Title: "History", Task_Description: "Before", Task_Status: true})
+	Suite.Require().NoError(err)
+
+	_, err = Suite.Model.PatchTask(ctx, PatchTaskStoreRequest{ID: added.ID, Patch: MergePatch(`{"Task": {"Task_Description": "After"}}`)})
+	Suite.Require().NoError(err)
+
+	_, err = Suite.Model.TransitionTask(ctx, TransitionTaskStoreRequest{ID: added.ID, Workflow_Status: "in_progress"})
+	Suite.Require().NoError(err)
+
+	_, err = Suite.Model.EditTask(ctx, UpdateTaskStoreRequest{ID: added.ID + 1000, Task: added.Task})
+	Suite.Require().Error(err)
+
+	_, err = Suite.Model.DeleteTask(context.Background(), DeleteTaskStoreRequest{ID: added.ID})
+	Suite.Require().NoError(err)
+
+	err = Suite.Model.PurgeTask(ctx, TrashTaskStoreRequest{ID: added.ID})
+	Suite.Require().NoError(err)
+
+	page, err := Suite.Model.TaskHistory(ctx, TaskHistoryStore{ID: added.ID, Limit: 10, Page: 1})
+	Suite.Require().NoError(err)
+	Suite.Require().Equal(int64(5), page.Total_Count, "Failed writes leave no history")
+
+	actions := []string{}
+	for _, entry := range page.Items {
+		actions = append(actions, entry.Action)
+	}
+	Suite.Suite.Equal([]string{ActionCreated, ActionUpdated, ActionTransitioned, ActionDeleted, ActionPurged}, actions)
+
+	created := page.Items[0]
+	Suite.Suite.Equal("alice", created.Actor)
+	Suite.Suite.Equal("req-1", created.Request_ID)
+	Suite.Suite.Equal(int64(1), created.Version)
+	Suite.Suite.Equal(FieldChange{Before: nil, After: "History"}, created.Changes["Title"])
+	Suite.Suite.False(created.Changed_At.IsZero())
+
+	Suite.Suite.Equal(map[string]FieldChange{"Task_Description": {Before: "Before", After: "After"}}, page.Items[1].Changes)
+	Suite.Suite.Equal(map[string]FieldChange{"Workflow_Status": {Before: "todo", After: "in_progress"}}, page.Items[2].Changes)
+	Suite.Suite.Equal(SystemActor, page.Items[3].Actor)
+	Suite.Suite.NotContains(page.Items[3].Changes, "Task_Status", "Deleting sets Deleted_At only")
+	Suite.Suite.Equal(FieldChange{Before: "After", After: nil}, page.Items[4].Changes["Task_Description"])
+
+	page, err = Suite.Model.TaskHistory(ctx, TaskHistoryStore{ID: added.ID, Limit: 2, Page: 3})
+	Suite.Require().NoError(err)
+	Suite.Suite.Len(page.Items, 1)
+	Suite.Suite.Equal(ActionPurged, page.Items[0].Action)
+
+	_, err = Suite.Model.TaskHistory(ctx, TaskHistoryStore{ID: added.ID + 1000})
+	Suite.Suite.ErrorIs(err, ErrTaskNotFound)
+}
+
+func (Suite *SuiteStruct) TestRevisions() {
+
+	ctx := context.Background()
+
+	added, err := Suite.Model.AddTask(ctx, TaskStoreRequest{Title: "Revision", Task_Description: "First", Task_Status: true})
+	Suite.Require().NoError(err)
+
+	for _, description := range []string{"Second", "Accident"} {
+		_, err = Suite.Model.EditTask(ctx, UpdateTaskStoreRequest{ID: added.ID, Task: TaskStoreRequest{Title: "Revision", Task_Description: description, Task_Status: true}})
+		Suite.Require().NoError(err)
 	}
 
+	first, err := Suite.Model.GetTask(ctx, GetTask{ID: added.ID, Revision: 1})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal(added, first)
+
+	latest, err := Suite.Model.GetTask(ctx, GetTask{ID: added.ID, As_Of: time.Now().Add(time.Second)})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal(int64(3), latest.Version)
+	Suite.Suite.Equal("Accident", latest.Task.Task_Description)
+
+	_, err = Suite.Model.GetTask(ctx, GetTask{ID: added.ID, As_Of: time.Now().Add(-time.Hour)})
+	Suite.Suite.ErrorIs(err, ErrRevisionNotFound, "The task did not exist an hour ago")
+
+	_, err = Suite.Model.GetTask(ctx, GetTask{ID: added.ID, Revision: 9})
+	Suite.Suite.ErrorIs(err, ErrRevisionNotFound)
+
+	_, err = Suite.Model.GetTask(ctx, GetTask{ID: added.ID, Revision: 1, As_Of: time.Now()})
+	var validationErr *ValidationError
+	Suite.Suite.ErrorAs(err, &validationErr)
+
+	_, err = Suite.Model.RevertTask(ctx, RevertTaskStoreRequest{ID: added.ID, Version: 2, Revision: 2})
+	var conflictErr *VersionConflictError
+	Suite.Suite.ErrorAs(err, &conflictErr)
+
+	reverted, err := Suite.Model.RevertTask(ctx, RevertTaskStoreRequest{ID: added.ID, Version: 3, Revision: 2})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal(int64(4), reverted.Version)
+	Suite.Suite.Equal("Second", reverted.Task.Task_Description)
+
+	current, err := Suite.Model.GetTask(ctx, GetTask{ID: added.ID})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal(reverted, current)
+
+	history, err := Suite.Model.TaskHistory(ctx, TaskHistoryStore{ID: added.ID, Limit: 10, Page: 1})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal(ActionReverted, history.Items[len(history.Items)-1].Action)
+
+	started, err := Suite.Model.TransitionTask(ctx, TransitionTaskStoreRequest{ID: added.ID, Workflow_Status: "in_progress"})
+	Suite.Require().NoError(err)
+	_, err = Suite.Model.EditTask(ctx, UpdateTaskStoreRequest{ID: added.ID, Task: TaskStoreRequest{Title: "Revision", Task_Description: "Paused", Task_Status: false}})
+	Suite.Require().NoError(err)
+
+	restored, err := Suite.Model.RevertTask(ctx, RevertTaskStoreRequest{ID: added.ID, Revision: started.Version})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal(started.Task, restored.Task, "The whole row comes back, Task_Status included")
+	Suite.Suite.Equal("in_progress", restored.Workflow_Status)
+
+	restored, err = Suite.Model.RevertTask(ctx, RevertTaskStoreRequest{ID: added.ID, Revision: 1})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal(DefaultWorkflow.Initial, restored.Workflow_Status, "Reverting may go back against the transitions")
+
+	var workflowErr *ValidationError
+	Suite.Suite.ErrorAs(revertedWorkflowStatus(Workflow{Initial: "open", Terminal: []string{"open"}}, restored), &workflowErr, "The workflow no longer has todo")
+
+	_, err = Suite.Model.DeleteTask(ctx, DeleteTaskStoreRequest{ID: added.ID})
+	Suite.Require().NoError(err)
+
+	_, err = Suite.Model.RevertTask(ctx, RevertTaskStoreRequest{ID: added.ID, Revision: 1})
+	Suite.Suite.ErrorIs(err, ErrTaskNotFound, "Deleted tasks have to be restored first")
+
+	old, err := Suite.Model.GetTask(ctx, GetTask{ID: added.ID, Revision: restored.Version})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal(restored, old, "Revisions outlive the delete")
+
+	var trashedErr *TrashedRevisionError
+	_, err = Suite.Model.GetTask(ctx, GetTask{ID: added.ID, Revision: restored.Version + 1})
+	Suite.Suite.ErrorAs(err, &trashedErr, "The revision the delete wrote is not a live task")
+	Suite.Suite.ErrorIs(err, ErrTaskNotFound)
+
+	_, err = Suite.Model.GetTask(ctx, GetTask{ID: added.ID, As_Of: time.Now().Add(time.Second)})
+	Suite.Suite.ErrorAs(err, &trashedErr, "Nor is the task as of now, while it is in the trash")
+
+	err = Suite.Model.PurgeTask(ctx, TrashTaskStoreRequest{ID: added.ID})
+	Suite.Require().NoError(err)
+
+	_, err = Suite.Model.GetTask(ctx, GetTask{ID: added.ID, Revision: 1})
+	Suite.Suite.ErrorIs(err, ErrRevisionNotFound, "Purging drops the revisions")
+}
+
+func (Suite *SuiteStruct) TestBulk() {
+
+	ctx := context.Background()
+	task := func(Title string) TaskStoreRequest {
+		return TaskStoreRequest{Title: Title, Task_Description: "Bulk", Task_Status: true}
+	}
+
+	created, err := Suite.Model.BulkCreate(ctx, BulkCreateStore{Mode: BulkAtomic, Tasks: []TaskStoreRequest{task("Bulk_1"), task("Bulk_2"), task("Bulk_3")}})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal(3, created.Applied)
+
+	for index, item := range created.Items {
+		Suite.Require().NoError(item.Err)
+		stored, err := Suite.Model.GetTask(ctx, GetTask{ID: item.Task.ID})
+		Suite.Require().NoError(err)
+		Suite.Suite.Equal("Bulk_"+strconv.Itoa(index+1), stored.Task.Title)
+		Suite.Suite.Equal(item.Task, stored)
+	}
+
+	aborted, err := Suite.Model.BulkCreate(ctx, BulkCreateStore{Mode: BulkAtomic, Tasks: []TaskStoreRequest{task("Bulk_aborted"), task("")}})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal(0, aborted.Applied)
+	var abortedErr *BulkAbortedError
+	Suite.Suite.ErrorAs(aborted.Items[0].Err, &abortedErr)
+	var validationErr *ValidationError
+	Suite.Suite.ErrorAs(aborted.Items[1].Err, &validationErr)
+
+	list, err := Suite.Model.ListTask(ctx, ListTaskStore{Limit: 10, Page: 1, Filter: ListTaskFilter{Title_Contains: "Bulk_aborted"}})
+	Suite.Require().NoError(err)
+	Suite.Suite.Empty(list, "Atomic requests write nothing when an item fails")
+
+	partial, err := Suite.Model.BulkCreate(ctx, BulkCreateStore{Mode: BulkBestEffort, Tasks: []TaskStoreRequest{task(""), task("Bulk_4")}})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal(1, partial.Applied)
+	Suite.Suite.Error(partial.Items[0].Err)
+	Suite.Suite.True(partial.Items[1].Task.ID > created.Items[2].Task.ID)
+
+	first, second := created.Items[0].Task, created.Items[1].Task
+	updated, err := Suite.Model.BulkUpdate(ctx, BulkUpdateStore{Mode: BulkBestEffort, Tasks: []UpdateTaskStoreRequest{
+		{ID: first.ID, Version: first.Version, Task: task("Bulk_1_updated")},
+		{ID: second.ID, Version: second.Version + 1, Task: task("Bulk_2_stale")},
+		{ID: first.ID, Task: task("Bulk_1_twice")},
+		{ID: first.ID + 1000, Task: task("Bulk_missing")},
+	}})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal(1, updated.Applied)
+	Suite.Suite.Equal(first.Version+1, updated.Items[0].Task.Version)
+	var conflictErr *VersionConflictError
+	Suite.Suite.ErrorAs(updated.Items[1].Err, &conflictErr)
+	Suite.Suite.ErrorAs(updated.Items[2].Err, &validationErr)
+	Suite.Suite.ErrorIs(updated.Items[3].Err, ErrTaskNotFound)
+
+	stored, err := Suite.Model.GetTask(ctx, GetTask{ID: first.ID})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal(updated.Items[0].Task, stored)
+
+	_, err = Suite.Model.BulkDelete(ctx, BulkDeleteStore{Mode: BulkAtomic, Tasks: []DeleteTaskStoreRequest{{ID: second.ID}, {ID: first.ID, Version: first.Version}}})
+	Suite.Require().NoError(err)
+
+	_, err = Suite.Model.GetTask(ctx, GetTask{ID: second.ID})
+	Suite.Suite.NoError(err, "Atomic deletes keep every task when one fails")
+
+	deleted, err := Suite.Model.BulkDelete(ctx, BulkDeleteStore{Mode: BulkAtomic, Tasks: []DeleteTaskStoreRequest{{ID: first.ID}, {ID: second.ID, Version: second.Version}}})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal(2, deleted.Applied)
+	Suite.Suite.True(deleted.Items[1].Task.Task.Task_Status, "Deleting sets Deleted_At only")
+
+	history, err := Suite.Model.TaskHistory(ctx, TaskHistoryStore{ID: first.ID, Limit: 10, Page: 1})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal(int64(3), history.Total_Count)
+
+	_, err = Suite.Model.BulkCreate(ctx, BulkCreateStore{Mode: "some", Tasks: make([]TaskStoreRequest, MaxBulkItems+1)})
+	Suite.Suite.ErrorAs(err, &validationErr)
+	Suite.Suite.Len(validationErr.Violations, 2)
+}
+
+func (Suite *SuiteStruct) TestIdempotencyKeys() {
+	ctx := context.Background()
+	request := IdempotencyKeyStore{Key: "Idempotency_" + strconv.FormatBool(Suite.Memory), Request_Hash: "first"}
+
+	reserved, err := Suite.Model.ReserveIdempotencyKey(ctx, request)
+	Suite.Require().NoError(err)
+	Suite.Suite.True(reserved.Reserved)
+
+	_, err = Suite.Model.ReserveIdempotencyKey(ctx, request)
+	var inUseErr *IdempotencyKeyInUseError
+	Suite.Suite.ErrorAs(err, &inUseErr, "A running request holds its key")
+
+	err = Suite.Model.CompleteIdempotencyKey(ctx, IdempotencyRecord{
+		Key:          request.Key,
+		Request_Hash: request.Request_Hash,
+		Status:       201,
+		Headers:      map[string][]string{"Location": {"/v1/tasks/1"}},
+		Body:         []byte(`{"ID":1}`),
+	})
+	Suite.Require().NoError(err)
+
+	replayed, err := Suite.Model.ReserveIdempotencyKey(ctx, request)
+	Suite.Require().NoError(err)
+	Suite.Suite.False(replayed.Reserved)
+	Suite.Suite.Equal(201, replayed.Status)
+	Suite.Suite.Equal([]string{"/v1/tasks/1"}, replayed.Headers["Location"])
+	Suite.Suite.Equal(`{"ID":1}`, string(replayed.Body))
+
+	_, err = Suite.Model.ReserveIdempotencyKey(ctx, IdempotencyKeyStore{Key: request.Key, Request_Hash: "second"})
+	var reusedErr *IdempotencyKeyReusedError
+	Suite.Suite.ErrorAs(err, &reusedErr)
+
+	err = Suite.Model.CompleteIdempotencyKey(ctx, IdempotencyRecord{Key: request.Key, Request_Hash: request.Request_Hash, Status: 200})
+	Suite.Suite.ErrorIs(err, ErrIdempotencyKeyLost, "A stored response is never overwritten")
+
+	released := IdempotencyKeyStore{Key: request.Key + "_released", Request_Hash: "first"}
+	_, err = Suite.Model.ReserveIdempotencyKey(ctx, released)
+	Suite.Require().NoError(err)
+	Suite.Require().NoError(Suite.Model.ReleaseIdempotencyKey(ctx, released))
+
+	reserved, err = Suite.Model.ReserveIdempotencyKey(ctx, IdempotencyKeyStore{Key: released.Key, Request_Hash: "second"})
+	Suite.Require().NoError(err)
+	Suite.Suite.True(reserved.Reserved, "A released key is free for any request")
+
+	purged, err := Suite.Model.PurgeIdempotencyKeys(ctx, PurgeIdempotencyKeyStore{Before: time.Now().Add(time.Hour)})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal(int64(2), purged)
+
+	reserved, err = Suite.Model.ReserveIdempotencyKey(ctx, IdempotencyKeyStore{Key: request.Key, Request_Hash: "second"})
+	Suite.Require().NoError(err)
+	Suite.Suite.True(reserved.Reserved, "A purged key is free for any request")
+
+	_, err = Suite.Model.ReserveIdempotencyKey(ctx, IdempotencyKeyStore{})
+	var validationErr *ValidationError
+	Suite.Suite.ErrorAs(err, &validationErr)
+	Suite.Suite.Len(validationErr.Violations, 2)
+}
+
+func (Suite *SuiteStruct) TestTransactRetries() {
+	model, ok := Suite.Model.(*ModelStruct)
+	if !ok {
+		Suite.T().Skip("MemoryModelStruct has no transactions")
+	}
+
+	ctx := context.Background()
+	busy := sqlite3.Error{Code: sqlite3.ErrBusy}
+	retries := txMetric("retries.busy")
+
+	attempts := 0
+	err := model.transact(ctx, func(Tx *sql.Tx) error {
+		attempts++
+		if attempts < 3 {
+			return busy
+		}
+		return nil
+	})
+	Suite.Require().NoError(err)
+	Suite.Suite.Equal(3, attempts)
+	Suite.Suite.Equal(retries+2, txMetric("retries.busy"))
+
+	attempts = 0
+	err = model.transact(ctx, func(Tx *sql.Tx) error {
+		attempts++
+		return busy
+	})
+	var unavailableErr *UnavailableError
+	Suite.Suite.ErrorAs(err, &unavailableErr)
+	Suite.Suite.Equal(model.Retry.MaxAttempts, attempts)
+
+	attempts = 0
+	err = model.transact(ctx, func(Tx *sql.Tx) error {
+		attempts++
+		return taskNotFound(1)
+	})
+	Suite.Suite.ErrorIs(err, ErrTaskNotFound)
+	Suite.Suite.Equal(1, attempts, "Only conflicts between transactions are retried")
+
+	err = model.transact(ctx, func(Tx *sql.Tx) error {
+		Tx.Rollback()
+		return taskNotFound(1)
+	})
+	Suite.Suite.ErrorIs(err, ErrTaskNotFound, "A failed rollback does not hide why the transaction failed")
+
+	deadline, cancelFunc := context.WithTimeout(ctx, time.Minute)
+	defer cancelFunc()
+
+	started := time.Now()
+	Suite.Suite.False(sleep(deadline, time.Hour), "No retry outlives the deadline of the request")
+	Suite.Suite.Less(time.Since(started), time.Second)
+	Suite.Suite.True(sleep(deadline, time.Millisecond))
+}
+
+func (Suite *SuiteStruct) TestNewModelRejectsUnknownDriver() {
+	config := Configurator.NewConfigurator()
+	config.DbDriver = "postgres"
+
+	_, err := NewModel(*config)
+	Suite.ErrorContains(err, "postgres")
+}
+
+func (Suite *SuiteStruct) TestCancelledContext() {
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	cancelFunc()
+
+	_, err := Suite.Model.AddTask(ctx, TaskStoreRequest{
+		Title:            "Cancelled",
+		Task_Description: "Task",
+		Task_Status:      true,
+	})
+
+	Suite.Suite.ErrorIs(err, context.Canceled)
+}
+
+func txMetric(Name string) int64 {
+	counter, ok := TxMetrics.Get(Name).(*expvar.Int)
+	if !ok {
+		return 0
+	}
+	return counter.Value()
 }
 
 func TestSuite(Testor *testing.T) {
 	suite.Run(Testor, new(SuiteStruct))
 }
+
+func TestMemorySuite(Testor *testing.T) {
+	suite.Run(Testor, &SuiteStruct{Memory: true})
+}
+
+// mysqlModel migrates and opens the MySQL database TASKMANAGER_TEST_MYSQL
+// names, with a session that asks for a zone other than UTC, or skips.
+func mysqlModel(Testor *testing.T) *ModelStruct {
+	dsn, ok := os.LookupEnv(Configurator.EnvPrefix + "_TEST_MYSQL")
+	if !ok {
+		Testor.Skip(Configurator.EnvPrefix + "_TEST_MYSQL is not set")
+	}
+
+	mysqlConfig, err := mysql.ParseDSN(dsn)
+	require.NoError(Testor, err)
+	if mysqlConfig.Params == nil {
+		mysqlConfig.Params = map[string]string{}
+	}
+	mysqlConfig.Params["time_zone"] = "'+05:00'"
+
+	config := Configurator.NewConfigurator()
+	config.DbDriver = MySQLDriver
+	config.DbConnString = mysqlConfig.FormatDSN()
+	require.NoError(Testor, config.LoadDBInstance())
+	Testor.Cleanup(func() { config.SqlDBConn.Close() })
+
+	migrator, err := Migrator.NewMigrator(*config)
+	require.NoError(Testor, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(Testor, err)
+
+	model, err := NewModel(*config)
+	require.NoError(Testor, err)
+
+	return &model
+}
+
+func TestMySQLAsOfIgnoresSessionZone(Testor *testing.T) {
+	model := mysqlModel(Testor)
+	ctx := context.Background()
+
+	added, err := model.AddTask(ctx, TaskStoreRequest{Title: "Zone", Task_Description: "As of", Task_Status: true})
+	require.NoError(Testor, err)
+
+	current, err := model.GetTask(ctx, GetTask{ID: added.ID, As_Of: time.Now().Add(time.Second)})
+	require.NoError(Testor, err, "a session ahead of UTC would put the revision hours in the future")
+	require.Equal(Testor, added, current)
+
+	_, err = model.GetTask(ctx, GetTask{ID: added.ID, As_Of: time.Now().Add(-time.Hour)})
+	require.ErrorIs(Testor, err, ErrRevisionNotFound)
+}
+
+func TestMySQLPurgeTrashIgnoresSessionZone(Testor *testing.T) {
+	model := mysqlModel(Testor)
+	ctx := context.Background()
+
+	added, err := model.AddTask(ctx, TaskStoreRequest{Title: "Zone", Task_Description: "Purge", Task_Status: true})
+	require.NoError(Testor, err)
+	_, err = model.DeleteTask(ctx, DeleteTaskStoreRequest{ID: added.ID})
+	require.NoError(Testor, err)
+
+	_, err = model.PurgeTrash(ctx, PurgeTrashStore{Before: time.Now().Add(-time.Minute)})
+	require.NoError(Testor, err)
+	_, err = model.GetTrashedTask(ctx, GetTask{ID: added.ID})
+	require.NoError(Testor, err, "deleted a moment ago, the task is not older than the bound")
+
+	_, err = model.PurgeTrash(ctx, PurgeTrashStore{Before: time.Now().Add(time.Minute)})
+	require.NoError(Testor, err)
+	_, err = model.GetTrashedTask(ctx, GetTask{ID: added.ID})
+	require.ErrorIs(Testor, err, ErrTrashedTaskNotFound, "deleted before the bound, the task is purged")
+}
@@ -0,0 +1,639 @@
+package Model
+
+import (
+	"cmp"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryModelStruct is an in-memory ModelInterface with the same semantics
+// as ModelStruct: soft delete through Deleted_At, Page/Limit pagination and
+// the same validation errors. It is meant for tests and throwaway servers.
+type MemoryModelStruct struct {
+	mutex        sync.RWMutex
+	lastID       int64
+	rows         map[int64]taskRow
+	PageTokenKey []byte
+	index        *SearchIndex
+	history      []HistoryEntry
+	revisions    map[int64][]memoryRevision
+	idempotency  map[string]IdempotencyRecord
+	Workflow     Workflow
+	// IdempotencyWindow is how long responses stored under an
+	// Idempotency-Key are replayed, DefaultIdempotencyWindow when zero.
+	IdempotencyWindow time.Duration
+}
+
+// taskRow is one TaskStore row. MemoryModelStruct keeps its tasks as rows
+// and ModelStruct scans list pages into them.
+type taskRow struct {
+	ID              int64
+	Version         int64
+	Workflow_Status string
+	Task            TaskStoreRequest
+	Edited_On       time.Time
+	Created_At      time.Time
+	// Deleted_At is zero unless the task is in the trash.
+	Deleted_At time.Time
+}
+
+// trashed reports whether the task is in the trash. Task_Status is the
+// task's own flag and says nothing about deletion.
+func (Row taskRow) trashed() bool {
+	return !Row.Deleted_At.IsZero()
+}
+
+func (Row taskRow) response() TaskStoreResponse {
+	return TaskStoreResponse{
+		ID:              Row.ID,
+		Version:         Row.Version,
+		Workflow_Status: Row.Workflow_Status,
+		Task:            Row.Task,
+	}
+}
+
+var _ ModelInterface = (*MemoryModelStruct)(nil)
+
+func NewMemoryModel() *MemoryModelStruct {
+	return &MemoryModelStruct{
+		rows:         map[int64]taskRow{},
+		revisions:    map[int64][]memoryRevision{},
+		idempotency:  map[string]IdempotencyRecord{},
+		PageTokenKey: NewPageTokenKey(""),
+		index:        NewSearchIndex(),
+		Workflow:     DefaultWorkflow,
+
+		IdempotencyWindow: DefaultIdempotencyWindow,
+	}
+}
+
+// store writes Row and keeps the search index in step with it. The caller
+// holds the mutex.
+func (Model *MemoryModelStruct) store(Row taskRow) {
+	Model.rows[Row.ID] = Row
+
+	if !Row.trashed() {
+		Model.index.Put(Row.response())
+	} else {
+		Model.index.Remove(Row.ID)
+	}
+}
+
+// record appends the change of a task from Before to After to the history
+// and keeps After as a revision, like ModelStruct does. The caller holds the
+// mutex.
+func (Model *MemoryModelStruct) record(Ctx context.Context, Action string, Before *TaskStoreResponse, After *TaskStoreResponse) {
+	entry := newHistoryEntry(Ctx, Action, Before, After)
+	entry.ID = int64(len(Model.history)) + 1
+	entry.Changed_At = time.Now().UTC().Truncate(time.Second)
+	Model.history = append(Model.history, entry)
+
+	if After == nil {
+		delete(Model.revisions, entry.Task_ID)
+		return
+	}
+
+	Model.revisions[After.ID] = append(Model.revisions[After.ID], memoryRevision{Task: *After, Revised_At: entry.Changed_At, Deleted_At: Model.rows[After.ID].Deleted_At})
+}
+
+// checkVersion returns the row of active task ID, failing with a
+// VersionConflictError when Expected is set and no longer matches it. The
+// caller holds the mutex.
+func (Model *MemoryModelStruct) checkVersion(ID int64, Expected int64) (taskRow, error) {
+	row, ok := Model.rows[ID]
+	if !ok || row.trashed() {
+		return taskRow{}, taskNotFound(ID)
+	}
+
+	if Expected > 0 && Expected != row.Version {
+		return taskRow{}, &VersionConflictError{ID: ID, Version: Expected}
+	}
+
+	return row, nil
+}
+
+func (Model *MemoryModelStruct) AddTask(Ctx context.Context, Task TaskStoreRequest) (TaskStoreResponse, error) {
+	if err := Ctx.Err(); err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	err := validateAddTask(Task).OrNil()
+
+	if err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	Model.mutex.Lock()
+	Model.lastID++
+	now := time.Now().UTC().Truncate(time.Second)
+	row := taskRow{
+		ID:              Model.lastID,
+		Version:         1,
+		Workflow_Status: Model.Workflow.Initial,
+		Task:            Task,
+		Edited_On:       now,
+		Created_At:      now,
+	}
+	Model.store(row)
+	created := row.response()
+	Model.record(Ctx, ActionCreated, nil, &created)
+	Model.mutex.Unlock()
+
+	return row.response(), nil
+}
+
+func (Model *MemoryModelStruct) GetTask(Ctx context.Context, Task GetTask) (TaskStoreResponse, error) {
+	if err := Ctx.Err(); err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	err := validateGetTask(Task).OrNil()
+
+	if err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	Model.mutex.RLock()
+	row, ok := Model.rows[Task.ID]
+	if Task.Revision > 0 || !Task.As_Of.IsZero() {
+		defer Model.mutex.RUnlock()
+		return Model.revision(Task)
+	}
+	Model.mutex.RUnlock()
+
+	if !ok || row.trashed() {
+		return TaskStoreResponse{}, taskNotFound(Task.ID)
+	}
+
+	return row.response(), nil
+}
+
+func (Model *MemoryModelStruct) EditTask(Ctx context.Context, Task UpdateTaskStoreRequest) (TaskStoreResponse, error) {
+	if err := Ctx.Err(); err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	err := validateEditTask(Task).OrNil()
+
+	if err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	Model.mutex.Lock()
+	defer Model.mutex.Unlock()
+
+	row, err := Model.checkVersion(Task.ID, Task.Version)
+	if err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	before := row.response()
+	row.Task = Task.Task
+	row.Version++
+	row.Edited_On = time.Now().UTC().Truncate(time.Second)
+	Model.store(row)
+	after := row.response()
+	Model.record(Ctx, ActionUpdated, &before, &after)
+
+	return row.response(), nil
+}
+
+func (Model *MemoryModelStruct) PatchTask(Ctx context.Context, Task PatchTaskStoreRequest) (TaskStoreResponse, error) {
+	if err := Ctx.Err(); err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	err := validateGetTask(GetTask{ID: Task.ID}).OrNil()
+
+	if err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	Model.mutex.Lock()
+	defer Model.mutex.Unlock()
+
+	row, err := Model.checkVersion(Task.ID, Task.Version)
+	if err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	patched, columns, err := applyTaskPatch(row.response(), Task.Patch)
+	if err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	if len(columns) > 0 {
+		before := row.response()
+		row.Task = patched
+		row.Version++
+		row.Edited_On = time.Now().UTC().Truncate(time.Second)
+		Model.store(row)
+		after := row.response()
+		Model.record(Ctx, ActionUpdated, &before, &after)
+	}
+
+	return row.response(), nil
+}
+
+func (Model *MemoryModelStruct) TaskWorkflow() Workflow {
+	return Model.Workflow
+}
+
+func (Model *MemoryModelStruct) TransitionTask(Ctx context.Context, Task TransitionTaskStoreRequest) (TaskStoreResponse, error) {
+	if err := Ctx.Err(); err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	err := validateTransitionTask(Task, Model.Workflow).OrNil()
+
+	if err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	Model.mutex.Lock()
+	defer Model.mutex.Unlock()
+
+	row, err := Model.checkVersion(Task.ID, Task.Version)
+	if err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	err = Model.Workflow.transition(Task.ID, row.Workflow_Status, Task.Workflow_Status)
+	if err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	before := row.response()
+	row.Workflow_Status = Task.Workflow_Status
+	row.Version++
+	row.Edited_On = time.Now().UTC().Truncate(time.Second)
+	Model.store(row)
+	after := row.response()
+	Model.record(Ctx, ActionTransitioned, &before, &after)
+
+	return row.response(), nil
+}
+
+func (Model *MemoryModelStruct) DeleteTask(Ctx context.Context, Task DeleteTaskStoreRequest) (DeleteTaskStoreResponse, error) {
+	if err := Ctx.Err(); err != nil {
+		return DeleteTaskStoreResponse{}, err
+	}
+
+	Model.mutex.Lock()
+	defer Model.mutex.Unlock()
+
+	row, err := Model.checkVersion(Task.ID, Task.Version)
+	if err != nil {
+		return DeleteTaskStoreResponse{}, err
+	}
+
+	before := row.response()
+	row.Version++
+	row.Edited_On = time.Now().UTC().Truncate(time.Second)
+	row.Deleted_At = row.Edited_On
+	Model.store(row)
+	after := row.response()
+	Model.record(Ctx, ActionDeleted, &before, &after)
+
+	return DeleteTaskStoreResponse{
+		ID:     Task.ID,
+		Status: true,
+		Task:   Task.Task,
+	}, nil
+}
+
+func (Model *MemoryModelStruct) ListTask(Ctx context.Context, Task ListTaskStore) ([]TaskStoreResponse, error) {
+	page, err := Model.PageTask(Ctx, Task)
+	if err != nil {
+		return []TaskStoreResponse{}, err
+	}
+	return page.Items, nil
+}
+
+func (Model *MemoryModelStruct) PageTask(Ctx context.Context, Task ListTaskStore) (ListTaskPage, error) {
+	if err := Ctx.Err(); err != nil {
+		return ListTaskPage{}, err
+	}
+
+	err := validateListTask(Task).OrNil()
+
+	if err != nil {
+		return ListTaskPage{}, err
+	}
+
+	if Task.Limit < 1 || Task.Page < 1 {
+		Task.Limit = 10
+		Task.Page = 1
+	}
+
+	Task.Offset = (Task.Page - 1) * Task.Limit
+
+	keys := sortKeys(Task.Sort)
+	compare := func(A taskRow, B taskRow) int {
+		for _, key := range keys {
+			order := memoryCompare[key.Field](A, B)
+			if key.Descending {
+				order = -order
+			}
+			if order != 0 {
+				return order
+			}
+		}
+		return 0
+	}
+
+	var cursor *taskRow
+
+	if len(Task.Page_Token) > 0 {
+		token, err := decodePageToken(Model.PageTokenKey, Task.Page_Token, Task)
+		if err != nil {
+			return ListTaskPage{}, err
+		}
+		row, err := cursorRow(Task, token)
+		if err != nil {
+			return ListTaskPage{}, err
+		}
+		cursor = &row
+		Task.Offset = 0
+	}
+
+	Model.mutex.RLock()
+	rows := make([]taskRow, 0, len(Model.rows))
+	matched := int64(0)
+	for _, row := range Model.rows {
+		if !memoryFilter(Task.Filter, row) {
+			continue
+		}
+		matched++
+		if cursor == nil || compare(row, *cursor) > 0 {
+			rows = append(rows, row)
+		}
+	}
+	Model.mutex.RUnlock()
+
+	sort.Slice(rows, func(i, j int) bool { return compare(rows[i], rows[j]) < 0 })
+
+	// Hand pageOf one row past the page, like the SQL probe does.
+	if Task.Offset > int64(len(rows)) {
+		Task.Offset = int64(len(rows))
+	}
+	rows = rows[Task.Offset:]
+	if int64(len(rows)) > Task.Limit+1 {
+		rows = rows[:Task.Limit+1]
+	}
+
+	page := pageOf(Model.PageTokenKey, Task, rows)
+
+	if Task.Count_Total {
+		page.Total_Count = &matched
+	}
+
+	return page, nil
+}
+
+// trashedRow returns the row of task ID when it is in the trash, failing
+// with a VersionConflictError when Expected is set and no longer matches it.
+// The caller holds the mutex.
+func (Model *MemoryModelStruct) trashedRow(ID int64, Expected int64) (taskRow, error) {
+	row, ok := Model.rows[ID]
+	if !ok || !row.trashed() {
+		return taskRow{}, trashedTaskNotFound(ID)
+	}
+
+	if Expected > 0 && Expected != row.Version {
+		return taskRow{}, &VersionConflictError{ID: ID, Version: Expected}
+	}
+
+	return row, nil
+}
+
+func (Model *MemoryModelStruct) GetTrashedTask(Ctx context.Context, Task GetTask) (TaskStoreResponse, error) {
+	if err := Ctx.Err(); err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	err := validateGetTask(Task).OrNil()
+
+	if err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	Model.mutex.RLock()
+	row, err := Model.trashedRow(Task.ID, 0)
+	Model.mutex.RUnlock()
+
+	if err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	return row.response(), nil
+}
+
+func (Model *MemoryModelStruct) RestoreTask(Ctx context.Context, Task TrashTaskStoreRequest) (TaskStoreResponse, error) {
+	if err := Ctx.Err(); err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	err := validateGetTask(GetTask{ID: Task.ID}).OrNil()
+
+	if err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	Model.mutex.Lock()
+	defer Model.mutex.Unlock()
+
+	row, err := Model.trashedRow(Task.ID, Task.Version)
+	if err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	before := row.response()
+	row.Version++
+	row.Edited_On = time.Now().UTC().Truncate(time.Second)
+	row.Deleted_At = time.Time{}
+	Model.store(row)
+	after := row.response()
+	Model.record(Ctx, ActionRestored, &before, &after)
+
+	return row.response(), nil
+}
+
+func (Model *MemoryModelStruct) PurgeTask(Ctx context.Context, Task TrashTaskStoreRequest) error {
+	if err := Ctx.Err(); err != nil {
+		return err
+	}
+
+	err := validateGetTask(GetTask{ID: Task.ID}).OrNil()
+
+	if err != nil {
+		return err
+	}
+
+	Model.mutex.Lock()
+	defer Model.mutex.Unlock()
+
+	row, err := Model.trashedRow(Task.ID, Task.Version)
+	if err != nil {
+		return err
+	}
+
+	delete(Model.rows, Task.ID)
+	purged := row.response()
+	Model.record(Ctx, ActionPurged, &purged, nil)
+
+	return nil
+}
+
+func (Model *MemoryModelStruct) PurgeTrash(Ctx context.Context, Task PurgeTrashStore) (int64, error) {
+	if err := Ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	err := validatePurgeTrash(Task).OrNil()
+
+	if err != nil {
+		return 0, err
+	}
+
+	Model.mutex.Lock()
+	defer Model.mutex.Unlock()
+
+	purged := int64(0)
+	for id, row := range Model.rows {
+		if row.trashed() && row.Deleted_At.Before(Task.Before) {
+			delete(Model.rows, id)
+			expired := row.response()
+			Model.record(Ctx, ActionPurged, &expired, nil)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+func (Model *MemoryModelStruct) TaskHistory(Ctx context.Context, Task TaskHistoryStore) (TaskHistoryPage, error) {
+	if err := Ctx.Err(); err != nil {
+		return TaskHistoryPage{}, err
+	}
+
+	err := validateGetTask(GetTask{ID: Task.ID}).OrNil()
+
+	if err != nil {
+		return TaskHistoryPage{}, err
+	}
+
+	if Task.Limit < 1 || Task.Page < 1 {
+		Task.Limit = 10
+		Task.Page = 1
+	}
+
+	Model.mutex.RLock()
+	defer Model.mutex.RUnlock()
+
+	entries := []HistoryEntry{}
+	for _, entry := range Model.history {
+		if entry.Task_ID == Task.ID {
+			entries = append(entries, entry)
+		}
+	}
+
+	if _, ok := Model.rows[Task.ID]; !ok && len(entries) <= 0 {
+		return TaskHistoryPage{}, taskNotFound(Task.ID)
+	}
+
+	page := TaskHistoryPage{Items: []HistoryEntry{}, Total_Count: int64(len(entries))}
+	offset := min((Task.Page-1)*Task.Limit, page.Total_Count)
+	page.Items = append(page.Items, entries[offset:min(offset+Task.Limit, page.Total_Count)]...)
+
+	return page, nil
+}
+
+func (Model *MemoryModelStruct) SearchTask(Ctx context.Context, Task SearchTaskStore) (SearchTaskPage, error) {
+	if err := Ctx.Err(); err != nil {
+		return SearchTaskPage{}, err
+	}
+
+	err := validateSearchTask(Task).OrNil()
+
+	if err != nil {
+		return SearchTaskPage{}, err
+	}
+
+	if Task.Limit < 1 || Task.Page < 1 {
+		Task.Limit = 10
+		Task.Page = 1
+	}
+
+	hits, total := Model.index.Search(ParseSearchQuery(Task.Query), Task.Limit, (Task.Page-1)*Task.Limit)
+
+	return SearchTaskPage{Items: hits, Total_Count: total}, nil
+}
+
+func memoryFilter(Filter ListTaskFilter, Row taskRow) bool {
+	switch Filter.Status {
+	case StatusDeleted:
+		if !Row.trashed() {
+			return false
+		}
+	case StatusAll:
+	default:
+		if Row.trashed() {
+			return false
+		}
+	}
+
+	if len(Filter.Workflow_Status) > 0 && Row.Workflow_Status != Filter.Workflow_Status {
+		return false
+	}
+
+	if !containsFold(Row.Task.Title, Filter.Title_Contains) || !containsFold(Row.Task.Task_Description, Filter.Description_Contains) {
+		return false
+	}
+
+	return inRange(Row.Created_At, Filter.Created_After, Filter.Created_Before) &&
+		inRange(Row.Edited_On, Filter.Edited_After, Filter.Edited_Before)
+}
+
+// containsFold matches like the LIKE '%...%' of ModelStruct, ignoring case.
+func containsFold(Value string, Part string) bool {
+	return strings.Contains(strings.ToLower(Value), strings.ToLower(Part))
+}
+
+func inRange(Value time.Time, After time.Time, Before time.Time) bool {
+	if !After.IsZero() && Value.Before(After) {
+		return false
+	}
+	if !Before.IsZero() && !Value.Before(Before) {
+		return false
+	}
+	return true
+}
+
+var memoryCompare = map[string]func(A taskRow, B taskRow) int{
+	"ID":    func(A taskRow, B taskRow) int { return cmp.Compare(A.ID, B.ID) },
+	"Title": func(A taskRow, B taskRow) int { return strings.Compare(A.Task.Title, B.Task.Title) },
+	"Task_Description": func(A taskRow, B taskRow) int {
+		return strings.Compare(A.Task.Task_Description, B.Task.Task_Description)
+	},
+	"Task_Status": func(A taskRow, B taskRow) int { return compareBool(A.Task.Task_Status, B.Task.Task_Status) },
+	"Workflow_Status": func(A taskRow, B taskRow) int {
+		return strings.Compare(A.Workflow_Status, B.Workflow_Status)
+	},
+	"Version":    func(A taskRow, B taskRow) int { return cmp.Compare(A.Version, B.Version) },
+	"Created_At": func(A taskRow, B taskRow) int { return A.Created_At.Compare(B.Created_At) },
+	"Edited_On":  func(A taskRow, B taskRow) int { return A.Edited_On.Compare(B.Edited_On) },
+}
+
+func compareBool(A bool, B bool) int {
+	switch {
+	case A == B:
+		return 0
+	case A:
+		return 1
+	}
+	return -1
+}
@@ -1,24 +1,54 @@
 package Model
 
+import "time"
+
 type TaskStoreRequest struct {
 	Title            string
 	Task_Description string
 	Task_Status      bool
 }
 
+// Workflow_Status is where the task stands in the Workflow. Writes leave it
+// alone, only TransitionTask and RevertTask move it.
 type TaskStoreResponse struct {
-	ID   int64
-	Task TaskStoreRequest
+	ID              int64
+	Version         int64
+	Workflow_Status string
+	Task            TaskStoreRequest
 }
 
+// Version, when set, makes the write fail with a VersionConflictError
+// unless it matches the stored version of the task.
 type UpdateTaskStoreRequest struct {
-	ID   int64
-	Task TaskStoreRequest
+	ID      int64
+	Version int64
+	Task    TaskStoreRequest
+}
+
+type PatchTaskStoreRequest struct {
+	ID      int64
+	Version int64
+	Patch   TaskPatch
+}
+
+// TransitionTaskStoreRequest moves a task to the Workflow_Status it names.
+type TransitionTaskStoreRequest struct {
+	ID              int64
+	Version         int64
+	Workflow_Status string
+}
+
+// RevertTaskStoreRequest restores task ID as it was at Revision.
+type RevertTaskStoreRequest struct {
+	ID       int64
+	Version  int64
+	Revision int64
 }
 
 type DeleteTaskStoreRequest struct {
-	ID   int64
-	Task TaskStoreRequest
+	ID      int64
+	Version int64
+	Task    TaskStoreRequest
 }
 
 type DeleteTaskStoreResponse struct {
@@ -27,13 +57,134 @@ type DeleteTaskStoreResponse struct {
 	Task   TaskStoreRequest
 }
 
+// BulkCreateStore, BulkUpdateStore and BulkDeleteStore carry up to
+// MaxBulkItems writes. Mode is BulkAtomic or BulkBestEffort.
+type BulkCreateStore struct {
+	Mode  string
+	Tasks []TaskStoreRequest
+	// Rejected holds the violations found in an item before it became a
+	// TaskStoreRequest, by index, such as a Task_Status that is not a
+	// boolean. They fail the item along with its own.
+	Rejected map[int]*ValidationError
+}
+
+type BulkUpdateStore struct {
+	Mode  string
+	Tasks []UpdateTaskStoreRequest
+	// Rejected works as in BulkCreateStore.
+	Rejected map[int]*ValidationError
+}
+
+type BulkDeleteStore struct {
+	Mode  string
+	Tasks []DeleteTaskStoreRequest
+}
+
+// BulkItem is the outcome of one write of a bulk request: the task it left
+// behind, or why it was not applied.
+type BulkItem struct {
+	Task TaskStoreResponse
+	Err  error
+}
+
+// BulkResult holds one BulkItem per write, in request order, and how many
+// of them were applied.
+type BulkResult struct {
+	Items   []BulkItem
+	Applied int
+}
+
+// ListTaskStore selects a page either by Page/Limit or, when Page_Token is
+// set, by the keyset cursor a previous ListTaskPage handed out.
+// Count_Total also counts every task matching Filter, which costs a scan.
 type ListTaskStore struct {
-	Limit  int64
-	Page   int64
-	Offset int64
+	Limit       int64
+	Page        int64
+	Offset      int64
+	Filter      ListTaskFilter
+	Sort        []SortKey
+	Fields      []string
+	Page_Token  string
+	Count_Total bool
 }
 
+// ListTaskPage is one page of a listing. Next_Page_Token is empty when
+// Has_More is false, Total_Count is nil unless Count_Total was asked for.
+type ListTaskPage struct {
+	Items           []TaskStoreResponse
+	Has_More        bool
+	Next_Page_Token string
+	Total_Count     *int64
+}
+
+// ListTaskFilter narrows a list. Zero values leave a filter out, and an
+// empty Status lists the active tasks only.
+type ListTaskFilter struct {
+	Status               string
+	Workflow_Status      string
+	Title_Contains       string
+	Description_Contains string
+	Created_After        time.Time
+	Created_Before       time.Time
+	Edited_After         time.Time
+	Edited_Before        time.Time
+}
+
+// GetTask reads the current task, or with Revision or As_Of the revision
+// it had then, whether or not it has been deleted since.
 type GetTask struct {
-	ID int64
+	ID       int64
+	Revision int64
+	As_Of    time.Time
 }
 
+// TrashTaskStoreRequest names a soft deleted task. Version, when set, must
+// match the stored version of the task like it does for the other writes.
+type TrashTaskStoreRequest struct {
+	ID      int64
+	Version int64
+}
+
+// PurgeTrashStore selects the tasks deleted before Before for purging.
+type PurgeTrashStore struct {
+	Before time.Time
+}
+
+// TaskHistoryStore selects a page of the timeline of task ID.
+type TaskHistoryStore struct {
+	ID    int64
+	Limit int64
+	Page  int64
+}
+
+// TaskHistoryPage is one page of a timeline, oldest change first, and the
+// number of changes in the whole timeline.
+type TaskHistoryPage struct {
+	Items       []HistoryEntry
+	Total_Count int64
+}
+
+// SearchTaskStore is a full-text search over Title and Task_Description of
+// the active tasks. Query holds words, word* prefixes and "quoted phrases",
+// every one of which must match.
+type SearchTaskStore struct {
+	Query string
+	Limit int64
+	Page  int64
+}
+
+// SearchHit is a matching task with its relevance, higher is better, and
+// HTML snippets of its text with the matches wrapped in <mark>.
+type SearchHit struct {
+	Task                TaskStoreResponse
+	Score               float64
+	Title_Snippet       string
+	Description_Snippet string
+}
+
+// SearchTaskPage is one page of hits, best match first, and the number of
+// tasks matching the whole search.
+type SearchTaskPage struct {
+	Items       []SearchHit
+	Total_Count int64
+}
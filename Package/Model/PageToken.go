@@ -0,0 +1,200 @@
+package Model
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pageCursor is the position after the last row of a page: the value of
+// every sort key of that row, in sortKeys order. Query pins the cursor to
+// the filter and sort it was issued for.
+type pageCursor struct {
+	Query  string   `json:"q"`
+	Values []string `json:"v"`
+}
+
+// NewPageTokenKey returns the HMAC key page tokens are signed with. An empty
+// Secret gives a random key, which invalidates tokens on every restart.
+func NewPageTokenKey(Secret string) []byte {
+	if len(Secret) > 0 {
+		return []byte(Secret)
+	}
+
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func invalidPageToken(Message string) error {
+	return &ValidationError{Violations: []FieldViolation{{Field: "page_token", Message: Message}}}
+}
+
+// queryFingerprint identifies the ordering and filtering of a listing, so a
+// token cannot continue a different one.
+func queryFingerprint(Task ListTaskStore) string {
+	encoded, _ := json.Marshal(struct {
+		Filter ListTaskFilter
+		Sort   []SortKey
+	}{Task.Filter, sortKeys(Task.Sort)})
+
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:8])
+}
+
+// encodePageToken signs Cursor as base64url(payload).base64url(HMAC-SHA256).
+func encodePageToken(Key []byte, Cursor pageCursor) string {
+	payload, _ := json.Marshal(Cursor)
+
+	mac := hmac.New(sha256.New, Key)
+	mac.Write(payload)
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// decodePageToken verifies Token and that it was issued for Task's query.
+func decodePageToken(Key []byte, Token string, Task ListTaskStore) (pageCursor, error) {
+	encodedPayload, encodedSum, ok := strings.Cut(Token, ".")
+	if !ok {
+		return pageCursor{}, invalidPageToken("is malformed")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return pageCursor{}, invalidPageToken("is malformed")
+	}
+
+	sum, err := base64.RawURLEncoding.DecodeString(encodedSum)
+	if err != nil {
+		return pageCursor{}, invalidPageToken("is malformed")
+	}
+
+	mac := hmac.New(sha256.New, Key)
+	mac.Write(payload)
+	if !hmac.Equal(sum, mac.Sum(nil)) {
+		return pageCursor{}, invalidPageToken("has been tampered with or was signed with a different key")
+	}
+
+	var cursor pageCursor
+	err = json.Unmarshal(payload, &cursor)
+	if err != nil {
+		return pageCursor{}, invalidPageToken("is malformed")
+	}
+
+	if cursor.Query != queryFingerprint(Task) || len(cursor.Values) != len(sortKeys(Task.Sort)) {
+		return pageCursor{}, invalidPageToken("was issued for a different filter or sort")
+	}
+
+	return cursor, nil
+}
+
+// cursorValue renders the sort key Field of Row for a pageCursor.
+func cursorValue(Row taskRow, Field string) string {
+	switch Field {
+	case "ID":
+		return strconv.FormatInt(Row.ID, 10)
+	case "Version":
+		return strconv.FormatInt(Row.Version, 10)
+	case "Workflow_Status":
+		return Row.Workflow_Status
+	case "Title":
+		return Row.Task.Title
+	case "Task_Description":
+		return Row.Task.Task_Description
+	case "Task_Status":
+		return strconv.FormatBool(Row.Task.Task_Status)
+	case "Created_At":
+		return sqlTime(Row.Created_At)
+	case "Edited_On":
+		return sqlTime(Row.Edited_On)
+	}
+	return ""
+}
+
+// cursorRow is the inverse of cursorValue, filling the sort keys of a row.
+func cursorRow(Task ListTaskStore, Cursor pageCursor) (taskRow, error) {
+	var row taskRow
+	var err error
+
+	for index, key := range sortKeys(Task.Sort) {
+		value := Cursor.Values[index]
+
+		switch key.Field {
+		case "ID":
+			row.ID, err = strconv.ParseInt(value, 10, 64)
+		case "Version":
+			row.Version, err = strconv.ParseInt(value, 10, 64)
+		case "Workflow_Status":
+			row.Workflow_Status = value
+		case "Title":
+			row.Task.Title = value
+		case "Task_Description":
+			row.Task.Task_Description = value
+		case "Task_Status":
+			row.Task.Task_Status, err = strconv.ParseBool(value)
+		case "Created_At":
+			row.Created_At, err = time.Parse(sqlTimeLayout, value)
+		case "Edited_On":
+			row.Edited_On, err = time.Parse(sqlTimeLayout, value)
+		}
+
+		if err != nil {
+			return taskRow{}, invalidPageToken("is malformed")
+		}
+	}
+
+	return row, nil
+}
+
+// cursorArg is the SQL argument comparing Field against the cursor Row.
+func cursorArg(Row taskRow, Field string) any {
+	switch Field {
+	case "ID":
+		return Row.ID
+	case "Version":
+		return Row.Version
+	case "Task_Status":
+		return Row.Task.Task_Status
+	}
+	return cursorValue(Row, Field)
+}
+
+// sqlTimestamp scans a TIMESTAMP column into Time whichever way the driver
+// returns it: time.Time for SQLite, text for MySQL without parseTime.
+type sqlTimestamp struct {
+	Time *time.Time
+}
+
+func (Stamp sqlTimestamp) Scan(Value any) error {
+	switch value := Value.(type) {
+	case time.Time:
+		*Stamp.Time = value.UTC()
+		return nil
+	case []byte:
+		return Stamp.parse(string(value))
+	case string:
+		return Stamp.parse(value)
+	}
+	return fmt.Errorf("cannot scan %T into a timestamp", Value)
+}
+
+func (Stamp sqlTimestamp) parse(Value string) error {
+	for _, layout := range []string{sqlTimeLayout, time.RFC3339Nano} {
+		parsed, err := time.Parse(layout, Value)
+		if err == nil {
+			*Stamp.Time = parsed.UTC()
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot parse timestamp %q", Value)
+}
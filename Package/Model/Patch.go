@@ -0,0 +1,401 @@
+package Model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// TaskPatch rewrites the JSON representation of a task, the document a
+// client reads back as TaskStoreResponse. PatchTask applies it to the stored
+// row inside the same transaction that writes the result.
+type TaskPatch interface {
+	Apply(Document any) (any, error)
+}
+
+// MergePatch is an RFC 7386 JSON Merge Patch document.
+type MergePatch []byte
+
+func (Patch MergePatch) Apply(Document any) (any, error) {
+	var patch any
+	err := json.Unmarshal(Patch, &patch)
+	if err != nil {
+		return nil, &ValidationError{Violations: []FieldViolation{{Field: "patch", Message: "is not valid JSON"}}}
+	}
+	return mergePatch(Document, patch), nil
+}
+
+func mergePatch(Target any, Patch any) any {
+	patch, ok := Patch.(map[string]any)
+	if !ok {
+		return Patch
+	}
+
+	target, ok := Target.(map[string]any)
+	if !ok {
+		target = map[string]any{}
+	}
+
+	for key, value := range patch {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+		target[key] = mergePatch(target[key], value)
+	}
+
+	return target
+}
+
+// PatchOperation is one operation of an RFC 6902 JSON Patch document.
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// JSONPatch is an RFC 6902 JSON Patch document. Operations apply in order
+// and the whole document fails if any of them fails.
+type JSONPatch []PatchOperation
+
+func (Patch JSONPatch) Apply(Document any) (any, error) {
+	violations := &ValidationError{}
+	for index, operation := range Patch {
+		violations.Violations = append(violations.Violations, operation.validate(index)...)
+	}
+	if err := violations.OrNil(); err != nil {
+		return nil, err
+	}
+
+	for index, operation := range Patch {
+		var err error
+		Document, err = operation.apply(Document)
+		if err != nil {
+			return nil, &ConflictError{Message: fmt.Sprintf("patch operation %d (%s %s) %s", index, operation.Op, operation.Path, err.Error()), Err: err}
+		}
+	}
+
+	return Document, nil
+}
+
+func (Operation PatchOperation) validate(Index int) []FieldViolation {
+	field := fmt.Sprintf("patch[%d]", Index)
+	violations := []FieldViolation{}
+
+	switch Operation.Op {
+	case "add", "replace", "test":
+		if len(Operation.Value) <= 0 {
+			violations = append(violations, FieldViolation{Field: field + ".value", Message: "is required"})
+		}
+	case "move", "copy":
+		if _, err := pointerTokens(Operation.From); err != nil {
+			violations = append(violations, FieldViolation{Field: field + ".from", Message: err.Error()})
+		}
+	case "remove":
+	default:
+		violations = append(violations, FieldViolation{Field: field + ".op", Message: "must be one of [add remove replace move copy test]"})
+	}
+
+	if _, err := pointerTokens(Operation.Path); err != nil {
+		violations = append(violations, FieldViolation{Field: field + ".path", Message: err.Error()})
+	}
+
+	return violations
+}
+
+func (Operation PatchOperation) apply(Document any) (any, error) {
+	path, _ := pointerTokens(Operation.Path)
+	from, _ := pointerTokens(Operation.From)
+
+	switch Operation.Op {
+	case "add":
+		value, err := Operation.value()
+		if err != nil {
+			return nil, err
+		}
+		return pointerAdd(Document, path, value)
+	case "remove":
+		return pointerRemove(Document, path)
+	case "replace":
+		value, err := Operation.value()
+		if err != nil {
+			return nil, err
+		}
+		Document, err = pointerRemove(Document, path)
+		if err != nil {
+			return nil, err
+		}
+		return pointerAdd(Document, path, value)
+	case "move":
+		if strings.HasPrefix(Operation.Path, Operation.From+"/") {
+			return nil, errors.New("cannot move a value into itself")
+		}
+		value, err := pointerGet(Document, from)
+		if err != nil {
+			return nil, err
+		}
+		Document, err = pointerRemove(Document, from)
+		if err != nil {
+			return nil, err
+		}
+		return pointerAdd(Document, path, value)
+	case "copy":
+		value, err := pointerGet(Document, from)
+		if err != nil {
+			return nil, err
+		}
+		return pointerAdd(Document, path, deepCopy(value))
+	case "test":
+		expected, err := Operation.value()
+		if err != nil {
+			return nil, err
+		}
+		actual, err := pointerGet(Document, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(expected, actual) {
+			return nil, errors.New("failed, the value does not match")
+		}
+		return Document, nil
+	}
+
+	return nil, fmt.Errorf("unknown op %q", Operation.Op)
+}
+
+func (Operation PatchOperation) value() (any, error) {
+	var value any
+	err := json.Unmarshal(Operation.Value, &value)
+	if err != nil {
+		return nil, errors.New("has a value that is not valid JSON")
+	}
+	return value, nil
+}
+
+// pointerTokens splits an RFC 6901 JSON pointer into unescaped reference tokens.
+func pointerTokens(Pointer string) ([]string, error) {
+	if len(Pointer) <= 0 {
+		return []string{}, nil
+	}
+
+	if !strings.HasPrefix(Pointer, "/") {
+		return nil, errors.New("must be a JSON pointer starting with /")
+	}
+
+	tokens := strings.Split(Pointer[1:], "/")
+	for index, token := range tokens {
+		tokens[index] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+func arrayIndex(Token string, Length int) (int, error) {
+	index, err := strconv.Atoi(Token)
+	if err != nil || index < 0 || index >= Length || (len(Token) > 1 && Token[0] == '0') {
+		return 0, fmt.Errorf("has no array element %q", Token)
+	}
+	return index, nil
+}
+
+func pointerGet(Document any, Tokens []string) (any, error) {
+	for _, token := range Tokens {
+		switch node := Document.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("has no member %q", token)
+			}
+			Document = value
+		case []any:
+			index, err := arrayIndex(token, len(node))
+			if err != nil {
+				return nil, err
+			}
+			Document = node[index]
+		default:
+			return nil, fmt.Errorf("cannot descend into %q", token)
+		}
+	}
+	return Document, nil
+}
+
+// pointerUpdate replaces the container holding the last token of Tokens with
+// the result of Update and returns the new document root.
+func pointerUpdate(Document any, Tokens []string, Update func(Container any, Token string) (any, error)) (any, error) {
+	if len(Tokens) == 1 {
+		return Update(Document, Tokens[0])
+	}
+
+	child, err := pointerGet(Document, Tokens[:1])
+	if err != nil {
+		return nil, err
+	}
+
+	child, err = pointerUpdate(child, Tokens[1:], Update)
+	if err != nil {
+		return nil, err
+	}
+
+	switch node := Document.(type) {
+	case map[string]any:
+		node[Tokens[0]] = child
+	case []any:
+		index, _ := arrayIndex(Tokens[0], len(node))
+		node[index] = child
+	}
+
+	return Document, nil
+}
+
+func pointerAdd(Document any, Tokens []string, Value any) (any, error) {
+	if len(Tokens) <= 0 {
+		return Value, nil
+	}
+
+	return pointerUpdate(Document, Tokens, func(Container any, Token string) (any, error) {
+		switch node := Container.(type) {
+		case map[string]any:
+			node[Token] = Value
+			return node, nil
+		case []any:
+			if Token == "-" {
+				return append(node, Value), nil
+			}
+			index, err := arrayIndex(Token, len(node)+1)
+			if err != nil {
+				return nil, err
+			}
+			node = append(node[:index], append([]any{Value}, node[index:]...)...)
+			return node, nil
+		}
+		return nil, fmt.Errorf("cannot add %q to a scalar", Token)
+	})
+}
+
+func pointerRemove(Document any, Tokens []string) (any, error) {
+	if len(Tokens) <= 0 {
+		return nil, errors.New("cannot remove the whole document")
+	}
+
+	return pointerUpdate(Document, Tokens, func(Container any, Token string) (any, error) {
+		switch node := Container.(type) {
+		case map[string]any:
+			if _, ok := node[Token]; !ok {
+				return nil, fmt.Errorf("has no member %q", Token)
+			}
+			delete(node, Token)
+			return node, nil
+		case []any:
+			index, err := arrayIndex(Token, len(node))
+			if err != nil {
+				return nil, err
+			}
+			return append(node[:index], node[index+1:]...), nil
+		}
+		return nil, fmt.Errorf("cannot remove %q from a scalar", Token)
+	})
+}
+
+func deepCopy(Value any) any {
+	switch node := Value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(node))
+		for key, value := range node {
+			copied[key] = deepCopy(value)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(node))
+		for index, value := range node {
+			copied[index] = deepCopy(value)
+		}
+		return copied
+	}
+	return Value
+}
+
+// taskColumn is a TaskStore column a patch is allowed to write.
+type taskColumn struct {
+	Name  string
+	Value func(Task TaskStoreRequest) any
+}
+
+var patchableColumns = []taskColumn{
+	{Name: "Title", Value: func(Task TaskStoreRequest) any { return Task.Title }},
+	{Name: "Task_Description", Value: func(Task TaskStoreRequest) any { return Task.Task_Description }},
+	{Name: "Task_Status", Value: func(Task TaskStoreRequest) any { return Task.Task_Status }},
+}
+
+// applyTaskPatch runs Patch against Current and returns the patched task
+// with the columns whose value changed.
+func applyTaskPatch(Current TaskStoreResponse, Patch TaskPatch) (TaskStoreRequest, []taskColumn, error) {
+	if Patch == nil {
+		return TaskStoreRequest{}, nil, &ValidationError{Violations: []FieldViolation{{Field: "patch", Message: "is required"}}}
+	}
+
+	encoded, err := json.Marshal(Current)
+	if err != nil {
+		return TaskStoreRequest{}, nil, err
+	}
+
+	var document any
+	err = json.Unmarshal(encoded, &document)
+	if err != nil {
+		return TaskStoreRequest{}, nil, err
+	}
+
+	document, err = Patch.Apply(document)
+	if err != nil {
+		return TaskStoreRequest{}, nil, err
+	}
+
+	encoded, err = json.Marshal(document)
+	if err != nil {
+		return TaskStoreRequest{}, nil, err
+	}
+
+	var patched TaskStoreResponse
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&patched)
+	if err != nil {
+		return TaskStoreRequest{}, nil, &ValidationError{Violations: []FieldViolation{{Field: "patch", Message: patchDecodeMessage(err)}}}
+	}
+
+	violations := validateEditTask(UpdateTaskStoreRequest{ID: Current.ID, Task: patched.Task})
+	if patched.ID != Current.ID {
+		violations.Add("ID", "is read-only")
+	}
+	if patched.Version != Current.Version {
+		violations.Add("Version", "is read-only")
+	}
+	if patched.Workflow_Status != Current.Workflow_Status {
+		violations.Add("Workflow_Status", "is read-only, move the task through its transitions")
+	}
+	if err := violations.OrNil(); err != nil {
+		return TaskStoreRequest{}, nil, err
+	}
+
+	changed := []taskColumn{}
+	for _, column := range patchableColumns {
+		if column.Value(patched.Task) != column.Value(Current.Task) {
+			changed = append(changed, column)
+		}
+	}
+
+	return patched.Task, changed, nil
+}
+
+func patchDecodeMessage(Err error) string {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(Err, &typeErr) {
+		return fmt.Sprintf("sets %s to a %s, expected %s", typeErr.Field, typeErr.Value, typeErr.Type.String())
+	}
+	return "produces " + strings.TrimPrefix(Err.Error(), "json: ")
+}
@@ -1 +1,175 @@
-package Model
\ No newline at end of file
+package Model
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+func validateAddTask(Task TaskStoreRequest) *ValidationError {
+	violations := &ValidationError{}
+
+	if len(Task.Title) <= 0 {
+		violations.Add("Title", "Invalid Title")
+	}
+
+	if len(Task.Task_Description) <= 0 {
+		violations.Add("Task_Description", "Invalid Description")
+	}
+
+	return violations
+}
+
+func validateEditTask(Task UpdateTaskStoreRequest) *ValidationError {
+	violations := &ValidationError{}
+
+	if Task.ID < 1 {
+		violations.Add("ID", "Invalid ID")
+	}
+
+	violations.Violations = append(violations.Violations, validateAddTask(Task.Task).Violations...)
+
+	return violations
+}
+
+func validateGetTask(Task GetTask) *ValidationError {
+	violations := &ValidationError{}
+
+	if Task.ID < 1 {
+		violations.Add("ID", "Invalid ID")
+	}
+
+	if Task.Revision < 0 {
+		violations.Add("Revision", "Invalid Revision")
+	}
+
+	if Task.Revision > 0 && !Task.As_Of.IsZero() {
+		violations.Add("As_Of", "cannot be combined with Revision")
+	}
+
+	return violations
+}
+
+func validateRevertTask(Task RevertTaskStoreRequest) *ValidationError {
+	violations := validateGetTask(GetTask{ID: Task.ID})
+
+	if Task.Revision < 1 {
+		violations.Add("Revision", "Invalid Revision")
+	}
+
+	return violations
+}
+
+func validateTransitionTask(Task TransitionTaskStoreRequest, Flow Workflow) *ValidationError {
+	violations := validateGetTask(GetTask{ID: Task.ID})
+
+	if !contains(Flow.States(), Task.Workflow_Status) {
+		violations.Add("Workflow_Status", "must be one of ["+strings.Join(Flow.States(), " ")+"]")
+	}
+
+	return violations
+}
+
+func validateBulk(Mode string, Count int) *ValidationError {
+	violations := &ValidationError{}
+
+	if Mode != BulkAtomic && Mode != BulkBestEffort {
+		violations.Add("Mode", "must be one of ["+BulkAtomic+" "+BulkBestEffort+"]")
+	}
+
+	if Count < 1 || Count > MaxBulkItems {
+		violations.Add("Tasks", "must hold between 1 and "+strconv.Itoa(MaxBulkItems)+" tasks")
+	}
+
+	return violations
+}
+
+func validatePurgeTrash(Task PurgeTrashStore) *ValidationError {
+	violations := &ValidationError{}
+
+	if Task.Before.IsZero() {
+		violations.Add("Before", "is required")
+	}
+
+	return violations
+}
+
+func validateIdempotencyKey(Task IdempotencyKeyStore) *ValidationError {
+	violations := &ValidationError{}
+
+	if len(Task.Key) <= 0 || len(Task.Key) > MaxIdempotencyKeyLength {
+		violations.Add("Idempotency-Key", "must hold between 1 and "+strconv.Itoa(MaxIdempotencyKeyLength)+" characters")
+	}
+
+	if len(Task.Request_Hash) <= 0 {
+		violations.Add("Request_Hash", "is required")
+	}
+
+	return violations
+}
+
+func validateCompleteIdempotencyKey(Task IdempotencyRecord) *ValidationError {
+	violations := validateIdempotencyKey(IdempotencyKeyStore{Key: Task.Key, Request_Hash: Task.Request_Hash})
+
+	if Task.Status < 100 || Task.Status > 599 {
+		violations.Add("Status", "Invalid Status")
+	}
+
+	return violations
+}
+
+func validatePurgeIdempotencyKeys(Task PurgeIdempotencyKeyStore) *ValidationError {
+	return validatePurgeTrash(PurgeTrashStore{Before: Task.Before})
+}
+
+func validateListTask(Task ListTaskStore) *ValidationError {
+	violations := &ValidationError{}
+
+	switch Task.Filter.Status {
+	case "", StatusActive, StatusDeleted, StatusAll:
+	default:
+		violations.Add("status", "must be one of ["+strings.Join([]string{StatusActive, StatusDeleted, StatusAll}, " ")+"]")
+	}
+
+	validateRange(violations, "created", Task.Filter.Created_After, Task.Filter.Created_Before)
+	validateRange(violations, "edited", Task.Filter.Edited_After, Task.Filter.Edited_Before)
+
+	for _, key := range Task.Sort {
+		if !contains(SortableFields, key.Field) {
+			violations.Add("sort", "cannot sort by "+key.Field+", must be one of ["+strings.Join(SortableFields, " ")+"]")
+		}
+	}
+
+	for _, field := range Task.Fields {
+		if !contains(SelectableFields, field) {
+			violations.Add("fields", "has no field "+field+", must be one of ["+strings.Join(SelectableFields, " ")+"]")
+		}
+	}
+
+	return violations
+}
+
+func validateRange(Violations *ValidationError, Name string, After time.Time, Before time.Time) {
+	if !After.IsZero() && !Before.IsZero() && !After.Before(Before) {
+		Violations.Add(Name+"_after", "must be before "+Name+"_before")
+	}
+}
+
+// maxSearchTerms bounds the work one search can ask for.
+const maxSearchTerms int = 16
+
+func validateSearchTask(Task SearchTaskStore) *ValidationError {
+	violations := &ValidationError{}
+
+	terms := ParseSearchQuery(Task.Query)
+
+	if len(terms) <= 0 {
+		violations.Add("q", "must contain at least one word")
+	}
+
+	if len(terms) > maxSearchTerms {
+		violations.Add("q", "must contain at most "+strconv.Itoa(maxSearchTerms)+" words, prefixes or phrases")
+	}
+
+	return violations
+}
@@ -0,0 +1,295 @@
+package Model
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Status values accepted by ListTaskFilter.Status.
+const (
+	StatusActive  string = "active"
+	StatusDeleted string = "deleted"
+	StatusAll     string = "all"
+)
+
+// SortKey orders a list by Field, ascending unless Descending is set.
+type SortKey struct {
+	Field      string
+	Descending bool
+}
+
+// SortableFields are the TaskStore columns a list can be ordered by.
+var SortableFields = []string{"ID", "Title", "Task_Description", "Task_Status", "Workflow_Status", "Version", "Created_At", "Edited_On"}
+
+// SelectableFields are the TaskStore columns a sparse fieldset can name.
+// ID is always returned.
+var SelectableFields = []string{"ID", "Version", "Workflow_Status", "Title", "Task_Description", "Task_Status"}
+
+// ParseSortKeys reads a comma separated list of fields, each optionally
+// prefixed with - for descending order, such as "-Created_At,Title".
+func ParseSortKeys(Sort string) []SortKey {
+	keys := []SortKey{}
+	for _, field := range splitList(Sort) {
+		key := SortKey{Field: strings.TrimPrefix(field, "-")}
+		key.Descending = key.Field != field
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// ParseFields reads a comma separated sparse fieldset such as "ID,Title".
+func ParseFields(Fields string) []string {
+	return splitList(Fields)
+}
+
+func splitList(List string) []string {
+	items := []string{}
+	for _, item := range strings.Split(List, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) > 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func contains(List []string, Item string) bool {
+	for _, value := range List {
+		if value == Item {
+			return true
+		}
+	}
+	return false
+}
+
+// likeEscape is the ESCAPE character of every LIKE pattern. It is not a
+// backslash because MySQL and SQLite disagree on escaping a backslash.
+const likeEscape string = "!"
+
+func likePattern(Contains string) string {
+	escaped := strings.NewReplacer(likeEscape, likeEscape+likeEscape, "%", likeEscape+"%", "_", likeEscape+"_").Replace(Contains)
+	return "%" + escaped + "%"
+}
+
+const sqlTimeLayout string = "2006-01-02 15:04:05"
+
+// sqlTime formats a bound the way both drivers store CURRENT_TIMESTAMP, in
+// UTC. The Configurator pins MySQL sessions to UTC for it, see sessionDSN.
+func sqlTime(Time time.Time) string {
+	return Time.UTC().Format(sqlTimeLayout)
+}
+
+const ListTaskQuery string = `
+SELECT %s FROM TaskStore
+WHERE %s
+ORDER BY %s
+LIMIT ? OFFSET ?
+;
+`
+
+const CountTaskQuery string = `
+SELECT COUNT(*) FROM TaskStore
+WHERE %s
+;
+`
+
+// listConditions builds the WHERE clause of Task's filter. Column names only
+// ever come from this package, every value from the request travels as an
+// argument.
+func listConditions(Task ListTaskStore) ([]string, []any) {
+	conditions := []string{}
+	args := []any{}
+
+	switch Task.Filter.Status {
+	case StatusDeleted:
+		conditions = append(conditions, "Deleted_At IS NOT NULL")
+	case StatusAll:
+	default:
+		conditions = append(conditions, "Deleted_At IS NULL")
+	}
+
+	if len(Task.Filter.Workflow_Status) > 0 {
+		conditions = append(conditions, "Workflow_Status = ?")
+		args = append(args, Task.Filter.Workflow_Status)
+	}
+
+	if len(Task.Filter.Title_Contains) > 0 {
+		conditions = append(conditions, "Title LIKE ? ESCAPE '"+likeEscape+"'")
+		args = append(args, likePattern(Task.Filter.Title_Contains))
+	}
+
+	if len(Task.Filter.Description_Contains) > 0 {
+		conditions = append(conditions, "Task_Description LIKE ? ESCAPE '"+likeEscape+"'")
+		args = append(args, likePattern(Task.Filter.Description_Contains))
+	}
+
+	ranges := []struct {
+		Condition string
+		Bound     time.Time
+	}{
+		{"Created_At >= ?", Task.Filter.Created_After},
+		{"Created_At < ?", Task.Filter.Created_Before},
+		{"Edited_On >= ?", Task.Filter.Edited_After},
+		{"Edited_On < ?", Task.Filter.Edited_Before},
+	}
+
+	for _, bound := range ranges {
+		if !bound.Bound.IsZero() {
+			conditions = append(conditions, bound.Condition)
+			args = append(args, sqlTime(bound.Bound))
+		}
+	}
+
+	return conditions, args
+}
+
+// afterCondition matches the rows that sort after Cursor, expanded as
+// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ... so every key can have its own
+// direction.
+func afterCondition(Keys []SortKey, Cursor taskRow) (string, []any) {
+	alternatives := []string{}
+	args := []any{}
+
+	for index, key := range Keys {
+		terms := []string{}
+		for _, equal := range Keys[:index] {
+			terms = append(terms, equal.Field+" = ?")
+			args = append(args, cursorArg(Cursor, equal.Field))
+		}
+
+		operator := " > ?"
+		if key.Descending {
+			operator = " < ?"
+		}
+		terms = append(terms, key.Field+operator)
+		args = append(args, cursorArg(Cursor, key.Field))
+
+		alternatives = append(alternatives, "("+strings.Join(terms, " AND ")+")")
+	}
+
+	return "(" + strings.Join(alternatives, " OR ") + ")", args
+}
+
+// listTaskQuery builds the SELECT of one page of Task along with its
+// arguments and selected columns. With a Cursor it reads the rows after it
+// instead of using OFFSET.
+func (Dialect Dialect) listTaskQuery(Task ListTaskStore, Cursor *taskRow) (string, []any, []string) {
+	conditions, args := listConditions(Task)
+	keys := sortKeys(Task.Sort)
+
+	if Cursor != nil {
+		condition, cursorArgs := afterCondition(keys, *Cursor)
+		conditions = append(conditions, condition)
+		args = append(args, cursorArgs...)
+	}
+
+	order := []string{}
+	for _, key := range keys {
+		direction := "ASC"
+		if key.Descending {
+			direction = "DESC"
+		}
+		order = append(order, key.Field+" "+direction)
+	}
+
+	columns := selectedFields(Task.Fields)
+	for _, key := range keys {
+		if !contains(columns, key.Field) {
+			columns = append(columns, key.Field)
+		}
+	}
+
+	args = append(args, Task.Limit, Task.Offset)
+
+	query := fmt.Sprintf(Dialect.ListTaskQuery, strings.Join(columns, " , "), strings.Join(conditions, " AND "), strings.Join(order, " , "))
+	return query, args, columns
+}
+
+func (Dialect Dialect) countTaskQuery(Task ListTaskStore) (string, []any) {
+	conditions, args := listConditions(Task)
+	return fmt.Sprintf(Dialect.CountTaskQuery, strings.Join(conditions, " AND ")), args
+}
+
+// sortKeys appends ID to Sort so every ordering is total and stable.
+func sortKeys(Sort []SortKey) []SortKey {
+	for _, key := range Sort {
+		if key.Field == "ID" {
+			return Sort
+		}
+	}
+	return append(append([]SortKey{}, Sort...), SortKey{Field: "ID"})
+}
+
+// selectedFields is the SelectableFields order of Fields plus ID, or every
+// field when Fields is empty.
+func selectedFields(Fields []string) []string {
+	if len(Fields) <= 0 {
+		return append([]string{}, SelectableFields...)
+	}
+
+	columns := []string{}
+	for _, field := range SelectableFields {
+		if field == "ID" || contains(Fields, field) {
+			columns = append(columns, field)
+		}
+	}
+	return columns
+}
+
+// scanTarget is where a selected column of a list row lands.
+func scanTarget(Row *taskRow, Column string) any {
+	switch Column {
+	case "ID":
+		return &Row.ID
+	case "Version":
+		return &Row.Version
+	case "Workflow_Status":
+		return &Row.Workflow_Status
+	case "Title":
+		return &Row.Task.Title
+	case "Task_Description":
+		return &Row.Task.Task_Description
+	case "Task_Status":
+		return &Row.Task.Task_Status
+	case "Created_At":
+		return sqlTimestamp{Time: &Row.Created_At}
+	case "Edited_On":
+		return sqlTimestamp{Time: &Row.Edited_On}
+	}
+	return nil
+}
+
+// pageOf cuts Rows, read with one extra row, into the page of Task and the
+// token of the next one.
+func pageOf(Key []byte, Task ListTaskStore, Rows []taskRow) ListTaskPage {
+	page := ListTaskPage{Items: []TaskStoreResponse{}}
+
+	if int64(len(Rows)) > Task.Limit {
+		Rows = Rows[:Task.Limit]
+		page.Has_More = true
+	}
+
+	columns := selectedFields(Task.Fields)
+
+	for _, row := range Rows {
+		// Only hand out the selected columns, even when sorting read more.
+		var item taskRow
+		for _, column := range columns {
+			reflect.ValueOf(scanTarget(&item, column)).Elem().Set(reflect.ValueOf(scanTarget(&row, column)).Elem())
+		}
+		page.Items = append(page.Items, item.response())
+	}
+
+	if page.Has_More {
+		last := Rows[len(Rows)-1]
+		cursor := pageCursor{Query: queryFingerprint(Task)}
+		for _, key := range sortKeys(Task.Sort) {
+			cursor.Values = append(cursor.Values, cursorValue(last, key.Field))
+		}
+		page.Next_Page_Token = encodePageToken(Key, cursor)
+	}
+
+	return page
+}
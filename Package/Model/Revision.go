@@ -0,0 +1,289 @@
+package Model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Every write that gives a task a new Version also keeps the row it wrote in
+// TaskRevision, Deleted_At included, so the revision number of a task is its
+// Version. GetTask reads them back with As_Of or Revision and RevertTask
+// restores one.
+
+// RevisionColumns are the TaskRevision columns scanTask reads, in order.
+const RevisionColumns string = "Task_ID , Title , Task_Description , Task_Status , Version , Workflow_Status"
+
+// AddRevisionQuery copies the rows of the tasks inListQuery lists as they
+// are in TaskStore.
+const AddRevisionQuery string = `
+INSERT INTO TaskRevision (
+  Task_ID, Version, Title, Task_Description, Task_Status, Workflow_Status, Deleted_At
+)
+SELECT ID, Version, Title, Task_Description, Task_Status, Workflow_Status, Deleted_At FROM TaskStore
+WHERE ID IN (%s)
+;
+`
+
+const RevisionQuery string = `
+SELECT ` + RevisionColumns + ` , Deleted_At IS NOT NULL FROM TaskRevision
+WHERE Task_ID = ? AND Version = ?
+;
+`
+
+// RevisionAsOfQuery compares Revised_At, filled by now(), with a UTC bound.
+// That holds because the Configurator pins MySQL sessions to UTC.
+const RevisionAsOfQuery string = `
+SELECT ` + RevisionColumns + ` , Deleted_At IS NOT NULL FROM TaskRevision
+WHERE Task_ID = ? AND Revised_At <= ?
+ORDER BY Version DESC
+LIMIT 1
+;
+`
+
+const RevertTaskQuery string = `
+UPDATE TaskStore
+SET Title = ? , Task_Description = ? , Task_Status = ? , Workflow_Status = ? , Edited_On = CURRENT_TIMESTAMP() , Version = Version + 1
+WHERE ID = ? AND Version = ?
+;
+`
+
+const PurgeRevisionsQuery string = `
+DELETE FROM TaskRevision
+WHERE Task_ID = ?
+;
+`
+
+// ErrRevisionNotFound matches a revision a task never had, or had before
+// TaskRevision existed, and a task that did not exist at an As_Of time.
+var ErrRevisionNotFound = &NotFoundError{Resource: "Task Revision"}
+
+func revisionNotFound(ID int64) error {
+	return &NotFoundError{Resource: "Task Revision", ID: ID}
+}
+
+// TrashedRevisionError is a revision written while the task was in the
+// trash. The task could not be read then, so its revision cannot either, it
+// matches ErrTaskNotFound like GetTask of a trashed task does.
+type TrashedRevisionError struct {
+	ID       int64
+	Revision int64
+}
+
+func (Err *TrashedRevisionError) Error() string {
+	return fmt.Sprintf("Task %d was in the trash at revision %d", Err.ID, Err.Revision)
+}
+
+func (Err *TrashedRevisionError) Unwrap() error {
+	return taskNotFound(Err.ID)
+}
+
+// readRevision reads the revision of task ID that Task selects inside Tx.
+func (Model *ModelStruct) readRevision(Ctx context.Context, Tx *sql.Tx, Task GetTask) (TaskStoreResponse, error) {
+	var row *sql.Row
+
+	if Task.Revision > 0 {
+		row = Tx.QueryRowContext(Ctx, Model.Dialect.RevisionQuery, Task.ID, Task.Revision)
+	} else {
+		row = Tx.QueryRowContext(Ctx, Model.Dialect.RevisionAsOfQuery, Task.ID, sqlTime(Task.As_Of))
+	}
+
+	var task TaskStoreResponse
+	var trashed bool
+
+	err := row.Scan(
+		&task.ID,
+		&task.Task.Title,
+		&task.Task.Task_Description,
+		&task.Task.Task_Status,
+		&task.Version,
+		&task.Workflow_Status,
+		&trashed,
+	)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return TaskStoreResponse{}, revisionNotFound(Task.ID)
+	}
+
+	if err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	if trashed {
+		return TaskStoreResponse{}, &TrashedRevisionError{ID: Task.ID, Revision: task.Version}
+	}
+
+	return task, nil
+}
+
+// revise keeps Tasks, as a write just left them in TaskStore, in
+// TaskRevision inside Tx.
+func (Model *ModelStruct) revise(Ctx context.Context, Tx *sql.Tx, Tasks []TaskStoreResponse) error {
+	if len(Tasks) <= 0 {
+		return nil
+	}
+
+	args := []any{}
+	for _, task := range Tasks {
+		args = append(args, task.ID)
+	}
+
+	_, err := Tx.ExecContext(Ctx, Model.Dialect.AddRevisionQuery(len(Tasks)), args...)
+
+	return err
+}
+
+// revertedWorkflowStatus checks that Flow still has the Workflow_Status of
+// Revision. A revert undoes the moves made since, so it does not have to
+// follow the transitions, but the workflow may have lost the state since.
+func revertedWorkflowStatus(Flow Workflow, Revision TaskStoreResponse) error {
+	if contains(Flow.States(), Revision.Workflow_Status) {
+		return nil
+	}
+
+	violations := &ValidationError{}
+	violations.Add("Workflow_Status", fmt.Sprintf("was %s at revision %d, which is not one of [%s] anymore", Revision.Workflow_Status, Revision.Version, strings.Join(Flow.States(), " ")))
+
+	return violations
+}
+
+// RevertTask writes the row task ID had at Task.Revision back as a new
+// revision, Workflow_Status included as long as the workflow still has it.
+func (Model *ModelStruct) RevertTask(Ctx context.Context, Task RevertTaskStoreRequest) (TaskStoreResponse, error) {
+
+	err := validateRevertTask(Task).OrNil()
+
+	if err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	var reverted TaskStoreResponse
+
+	err = Model.transact(Ctx, func(db *sql.Tx) error {
+		current, err := Model.checkVersion(Ctx, db, Task.ID, Task.Version)
+
+		if err != nil {
+			return err
+		}
+
+		revision, err := Model.readRevision(Ctx, db, GetTask{ID: Task.ID, Revision: Task.Revision})
+
+		if err != nil {
+			return err
+		}
+
+		err = revertedWorkflowStatus(Model.Workflow, revision)
+
+		if err != nil {
+			return err
+		}
+
+		reverted = TaskStoreResponse{
+			ID:              Task.ID,
+			Version:         current.Version + 1,
+			Workflow_Status: revision.Workflow_Status,
+			Task:            revision.Task,
+		}
+
+		resp, err := db.ExecContext(Ctx, Model.Dialect.RevertTaskQuery, reverted.Task.Title, reverted.Task.Task_Description, reverted.Task.Task_Status, reverted.Workflow_Status, Task.ID, current.Version)
+
+		if err != nil {
+			return err
+		}
+
+		numRowAffected, err := resp.RowsAffected()
+
+		if err != nil {
+			return err
+		}
+
+		if numRowAffected != 1 {
+			return &VersionConflictError{ID: Task.ID, Version: current.Version}
+		}
+
+		return Model.record(Ctx, db, ActionReverted, &current, &reverted)
+	})
+
+	if err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	return reverted, nil
+}
+
+// memoryRevision is a revision MemoryModelStruct keeps.
+type memoryRevision struct {
+	Task       TaskStoreResponse
+	Revised_At time.Time
+	Deleted_At time.Time
+}
+
+// find returns the task of Revision, unless it was in the trash.
+func (Revision memoryRevision) find() (TaskStoreResponse, error) {
+	if !Revision.Deleted_At.IsZero() {
+		return TaskStoreResponse{}, &TrashedRevisionError{ID: Revision.Task.ID, Revision: Revision.Task.Version}
+	}
+	return Revision.Task, nil
+}
+
+// revision finds the revision of task ID that Task selects. The caller
+// holds the mutex.
+func (Model *MemoryModelStruct) revision(Task GetTask) (TaskStoreResponse, error) {
+	revisions := Model.revisions[Task.ID]
+
+	for index := len(revisions) - 1; index >= 0; index-- {
+		revision := revisions[index]
+		if Task.Revision > 0 && revision.Task.Version == Task.Revision {
+			return revision.find()
+		}
+		if Task.Revision <= 0 && !revision.Revised_At.After(Task.As_Of) {
+			return revision.find()
+		}
+	}
+
+	return TaskStoreResponse{}, revisionNotFound(Task.ID)
+}
+
+func (Model *MemoryModelStruct) RevertTask(Ctx context.Context, Task RevertTaskStoreRequest) (TaskStoreResponse, error) {
+	if err := Ctx.Err(); err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	err := validateRevertTask(Task).OrNil()
+
+	if err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	Model.mutex.Lock()
+	defer Model.mutex.Unlock()
+
+	row, err := Model.checkVersion(Task.ID, Task.Version)
+	if err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	revision, err := Model.revision(GetTask{ID: Task.ID, Revision: Task.Revision})
+	if err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	err = revertedWorkflowStatus(Model.Workflow, revision)
+	if err != nil {
+		return TaskStoreResponse{}, err
+	}
+
+	before := row.response()
+	row.Task = revision.Task
+	row.Workflow_Status = revision.Workflow_Status
+	row.Version++
+	row.Edited_On = time.Now().UTC().Truncate(time.Second)
+	Model.store(row)
+	after := row.response()
+	Model.record(Ctx, ActionReverted, &before, &after)
+
+	return after, nil
+}
@@ -0,0 +1,465 @@
+package Model
+
+import (
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// SearchTerm is one required part of a search query: a word, a word prefix
+// written as word*, or a phrase written in double quotes.
+type SearchTerm struct {
+	Words  []string
+	Prefix bool
+}
+
+func (Term SearchTerm) isPhrase() bool {
+	return len(Term.Words) > 1
+}
+
+// ParseSearchQuery splits Query into SearchTerms. Words are lower cased
+// letters and digits, every other character separates words.
+func ParseSearchQuery(Query string) []SearchTerm {
+	terms := []SearchTerm{}
+
+	for index, part := range strings.Split(Query, `"`) {
+		// Odd parts sit between double quotes.
+		if index%2 == 1 {
+			words := tokenize(part)
+			if len(words) > 0 {
+				terms = append(terms, SearchTerm{Words: words})
+			}
+			continue
+		}
+
+		for _, field := range strings.Fields(part) {
+			words := tokenize(field)
+			for position, word := range words {
+				terms = append(terms, SearchTerm{
+					Words:  []string{word},
+					Prefix: position == len(words)-1 && strings.HasSuffix(field, "*"),
+				})
+			}
+		}
+	}
+
+	return terms
+}
+
+func tokenize(Text string) []string {
+	words := []string{}
+	for _, span := range tokenSpans(Text) {
+		words = append(words, strings.ToLower(Text[span[0]:span[1]]))
+	}
+	return words
+}
+
+// tokenSpans returns the byte offsets of every word of Text.
+func tokenSpans(Text string) [][2]int {
+	spans := [][2]int{}
+	start := -1
+
+	for offset, char := range Text {
+		isWord := unicode.IsLetter(char) || unicode.IsDigit(char)
+		if isWord && start < 0 {
+			start = offset
+		}
+		if !isWord && start >= 0 {
+			spans = append(spans, [2]int{start, offset})
+			start = -1
+		}
+	}
+
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(Text)})
+	}
+
+	return spans
+}
+
+// matchesAt reports how many words of Words starting at Position Term covers.
+func (Term SearchTerm) matchesAt(Words []string, Position int) int {
+	if Position+len(Term.Words) > len(Words) {
+		return 0
+	}
+
+	for offset, word := range Term.Words {
+		last := offset == len(Term.Words)-1
+		if Term.Prefix && last {
+			if !strings.HasPrefix(Words[Position+offset], word) {
+				return 0
+			}
+			continue
+		}
+		if Words[Position+offset] != word {
+			return 0
+		}
+	}
+
+	return len(Term.Words)
+}
+
+func (Term SearchTerm) frequency(Words []string) int {
+	count := 0
+	for position := range Words {
+		if Term.matchesAt(Words, position) > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// MySQLBooleanQuery renders Terms for MATCH ... AGAINST (? IN BOOLEAN MODE)
+// with every term required. Words only hold letters and digits, so no
+// boolean operator can come from the request.
+func MySQLBooleanQuery(Terms []SearchTerm) string {
+	parts := []string{}
+	for _, term := range Terms {
+		switch {
+		case term.isPhrase():
+			parts = append(parts, `+"`+strings.Join(term.Words, " ")+`"`)
+		case term.Prefix:
+			parts = append(parts, "+"+term.Words[0]+"*")
+		default:
+			parts = append(parts, "+"+term.Words[0])
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+const MySQLSearchTaskQuery string = `
+SELECT ID , Title , Task_Description , Task_Status , Version , Workflow_Status ,
+  MATCH (Title, Task_Description) AGAINST (? IN BOOLEAN MODE) AS Score
+FROM TaskStore
+WHERE Deleted_At IS NULL AND MATCH (Title, Task_Description) AGAINST (? IN BOOLEAN MODE)
+ORDER BY Score DESC , ID ASC
+LIMIT ? OFFSET ?
+;
+`
+
+const MySQLCountSearchTaskQuery string = `
+SELECT COUNT(*) FROM TaskStore
+WHERE Deleted_At IS NULL AND MATCH (Title, Task_Description) AGAINST (? IN BOOLEAN MODE)
+;
+`
+
+// SearchFingerprintQuery changes whenever a task is added, written or
+// removed, since every write bumps Version. SearchIndex compares it to tell
+// whether it still reflects the table.
+const SearchFingerprintQuery string = `
+SELECT COUNT(*) , COALESCE(SUM(Version), 0) , COALESCE(MAX(ID), 0) FROM TaskStore
+;
+`
+
+const SearchIndexQuery string = `
+SELECT ` + TaskColumns + ` FROM TaskStore
+WHERE Deleted_At IS NULL
+;
+`
+
+const (
+	highlightOpen  string = "<mark>"
+	highlightClose string = "</mark>"
+	snippetWords   int    = 12
+)
+
+// Highlight HTML escapes Text and wraps every match of Terms in <mark>. With
+// Window above zero only about Window words around the first match are kept.
+func Highlight(Text string, Terms []SearchTerm, Window int) string {
+	spans := tokenSpans(Text)
+	words := []string{}
+	for _, span := range spans {
+		words = append(words, strings.ToLower(Text[span[0]:span[1]]))
+	}
+
+	marked := make([]bool, len(words))
+	first := -1
+	for position := range words {
+		for _, term := range Terms {
+			covered := term.matchesAt(words, position)
+			for offset := 0; offset < covered; offset++ {
+				marked[position+offset] = true
+			}
+			if covered > 0 && first < 0 {
+				first = position
+			}
+		}
+	}
+
+	from, to := 0, len(words)
+	if Window > 0 && len(words) > Window {
+		if first < 0 {
+			first = 0
+		}
+		from = max(0, min(first-Window/3, len(words)-Window))
+		to = from + Window
+	}
+
+	builder := strings.Builder{}
+	if from > 0 {
+		builder.WriteString("… ")
+	}
+
+	cursor := 0
+	if from < len(spans) {
+		cursor = spans[from][0]
+	}
+
+	for position := from; position < to; position++ {
+		span := spans[position]
+		builder.WriteString(html.EscapeString(Text[cursor:span[0]]))
+		if marked[position] && (position == from || !marked[position-1]) {
+			builder.WriteString(highlightOpen)
+		}
+		builder.WriteString(html.EscapeString(Text[span[0]:span[1]]))
+		if marked[position] && (position == to-1 || !marked[position+1]) {
+			builder.WriteString(highlightClose)
+		}
+		cursor = span[1]
+	}
+
+	if to < len(words) {
+		builder.WriteString(" …")
+	} else {
+		builder.WriteString(html.EscapeString(Text[cursor:]))
+	}
+
+	return builder.String()
+}
+
+// highlightHit fills the snippets of Hit for Terms.
+func highlightHit(Hit SearchHit, Terms []SearchTerm) SearchHit {
+	Hit.Title_Snippet = Highlight(Hit.Task.Task.Title, Terms, 0)
+	Hit.Description_Snippet = Highlight(Hit.Task.Task.Task_Description, Terms, snippetWords)
+	return Hit
+}
+
+// BM25 parameters, and how much more a title match weighs than a
+// description match.
+const (
+	bm25K1      float64 = 1.2
+	bm25B       float64 = 0.75
+	titleWeight float64 = 2
+)
+
+type indexedTask struct {
+	Task        TaskStoreResponse
+	Title       []string
+	Description []string
+}
+
+// SearchIndex is the in-process inverted index behind SearchTask for
+// backends without a native full-text index. It ranks with BM25 over Title
+// and Task_Description and checks phrases against word positions.
+type SearchIndex struct {
+	mutex    sync.RWMutex
+	docs     map[int64]indexedTask
+	postings map[string]map[int64]bool
+	// fingerprint identifies the table state the index was built from, see
+	// SearchFingerprintQuery.
+	fingerprint [3]int64
+}
+
+func NewSearchIndex() *SearchIndex {
+	return &SearchIndex{
+		docs:     map[int64]indexedTask{},
+		postings: map[string]map[int64]bool{},
+	}
+}
+
+// Put indexes Task, replacing any earlier version of it.
+func (Index *SearchIndex) Put(Task TaskStoreResponse) {
+	Index.mutex.Lock()
+	defer Index.mutex.Unlock()
+	Index.put(Task)
+}
+
+func (Index *SearchIndex) put(Task TaskStoreResponse) {
+	Index.remove(Task.ID)
+
+	doc := indexedTask{
+		Task:        Task,
+		Title:       tokenize(Task.Task.Title),
+		Description: tokenize(Task.Task.Task_Description),
+	}
+	Index.docs[Task.ID] = doc
+
+	for _, word := range append(append([]string{}, doc.Title...), doc.Description...) {
+		if Index.postings[word] == nil {
+			Index.postings[word] = map[int64]bool{}
+		}
+		Index.postings[word][Task.ID] = true
+	}
+}
+
+// Remove drops task ID from the index.
+func (Index *SearchIndex) Remove(ID int64) {
+	Index.mutex.Lock()
+	defer Index.mutex.Unlock()
+	Index.remove(ID)
+}
+
+func (Index *SearchIndex) remove(ID int64) {
+	doc, ok := Index.docs[ID]
+	if !ok {
+		return
+	}
+
+	delete(Index.docs, ID)
+
+	for _, word := range append(append([]string{}, doc.Title...), doc.Description...) {
+		delete(Index.postings[word], ID)
+		if len(Index.postings[word]) <= 0 {
+			delete(Index.postings, word)
+		}
+	}
+}
+
+// refresh rebuilds the index from Load unless it was already built from the
+// table state Fingerprint.
+func (Index *SearchIndex) refresh(Fingerprint [3]int64, Load func() ([]TaskStoreResponse, error)) error {
+	Index.mutex.Lock()
+	defer Index.mutex.Unlock()
+
+	if Index.fingerprint == Fingerprint {
+		return nil
+	}
+
+	tasks, err := Load()
+	if err != nil {
+		return err
+	}
+
+	Index.docs = map[int64]indexedTask{}
+	Index.postings = map[string]map[int64]bool{}
+	for _, task := range tasks {
+		Index.put(task)
+	}
+	Index.fingerprint = Fingerprint
+
+	return nil
+}
+
+// candidates returns the documents holding every word of Term, or a word
+// starting with it for a prefix.
+func (Index *SearchIndex) candidates(Term SearchTerm) map[int64]bool {
+	found := map[int64]bool{}
+
+	last := Term.Words[len(Term.Words)-1]
+	if Term.Prefix {
+		for word, docs := range Index.postings {
+			if strings.HasPrefix(word, last) {
+				for id := range docs {
+					found[id] = true
+				}
+			}
+		}
+	} else {
+		for id := range Index.postings[last] {
+			found[id] = true
+		}
+	}
+
+	for _, word := range Term.Words[:len(Term.Words)-1] {
+		for id := range found {
+			if !Index.postings[word][id] {
+				delete(found, id)
+			}
+		}
+	}
+
+	return found
+}
+
+// Search returns one page of the tasks matching every term of Terms, best
+// match first, and the number of matching tasks.
+func (Index *SearchIndex) Search(Terms []SearchTerm, Limit int64, Offset int64) ([]SearchHit, int64) {
+	Index.mutex.RLock()
+	defer Index.mutex.RUnlock()
+
+	if len(Terms) <= 0 || len(Index.docs) <= 0 {
+		return []SearchHit{}, 0
+	}
+
+	var titleLength, descriptionLength float64
+	for _, doc := range Index.docs {
+		titleLength += float64(len(doc.Title))
+		descriptionLength += float64(len(doc.Description))
+	}
+	titleLength = max(titleLength/float64(len(Index.docs)), 1)
+	descriptionLength = max(descriptionLength/float64(len(Index.docs)), 1)
+
+	var matched map[int64]bool
+	idf := make([]float64, len(Terms))
+
+	for index, term := range Terms {
+		found := Index.candidates(term)
+		count := float64(len(found))
+		idf[index] = math.Log(1 + (float64(len(Index.docs))-count+0.5)/(count+0.5))
+
+		if matched == nil {
+			matched = found
+			continue
+		}
+		for id := range matched {
+			if !found[id] {
+				delete(matched, id)
+			}
+		}
+	}
+
+	hits := []SearchHit{}
+
+	for id := range matched {
+		doc := Index.docs[id]
+		score := 0.0
+		complete := true
+
+		for index, term := range Terms {
+			titleFrequency := float64(term.frequency(doc.Title))
+			descriptionFrequency := float64(term.frequency(doc.Description))
+
+			// Phrases must match as a whole, not just as separate words.
+			if titleFrequency+descriptionFrequency <= 0 {
+				complete = false
+				break
+			}
+
+			score += idf[index] * (titleWeight*bm25(titleFrequency, float64(len(doc.Title)), titleLength) +
+				bm25(descriptionFrequency, float64(len(doc.Description)), descriptionLength))
+		}
+
+		if complete {
+			hits = append(hits, SearchHit{Task: doc.Task, Score: score})
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Task.ID < hits[j].Task.ID
+	})
+
+	total := int64(len(hits))
+	if Offset > total {
+		Offset = total
+	}
+	hits = hits[Offset:]
+	if int64(len(hits)) > Limit {
+		hits = hits[:Limit]
+	}
+
+	for index := range hits {
+		hits[index] = highlightHit(hits[index], Terms)
+	}
+
+	return hits, total
+}
+
+func bm25(Frequency float64, Length float64, AverageLength float64) float64 {
+	return Frequency * (bm25K1 + 1) / (Frequency + bm25K1*(1-bm25B+bm25B*Length/AverageLength))
+}
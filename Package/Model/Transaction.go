@@ -0,0 +1,140 @@
+package Model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"expvar"
+	"math/rand/v2"
+	"time"
+)
+
+// Serializable transactions that touch the same rows can fail only because
+// they ran at the same time: MySQL picks a deadlock victim or gives up
+// waiting for a lock, SQLite reports the database as busy. transact runs
+// such a transaction again, from the start, instead of failing the request.
+
+// RetryPolicy says how often and how fast transact runs a transaction again.
+// The delay before retry n is random, up to BaseDelay doubled n-1 times and
+// never more than MaxDelay.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   10 * time.Millisecond,
+	MaxDelay:    250 * time.Millisecond,
+}
+
+// TxMetrics counts the transactions of every ModelStruct in the process. It
+// is published through expvar as "transactions" and holds:
+//
+//	committed          transactions that committed, retried or not
+//	failed             transactions that gave up with an error
+//	retries            transactions run again after a retryable error
+//	retries.<reason>   the same, per reason the Dialect reported
+//	retries_exhausted  retryable failures that ran out of attempts or time
+var TxMetrics = expvar.NewMap("transactions")
+
+// backoff is the delay before retry Attempt, counted from 1.
+func (Policy RetryPolicy) backoff(Attempt int) time.Duration {
+	ceiling := Policy.BaseDelay << (Attempt - 1)
+	if ceiling <= 0 || ceiling > Policy.MaxDelay {
+		ceiling = Policy.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling) + 1
+}
+
+// transact runs Body in a transaction with Model.TxOption and commits it.
+// When Body or the commit fails with an error the Dialect reports as
+// retryable, the transaction is rolled back and run again after a jittered
+// backoff, as long as Model.Retry allows and the deadline of Ctx leaves
+// time for it. Body must only keep its results once it returns nil.
+func (Model *ModelStruct) transact(Ctx context.Context, Body func(Tx *sql.Tx) error) error {
+	policy := Model.Retry
+	if policy.MaxAttempts < 1 {
+		policy = DefaultRetryPolicy
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := Model.attempt(Ctx, Body)
+
+		if err == nil {
+			TxMetrics.Add("committed", 1)
+			return nil
+		}
+
+		reason := Model.retryable(err)
+
+		if len(reason) <= 0 {
+			TxMetrics.Add("failed", 1)
+			return Model.classify(err)
+		}
+
+		if attempt >= policy.MaxAttempts || !sleep(Ctx, policy.backoff(attempt)) {
+			TxMetrics.Add("retries_exhausted", 1)
+			TxMetrics.Add("failed", 1)
+			return Model.classify(err)
+		}
+
+		TxMetrics.Add("retries", 1)
+		TxMetrics.Add("retries."+reason, 1)
+	}
+}
+
+// attempt runs Body once in a new transaction.
+func (Model *ModelStruct) attempt(Ctx context.Context, Body func(Tx *sql.Tx) error) error {
+	db, err := Model.Config.SqlDBConn.BeginTx(Ctx, &Model.TxOption)
+
+	if err != nil {
+		return err
+	}
+
+	err = Body(db)
+
+	if err != nil {
+		// A transaction whose context is done was already rolled back, that
+		// is not worth reporting. Any other rollback failure goes with err,
+		// which still decides whether the transaction runs again.
+		nerr := db.Rollback()
+		if nerr != nil && !errors.Is(nerr, sql.ErrTxDone) {
+			return errors.Join(err, nerr)
+		}
+		return err
+	}
+
+	return db.Commit()
+}
+
+// retryable names why Err may go away when the transaction runs again, or
+// returns "" when it will not.
+func (Model *ModelStruct) retryable(Err error) string {
+	if Model.Dialect.RetryableError == nil {
+		return ""
+	}
+	return Model.Dialect.RetryableError(Err)
+}
+
+// sleep waits for Delay and reports whether it did. It returns false right
+// away when the deadline of Ctx would pass first, and as soon as Ctx is done.
+func sleep(Ctx context.Context, Delay time.Duration) bool {
+	if deadline, ok := Ctx.Deadline(); ok && time.Until(deadline) <= Delay {
+		return false
+	}
+
+	timer := time.NewTimer(Delay)
+	defer timer.Stop()
+
+	select {
+	case <-Ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
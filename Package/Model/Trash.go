@@ -0,0 +1,72 @@
+package Model
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Soft deleted tasks form the trash: their Deleted_At is the time they were
+// deleted, it is NULL for every other task.
+
+const TrashedTaskQuery string = `
+SELECT ` + TaskColumns + ` FROM TaskStore
+WHERE Deleted_At IS NOT NULL AND ID = ?
+;
+`
+
+const RestoreTaskQuery string = `
+UPDATE TaskStore
+SET Deleted_At = NULL , Edited_On = CURRENT_TIMESTAMP() , Version = Version + 1
+WHERE ID = ? AND Version = ? AND Deleted_At IS NOT NULL
+;
+`
+
+const SQLiteRestoreTaskQuery string = `
+UPDATE TaskStore
+SET Deleted_At = NULL , Edited_On = CURRENT_TIMESTAMP , Version = Version + 1
+WHERE ID = ? AND Version = ? AND Deleted_At IS NOT NULL
+;
+`
+
+const PurgeTaskQuery string = `
+DELETE FROM TaskStore
+WHERE ID = ? AND Version = ? AND Deleted_At IS NOT NULL
+;
+`
+
+// PurgeTrashQuery and ExpiredTrashQuery compare Deleted_At, filled by
+// CURRENT_TIMESTAMP(), with a UTC bound. That holds because the Configurator
+// pins MySQL sessions to UTC, a purge in the wrong zone cannot be undone.
+const PurgeTrashQuery string = `
+DELETE FROM TaskStore
+WHERE Deleted_At < ?
+;
+`
+
+// TrashPurgeInterval is how often PurgeTrashEvery looks for expired tasks.
+const TrashPurgeInterval time.Duration = time.Hour
+
+// PurgeTrashEvery permanently deletes the tasks that have been in the trash
+// for longer than Retention, right away and then every Interval, until Ctx is
+// done. Failures are logged and retried on the next run.
+func PurgeTrashEvery(Ctx context.Context, Store ModelInterface, Retention time.Duration, Interval time.Duration) {
+	ticker := time.NewTicker(Interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := Store.PurgeTrash(Ctx, PurgeTrashStore{Before: time.Now().Add(-Retention)})
+
+		if err != nil {
+			slog.Error("purging the trash failed", "error", err)
+		} else if purged > 0 {
+			slog.Info("purged the trash", "tasks", purged, "retention", Retention)
+		}
+
+		select {
+		case <-Ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
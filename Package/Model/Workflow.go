@@ -0,0 +1,142 @@
+package Model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Workflow is the state machine of Workflow_Status. A task starts in
+// Initial and TransitionTask only moves it along Transitions. Terminal
+// states have no way out.
+type Workflow struct {
+	Initial     string
+	Transitions map[string][]string
+	Terminal    []string
+}
+
+// DefaultWorkflow is used unless the configuration describes another one.
+var DefaultWorkflow = Workflow{
+	Initial: "todo",
+	Transitions: map[string][]string{
+		"todo":        {"in_progress", "cancelled"},
+		"in_progress": {"todo", "done", "cancelled"},
+	},
+	Terminal: []string{"done", "cancelled"},
+}
+
+// TransitionError is a move the Workflow does not allow from the current
+// Workflow_Status of a task.
+type TransitionError struct {
+	ID      int64
+	From    string
+	To      string
+	Allowed []string
+}
+
+func (Err *TransitionError) Error() string {
+	if len(Err.Allowed) <= 0 {
+		return fmt.Sprintf("Task %d is %s, which is final", Err.ID, Err.From)
+	}
+	return fmt.Sprintf("Task %d cannot move from %s to %s, only to [%s]", Err.ID, Err.From, Err.To, strings.Join(Err.Allowed, " "))
+}
+
+// ParseWorkflow reads a Workflow from its configuration: the initial state,
+// transitions written as "from>to" and terminal states, both comma
+// separated, such as "todo>done, done>todo". All empty gives DefaultWorkflow.
+func ParseWorkflow(Initial string, Transitions string, Terminal string) (Workflow, error) {
+	if len(Initial) <= 0 && len(Transitions) <= 0 && len(Terminal) <= 0 {
+		return DefaultWorkflow, nil
+	}
+
+	flow := Workflow{
+		Initial:     strings.TrimSpace(Initial),
+		Transitions: map[string][]string{},
+		Terminal:    splitList(Terminal),
+	}
+
+	for _, transition := range splitList(Transitions) {
+		from, to, ok := strings.Cut(transition, ">")
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+		if !ok || len(from) <= 0 || len(to) <= 0 {
+			return Workflow{}, fmt.Errorf("workflow transition %q must look like from>to", transition)
+		}
+		flow.Transitions[from] = append(flow.Transitions[from], to)
+	}
+
+	return flow, flow.Validate()
+}
+
+// Validate checks that Flow is a usable state machine.
+func (Flow Workflow) Validate() error {
+	states := Flow.States()
+
+	if len(Flow.Initial) <= 0 {
+		return fmt.Errorf("workflow has no initial state")
+	}
+
+	for _, state := range states {
+		if !isWorkflowState(state) {
+			return fmt.Errorf("workflow state %q may only hold letters, digits and _", state)
+		}
+	}
+
+	for _, state := range Flow.Terminal {
+		if len(Flow.Transitions[state]) > 0 {
+			return fmt.Errorf("workflow state %q is terminal but has transitions", state)
+		}
+	}
+
+	for _, state := range states {
+		if !contains(Flow.Terminal, state) && len(Flow.Transitions[state]) <= 0 {
+			return fmt.Errorf("workflow state %q has no transitions, list it as terminal", state)
+		}
+	}
+
+	return nil
+}
+
+func isWorkflowState(State string) bool {
+	if len(State) <= 0 || len(State) > 32 {
+		return false
+	}
+	for _, char := range State {
+		if !(char == '_' || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+// States lists every state Flow mentions, sorted.
+func (Flow Workflow) States() []string {
+	states := []string{}
+	add := func(State string) {
+		if !contains(states, State) {
+			states = append(states, State)
+		}
+	}
+
+	add(Flow.Initial)
+	for from, targets := range Flow.Transitions {
+		add(from)
+		for _, to := range targets {
+			add(to)
+		}
+	}
+	for _, state := range Flow.Terminal {
+		add(state)
+	}
+
+	sort.Strings(states)
+	return states
+}
+
+// transition checks the move of task ID from From to To.
+func (Flow Workflow) transition(ID int64, From string, To string) error {
+	allowed := Flow.Transitions[From]
+	if contains(allowed, To) {
+		return nil
+	}
+	return &TransitionError{ID: ID, From: From, To: To, Allowed: allowed}
+}
@@ -4,23 +4,122 @@ import (
 	"TaskManager/Helper/Startup"
 	"TaskManager/Package/Configurator"
 	"TaskManager/Package/Controller"
+	"TaskManager/Package/Migrator"
 	"TaskManager/Package/Model"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func main() {
+	memory := flag.Bool("memory", false, "serve tasks from an in-memory store instead of the configured database")
+	skipMigrations := flag.Bool("skip-migrations", false, "do not apply pending schema migrations at startup")
+
 	config := Configurator.NewConfigurator()
-	config.LoadConfig(Startup.DebugMode)
+	config.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	mode, err := Startup.ModeFromEnv()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	config.NoDatabase = *memory
+	err = config.LoadConfig(mode, flag.CommandLine)
+
+	if flag.Arg(0) == "config" {
+		err = configCommand(*config, Startup.ModeNames[mode], err, flag.Args()[1:])
+
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	err := config.LoadDBInstance()
+	// log.Fatal stays unfiltered, LogLevel only holds back the slog lines.
+	slog.SetLogLoggerLevel(config.LogLevel)
+
+	workflow, err := Model.ParseWorkflow(config.WorkflowInitial, config.WorkflowTransitions, config.WorkflowTerminal)
 
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	mdl := Model.NewModel(*config)
+	var store taskStore
+
+	if *memory {
+		mdl := Model.NewMemoryModel()
+		mdl.Workflow = workflow
+		mdl.PageTokenKey = Model.NewPageTokenKey(config.PageTokenSecret)
+
+		store = mdl
+	} else {
+		err = config.LoadDBInstance()
+
+		if err != nil {
+			log.Fatal(err)
+		}
 
-	controller := Controller.NewController(&mdl)
+		if flag.Arg(0) == "migrate" {
+			err = migrate(*config, flag.Args()[1:])
+
+			if err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+
+		if !*skipMigrations {
+			err = migrate(*config, []string{"up"})
+
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		mdl, err := Model.NewModel(*config)
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		mdl.Workflow = workflow
+
+		store = &mdl
+	}
+
+	stopPurges := startPurges(store, config.Reloadable())
+
+	reloader := Configurator.NewReloader(config, mode, flag.CommandLine)
+	reloader.Subscribe(func(Previous Configurator.ReloadableSettings, Current Configurator.ReloadableSettings) {
+		slog.SetLogLoggerLevel(Current.LogLevel)
+
+		stopPurges()
+		stopPurges = startPurges(store, Current)
+	})
+
+	go func() {
+		err := reloader.Watch(context.Background())
+
+		if err != nil {
+			slog.Warn("configuration will not be reloaded", "error", err)
+		}
+	}()
+
+	controller := Controller.NewController(store)
 
 	err = controller.StartServer(config.Address)
 
@@ -29,3 +128,150 @@ func main() {
 	}
 
 }
+
+// taskStore is the model main serves, the database or the -memory one,
+// whose idempotency window follows the configuration reloads.
+type taskStore interface {
+	Model.ModelInterface
+	SetIdempotencyWindow(Window time.Duration)
+}
+
+// startPurges applies the idempotency window of Settings to Store and starts
+// the jobs that purge the expired trash and idempotency keys. The returned
+// function stops the jobs.
+func startPurges(Store taskStore, Settings Configurator.ReloadableSettings) context.CancelFunc {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	window := time.Duration(Settings.IdempotencyWindowHours) * time.Hour
+	Store.SetIdempotencyWindow(window)
+
+	if Settings.TrashRetentionDays > 0 {
+		retention := time.Duration(Settings.TrashRetentionDays) * 24 * time.Hour
+		go Model.PurgeTrashEvery(ctx, Store, retention, Model.TrashPurgeInterval)
+	}
+
+	go Model.PurgeIdempotencyKeysEvery(ctx, Store, window, Model.IdempotencyPurgeInterval)
+
+	return cancelFunc
+}
+
+// configCommand runs `config check`, which reports whether the
+// configuration of the mode is valid without connecting to the database or
+// serving, or `config encrypt <key file>`, which prints the enc: value of
+// the secret read from standard input. LoadErr is what loading the
+// configuration returned.
+func configCommand(Config Configurator.ConfiguratorStruct, ModeName string, LoadErr error, Args []string) error {
+	switch {
+	case len(Args) == 1 && Args[0] == "check":
+		if LoadErr != nil {
+			return LoadErr
+		}
+
+		_, err := Model.ParseWorkflow(Config.WorkflowInitial, Config.WorkflowTransitions, Config.WorkflowTerminal)
+
+		if err != nil {
+			return fmt.Errorf("invalid %s configuration: workflow: %w", ModeName, err)
+		}
+
+		fmt.Printf("%s configuration OK\n", ModeName)
+
+	case len(Args) == 2 && Args[0] == "encrypt":
+		secret, err := io.ReadAll(os.Stdin)
+
+		if err != nil {
+			return err
+		}
+
+		value, err := Configurator.EncryptSecret(Args[1], strings.TrimRight(string(secret), "\r\n"))
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(value)
+
+	default:
+		return errors.New("usage: config check | encrypt <key file>")
+	}
+
+	return nil
+}
+
+// migrate runs `migrate up`, `migrate down [steps]`, `migrate status` or
+// `migrate resolve <version> applied|pending`, which clears a migration left
+// dirty once the schema has been repaired by hand.
+func migrate(Config Configurator.ConfiguratorStruct, Args []string) error {
+	migrator, err := Migrator.NewMigrator(Config)
+
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	if len(Args) <= 0 {
+		return errors.New("usage: migrate up | down [steps] | status | resolve <version> applied|pending")
+	}
+
+	switch Args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		for _, migration := range applied {
+			slog.Info(fmt.Sprintf("applied migration %04d_%s", migration.Version, migration.Name))
+		}
+
+	case "down":
+		steps := 1
+		if len(Args) > 1 {
+			steps, err = strconv.Atoi(Args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", Args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		for _, migration := range reverted {
+			slog.Info(fmt.Sprintf("reverted migration %04d_%s", migration.Version, migration.Name))
+		}
+
+	case "status":
+		statusList, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statusList {
+			state := "pending"
+			if status.Applied {
+				state = "applied " + status.AppliedAt
+			}
+			if status.Dirty {
+				state = "dirty, repair it and run migrate resolve"
+			}
+			fmt.Printf("%04d_%s\t%s\n", status.Migration.Version, status.Migration.Name, state)
+		}
+
+	case "resolve":
+		if len(Args) != 3 || (Args[2] != "applied" && Args[2] != "pending") {
+			return errors.New("usage: migrate resolve <version> applied|pending")
+		}
+		version, err := strconv.ParseInt(Args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", Args[1])
+		}
+		err = migrator.Resolve(ctx, version, Args[2] == "applied")
+		if err != nil {
+			return err
+		}
+		slog.Info(fmt.Sprintf("resolved migration %04d as %s", version, Args[2]))
+
+	default:
+		return fmt.Errorf("unknown migrate command %q", Args[0])
+	}
+
+	return nil
+}